@@ -19,6 +19,7 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	_ "time/tzdata" // часовые пояса сводок: в образе нет системной базы tzdata
 )
 
 // @title           Calendar Service API
@@ -44,45 +44,147 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/event": {
+        "/v1/admin/outbox": {
             "get": {
-                "description": "Возвращает список событий за период, заданный query-параметрами start и end",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает сообщения outbox (без payload) по фильтру, по возрастанию id. Для следующей страницы передайте afterID = nextAfterID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Event"
+                    "Outbox admin"
                 ],
-                "summary": "Получение событий за период",
+                "summary": "Список сообщений outbox",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Дата/время начала периода (например, 2026-01-01T00:00:00Z)",
-                        "name": "start",
-                        "in": "query",
-                        "required": true
+                        "description": "Статусы через запятую: NEW, SENT, FAILED, GAVE_UP",
+                        "name": "status",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Дата/время конца периода (например, 2026-01-31T23:59:59Z)",
-                        "name": "end",
-                        "in": "query",
-                        "required": true
+                        "description": "Тип сообщения (event_created, event_updated, ...)",
+                        "name": "eventType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID события",
+                        "name": "aggregateID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Создано не позже, чем столько назад (например, 1h)",
+                        "name": "olderThan",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Создано не раньше, чем столько назад (например, 24h)",
+                        "name": "newerThan",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Курсор: id последнего сообщения предыдущей страницы",
+                        "name": "afterID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 50, максимум 500)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/calendar_internal_application_entity.Event"
-                            }
+                            "$ref": "#/definitions/calendar_internal_application_entity.OutboxPage"
                         }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/outbox/requeue": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает в очередь relay все сообщения FAILED/GAVE_UP, подходящие под фильтр",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Outbox admin"
+                ],
+                "summary": "Массовая повторная отправка outbox",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "FAILED и/или GAVE_UP через запятую (по умолчанию оба)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип сообщения",
+                        "name": "eventType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID события",
+                        "name": "aggregateID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Создано не позже, чем столько назад (например, 1h)",
+                        "name": "olderThan",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Создано не раньше, чем столько назад (например, 24h)",
+                        "name": "newerThan",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
                     "409": {
                         "description": "Conflict"
                     },
@@ -90,28 +192,121 @@ const docTemplate = `{
                         "description": "Internal Server Error"
                     }
                 }
-            },
-            "put": {
-                "description": "Обновляет существующее событие по данным из тела запроса",
-                "consumes": [
+            }
+        },
+        "/v1/admin/outbox/sent": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Удаляет сообщения в статусе SENT, созданные раньше olderThan (по умолчанию все отправленные)",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Outbox admin"
+                ],
+                "summary": "Удаление отправленных сообщений outbox",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Возраст сообщения (например, 168h)",
+                        "name": "olderThan",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/outbox/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает сообщение outbox вместе с payload и причиной последней ошибки",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Event"
+                    "Outbox admin"
                 ],
-                "summary": "Обновление события",
+                "summary": "Сообщение outbox",
                 "parameters": [
                     {
-                        "description": "Данные события для обновления",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "ID сообщения outbox",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/calendar_internal_application_entity.Event"
+                            "$ref": "#/definitions/calendar_internal_application_entity.OutboxEvent"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/admin/outbox/{id}/requeue": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Возвращает сообщение в статусе FAILED или GAVE_UP в очередь relay: статус NEW, attempts = 0, next_attempt_at = now()",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Outbox admin"
+                ],
+                "summary": "Повторная отправка сообщения outbox",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID сообщения outbox",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -121,16 +316,63 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad Request"
                     },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
                     "404": {
                         "description": "Not Found"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/calendar": {
+            "get": {
+                "description": "Возвращает календари пользователя, основной - первым",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Calendar"
+                ],
+                "summary": "Календари пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "userID",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/calendar_internal_application_entity.Calendar"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             },
             "post": {
-                "description": "Создает новое событие и записывает его в БД",
+                "description": "Создаёт календарь пользователя. isDefault: true делает его основным вместо прежнего",
                 "consumes": [
                     "application/json"
                 ],
@@ -138,53 +380,86 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Event"
+                    "Calendar"
                 ],
-                "summary": "Создание события",
+                "summary": "Создание календаря",
                 "parameters": [
                     {
-                        "description": "Данные события",
+                        "description": "Календарь",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/calendar_internal_application_entity.Event"
+                            "$ref": "#/definitions/calendar_internal_application_entity.CalendarCreate"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.Calendar"
+                        }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
-                    "409": {
-                        "description": "Conflict"
-                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             }
         },
-        "/v1/event/{id}": {
-            "delete": {
-                "description": "Удаляет событие по идентификатору",
-                "consumes": [
+        "/v1/calendar/{id}": {
+            "get": {
+                "description": "Возвращает календарь по идентификатору",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "Calendar"
+                ],
+                "summary": "Календарь",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID календаря",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.Calendar"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет календарь без событий. Основной календарь и календарь с событиями удалить нельзя - 409",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Event"
+                    "Calendar"
                 ],
-                "summary": "Удаление события",
+                "summary": "Удаление календаря",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "ID события",
+                        "description": "ID календаря",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -200,23 +475,949 @@ const docTemplate = `{
                     "404": {
                         "description": "Not Found"
                     },
+                    "409": {
+                        "description": "Conflict"
+                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "calendar_internal_application_entity.Event": {
-            "type": "object",
-            "properties": {
-                "RqTm": {
-                    "description": "time request",
-                    "type": "string"
-                },
-                "creationDate": {
-                    "type": "string"
+            },
+            "patch": {
+                "description": "Меняет переданные поля календаря; пустые color и timeZone сбрасывают значение.\nisDefault: true делает календарь основным вместо прежнего",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Calendar"
+                ],
+                "summary": "Изменение календаря",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID календаря",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Изменения",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.CalendarPatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.Calendar"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/event": {
+            "get": {
+                "description": "Возвращает список событий за период, заданный query-параметрами start и end. Повторяющиеся события разворачиваются во вхождения периода.\nС userID - только события пользователя и события, в которых он участник; с calendarID - только события этих календарей\nФормат ответа выбирается по заголовку Accept (application/json или text/calendar); /v1/event.ics всегда отдаёт iCalendar",
+                "produces": [
+                    "application/json",
+                    "text/calendar"
+                ],
+                "tags": [
+                    "Event"
+                ],
+                "summary": "Получение событий за период",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дата/время начала периода (например, 2026-01-01T00:00:00Z)",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата/время конца периода (например, 2026-01-31T23:59:59Z)",
+                        "name": "end",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя: его события и события, где он участник",
+                        "name": "userID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "ID календарей через запятую или повтором параметра",
+                        "name": "calendarID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/calendar_internal_application_entity.EventResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает новое событие и записывает его в БД. Без calendarID событие попадает в основной календарь владельца",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Event"
+                ],
+                "summary": "Создание события",
+                "parameters": [
+                    {
+                        "description": "Данные события",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.Event"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Обновляет существующее событие по данным из тела запроса",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Event"
+                ],
+                "summary": "Обновление события",
+                "parameters": [
+                    {
+                        "description": "Данные события для обновления",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.Event"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/event.ics": {
+            "get": {
+                "description": "Возвращает список событий за период, заданный query-параметрами start и end. Повторяющиеся события разворачиваются во вхождения периода.\nС userID - только события пользователя и события, в которых он участник; с calendarID - только события этих календарей\nФормат ответа выбирается по заголовку Accept (application/json или text/calendar); /v1/event.ics всегда отдаёт iCalendar",
+                "produces": [
+                    "application/json",
+                    "text/calendar"
+                ],
+                "tags": [
+                    "Event"
+                ],
+                "summary": "Получение событий за период",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Дата/время начала периода (например, 2026-01-01T00:00:00Z)",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дата/время конца периода (например, 2026-01-31T23:59:59Z)",
+                        "name": "end",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID пользователя: его события и события, где он участник",
+                        "name": "userID",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "ID календарей через запятую или повтором параметра",
+                        "name": "calendarID",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/calendar_internal_application_entity.EventResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "409": {
+                        "description": "Conflict"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/event/import": {
+            "post": {
+                "description": "Разбирает VEVENT из .ics (multipart-поле file или тело text/calendar) и создаёт события через outbox (event_created).\nДля каждого UID возвращает статус: created, duplicate или rejected с ошибками валидации",
+                "consumes": [
+                    "multipart/form-data",
+                    "text/calendar"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Event"
+                ],
+                "summary": "Импорт событий из iCalendar (.ics)",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Владелец импортируемых событий",
+                        "name": "userID",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Файл .ics",
+                        "name": "file",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.ImportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type"
+                    }
+                }
+            }
+        },
+        "/v1/event/{id}": {
+            "delete": {
+                "description": "Удаляет событие по идентификатору",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Event"
+                ],
+                "summary": "Удаление события",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID события",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/event/{id}/attendees": {
+            "get": {
+                "description": "Возвращает участников события или серии с ролью, ответом (partstat) и комментарием",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Attendee"
+                ],
+                "summary": "Участники события",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID события",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/calendar_internal_application_entity.Attendee"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Приглашает участников в событие или серию (invitation_sent в outbox по каждому новому участнику).\nУже приглашённому меняется роль, его ответ сохраняется. Переопределение вхождения - 404",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Attendee"
+                ],
+                "summary": "Приглашение участников",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID события",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Участники",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.InviteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/calendar_internal_application_entity.Attendee"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/event/{id}/attendees/{userID}": {
+            "put": {
+                "description": "Сохраняет ответ участника (accepted, declined, tentative, needs-action) и комментарий.\nИзменённый ответ пишет rsvp_changed в outbox, организатору уходит уведомление",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Attendee"
+                ],
+                "summary": "Ответ на приглашение",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID события",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID участника",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ответ",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.RSVPRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.Attendee"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/event/{id}/occurrence": {
+            "put": {
+                "description": "Изменяет одно вхождение повторяющегося события. Изменение хранится как переопределение, серия не меняется",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Event"
+                ],
+                "summary": "Переопределение вхождения серии",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID серии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные вхождения",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.EventOccurrence"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Отменяет одно вхождение повторяющегося события, остальные вхождения серии не меняются",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Event"
+                ],
+                "summary": "Отмена вхождения серии",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID серии",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Исходное время начала вхождения (RFC3339)",
+                        "name": "recurrenceID",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/v1/notification-settings/{userID}": {
+            "get": {
+                "description": "Возвращает адреса каналов уведомлений (email, webhook) и язык шаблонов пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "Настройки уведомлений пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.NotificationSettings"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "description": "Создаёт или заменяет настройки уведомлений. Пустой email - письма пользователю не отправляются,\nпустой webhookURL - используется notification.webhook.url, пустой locale - язык по умолчанию.\nwebhookURL на loopback или частный адрес отклоняется",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notification"
+                ],
+                "summary": "Изменение настроек уведомлений пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID пользователя",
+                        "name": "userID",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Настройки уведомлений",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.NotificationSettings"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/calendar_internal_application_entity.NotificationSettings"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "calendar_internal_application_entity.Attendee": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "eventID": {
+                    "type": "string"
+                },
+                "invitedAt": {
+                    "type": "string"
+                },
+                "partstat": {
+                    "$ref": "#/definitions/calendar_internal_application_entity.PartStat"
+                },
+                "respondedAt": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/calendar_internal_application_entity.AttendeeRole"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "calendar_internal_application_entity.AttendeeInvite": {
+            "type": "object",
+            "required": [
+                "userID"
+            ],
+            "properties": {
+                "role": {
+                    "enum": [
+                        "required",
+                        "optional"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/calendar_internal_application_entity.AttendeeRole"
+                        }
+                    ]
+                },
+                "userID": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                }
+            }
+        },
+        "calendar_internal_application_entity.AttendeeRole": {
+            "type": "string",
+            "enum": [
+                "required",
+                "optional"
+            ],
+            "x-enum-varnames": [
+                "AttendeeRequired",
+                "AttendeeOptional"
+            ]
+        },
+        "calendar_internal_application_entity.Calendar": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "isDefault": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "timeZone": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "calendar_internal_application_entity.CalendarCreate": {
+            "type": "object",
+            "required": [
+                "name",
+                "userID"
+            ],
+            "properties": {
+                "color": {
+                    "type": "string",
+                    "maxLength": 7
+                },
+                "isDefault": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                },
+                "timeZone": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                }
+            }
+        },
+        "calendar_internal_application_entity.CalendarPatch": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string",
+                    "maxLength": 7
+                },
+                "isDefault": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                },
+                "timeZone": {
+                    "type": "string"
+                }
+            }
+        },
+        "calendar_internal_application_entity.Event": {
+            "type": "object",
+            "required": [
+                "creationDate",
+                "dateEvent",
+                "durationEvent",
+                "id",
+                "title",
+                "userID"
+            ],
+            "properties": {
+                "RqTm": {
+                    "description": "time request",
+                    "type": "string"
+                },
+                "calendarID": {
+                    "description": "Календарь владельца; пусто - основной календарь пользователя (в PATCH - календарь не меняется,\nпри смене userID событие переходит в основной календарь нового владельца)",
+                    "type": "string"
+                },
+                "creationDate": {
+                    "type": "string"
+                },
+                "dateEvent": {
+                    "type": "string"
+                },
+                "descriptionEvent": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "durationEvent": {
+                    "type": "string"
+                },
+                "exdate": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "rdate": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reminders": {
+                    "description": "Напоминания: за offset до начала или в момент at, по каналу channel. timeForNotification - сокращённая\nзапись ещё одного напоминания по email. В PATCH не-nil список заменяет напоминания события,\ntimeForNotification при этом не затрагивается.",
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "$ref": "#/definitions/calendar_internal_application_entity.Reminder"
+                    }
+                },
+                "rrule": {
+                    "description": "Повторение по RFC 5545: RRULE без DTSTART (DTSTART = dateEvent), RDATE и EXDATE в RFC3339.\nВ PATCH nil не меняет правило, пустая строка его сбрасывает.",
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "timeForNotification": {
+                    "type": "string"
+                },
+                "timeZone": {
+                    "description": "Часовой пояс IANA, в котором разворачивается серия; пусто - пояс календаря (в PATCH - не меняется)",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "minLength": 1
+                },
+                "userID": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 1
+                }
+            }
+        },
+        "calendar_internal_application_entity.EventOccurrence": {
+            "type": "object",
+            "required": [
+                "recurrenceID"
+            ],
+            "properties": {
+                "cancelled": {
+                    "type": "boolean"
+                },
+                "dateEvent": {
+                    "type": "string"
+                },
+                "descriptionEvent": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "durationEvent": {
+                    "type": "string"
+                },
+                "recurrenceID": {
+                    "type": "string"
+                },
+                "timeForNotification": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 200
+                }
+            }
+        },
+        "calendar_internal_application_entity.EventResponse": {
+            "type": "object",
+            "properties": {
+                "RqTm": {
+                    "description": "time request",
+                    "type": "string"
+                },
+                "calendarID": {
+                    "type": "string"
+                },
+                "cancelled": {
+                    "type": "boolean"
+                },
+                "creationDate": {
+                    "type": "string"
                 },
                 "dateEvent": {
                     "type": "string"
@@ -227,15 +1428,51 @@ const docTemplate = `{
                 "durationEvent": {
                     "type": "string"
                 },
+                "exdate": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "string"
                 },
+                "rdate": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "recurrenceID": {
+                    "type": "string"
+                },
+                "reminders": {
+                    "description": "Напоминания события, включая timeForNotification",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/calendar_internal_application_entity.ReminderResponse"
+                    }
+                },
+                "rrule": {
+                    "description": "Серия: правило повторения и явные включения/исключения дат",
+                    "type": "string"
+                },
+                "seriesID": {
+                    "description": "Вхождение серии: ID серии и исходное время начала вхождения.\nДля развёрнутых вхождений ID совпадает с SeriesID, для переопределений - свой ID.",
+                    "type": "string"
+                },
                 "timeForNotification": {
                     "type": "string"
                 },
+                "timeZone": {
+                    "type": "string"
+                },
                 "title": {
                     "type": "string"
                 },
+                "updatedAt": {
+                    "type": "string"
+                },
                 "userID": {
                     "type": "string"
                 }
@@ -288,6 +1525,381 @@ const docTemplate = `{
                     "$ref": "#/definitions/calendar_internal_application_entity.HealthCheckItem"
                 }
             }
+        },
+        "calendar_internal_application_entity.ImportResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/calendar_internal_application_entity.ImportResult"
+                    }
+                },
+                "rejected": {
+                    "type": "integer"
+                }
+            }
+        },
+        "calendar_internal_application_entity.ImportResult": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "eventID": {
+                    "type": "string"
+                },
+                "recurrenceID": {
+                    "description": "для переопределений вхождений серии",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/calendar_internal_application_entity.ImportStatus"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "calendar_internal_application_entity.ImportStatus": {
+            "type": "string",
+            "enum": [
+                "created",
+                "duplicate",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "ImportCreated",
+                "ImportDuplicate",
+                "ImportRejected"
+            ]
+        },
+        "calendar_internal_application_entity.InviteRequest": {
+            "type": "object",
+            "required": [
+                "attendees"
+            ],
+            "properties": {
+                "attendees": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/calendar_internal_application_entity.AttendeeInvite"
+                    }
+                }
+            }
+        },
+        "calendar_internal_application_entity.NotificationSettings": {
+            "type": "object",
+            "required": [
+                "userID"
+            ],
+            "properties": {
+                "digestChannel": {
+                    "enum": [
+                        "email",
+                        "push",
+                        "webhook"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/calendar_internal_application_entity.ReminderChannel"
+                        }
+                    ]
+                },
+                "digestEnabled": {
+                    "type": "boolean"
+                },
+                "digestTime": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string",
+                    "enum": [
+                        "ru",
+                        "en"
+                    ]
+                },
+                "timeZone": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "userID": {
+                    "type": "string"
+                },
+                "webhookURL": {
+                    "type": "string"
+                }
+            }
+        },
+        "calendar_internal_application_entity.OutboxAggregate": {
+            "type": "string",
+            "enum": [
+                "event",
+                "user"
+            ],
+            "x-enum-comments": {
+                "AggregateUser": "aggregate_id - UserAggregateID(userID)"
+            },
+            "x-enum-descriptions": [
+                "",
+                "aggregate_id - UserAggregateID(userID)"
+            ],
+            "x-enum-varnames": [
+                "AggregateEvent",
+                "AggregateUser"
+            ]
+        },
+        "calendar_internal_application_entity.OutboxEvent": {
+            "type": "object",
+            "properties": {
+                "aggregateID": {
+                    "description": "events.id (без FK: сообщение переживает удаление события)",
+                    "type": "string"
+                },
+                "aggregateType": {
+                    "description": "\"event\"",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/calendar_internal_application_entity.OutboxAggregate"
+                        }
+                    ]
+                },
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "errorClass": {
+                    "description": "класс ошибки (producer.ClassifyRetry)",
+                    "type": "string"
+                },
+                "eventType": {
+                    "description": "\"event_created\" / ...",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/calendar_internal_application_entity.OutboxEventType"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "description": "причина последней неудачной отправки",
+                    "type": "string"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "payload": {
+                    "description": "JSONB для Kafka",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "status": {
+                    "description": "NEW | SENT | FAILED",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/calendar_internal_application_entity.OutboxStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "calendar_internal_application_entity.OutboxEventType": {
+            "type": "string",
+            "enum": [
+                "event_created",
+                "event_updated",
+                "event_deleted",
+                "event_reminder",
+                "agenda_digest",
+                "invitation_sent",
+                "rsvp_changed"
+            ],
+            "x-enum-varnames": [
+                "EventCreated",
+                "EventUpdated",
+                "EventDeleted",
+                "EventReminder",
+                "AgendaDigest",
+                "InvitationSent",
+                "RSVPChanged"
+            ]
+        },
+        "calendar_internal_application_entity.OutboxPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/calendar_internal_application_entity.OutboxEvent"
+                    }
+                },
+                "nextAfterID": {
+                    "type": "integer"
+                }
+            }
+        },
+        "calendar_internal_application_entity.OutboxStatus": {
+            "type": "string",
+            "enum": [
+                "NEW",
+                "SENT",
+                "FAILED",
+                "GAVE_UP"
+            ],
+            "x-enum-varnames": [
+                "OutboxNew",
+                "OutboxSent",
+                "OutboxFailed",
+                "OutboxGaveUp"
+            ]
+        },
+        "calendar_internal_application_entity.PartStat": {
+            "type": "string",
+            "enum": [
+                "needs-action",
+                "accepted",
+                "declined",
+                "tentative"
+            ],
+            "x-enum-varnames": [
+                "PartStatNeedsAction",
+                "PartStatAccepted",
+                "PartStatDeclined",
+                "PartStatTentative"
+            ]
+        },
+        "calendar_internal_application_entity.RSVPRequest": {
+            "type": "object",
+            "required": [
+                "partstat"
+            ],
+            "properties": {
+                "comment": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "partstat": {
+                    "enum": [
+                        "needs-action",
+                        "accepted",
+                        "declined",
+                        "tentative"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/calendar_internal_application_entity.PartStat"
+                        }
+                    ]
+                }
+            }
+        },
+        "calendar_internal_application_entity.Reminder": {
+            "type": "object",
+            "required": [
+                "channel"
+            ],
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "channel": {
+                    "enum": [
+                        "email",
+                        "push",
+                        "webhook"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/calendar_internal_application_entity.ReminderChannel"
+                        }
+                    ]
+                },
+                "offset": {
+                    "type": "string"
+                }
+            }
+        },
+        "calendar_internal_application_entity.ReminderChannel": {
+            "type": "string",
+            "enum": [
+                "email",
+                "email",
+                "push",
+                "webhook",
+                "email",
+                "email"
+            ],
+            "x-enum-varnames": [
+                "InvitationChannel",
+                "ReminderEmail",
+                "ReminderPush",
+                "ReminderWebhook",
+                "NotificationReminderChannel",
+                "DefaultDigestChannel"
+            ]
+        },
+        "calendar_internal_application_entity.ReminderResponse": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "channel": {
+                    "$ref": "#/definitions/calendar_internal_application_entity.ReminderChannel"
+                },
+                "offset": {
+                    "type": "string"
+                },
+                "remindAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/calendar_internal_application_entity.ReminderStatus"
+                }
+            }
+        },
+        "calendar_internal_application_entity.ReminderStatus": {
+            "type": "string",
+            "enum": [
+                "PENDING",
+                "FIRED",
+                "MISSED"
+            ],
+            "x-enum-comments": {
+                "ReminderMissed": "время прошло больше чем на cron.reminderMaxLateness, не отправлено"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "время прошло больше чем на cron.reminderMaxLateness, не отправлено"
+            ],
+            "x-enum-varnames": [
+                "ReminderPending",
+                "ReminderFired",
+                "ReminderMissed"
+            ]
         }
     },
     "securityDefinitions": {
@@ -299,13 +1911,14 @@ const docTemplate = `{
     }
 }`
 
+// SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
 	Host:             "",
-	BasePath:         "/api",
+	BasePath:         "/calendar/api",
 	Schemes:          []string{},
 	Title:            "Calendar Service API",
-	Description:      "Микросервис для событий календаря",
+	Description:      "Микросервис календарь",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
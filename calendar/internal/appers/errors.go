@@ -32,6 +32,82 @@ var (
 		http.StatusForbidden,
 		"уже создан",
 	}
+	ErrOccurrenceNotFound = ErrorResp{
+		http.StatusNotFound,
+		"вхождение серии не найдено",
+	}
+	ErrOverrideSeriesFields = ErrorResp{
+		http.StatusUnprocessableEntity,
+		"rrule, rdate, exdate и timeZone меняются у серии, а не у переопределения вхождения",
+	}
+	ErrOverrideCalendar = ErrorResp{
+		http.StatusUnprocessableEntity,
+		"календарь и владелец меняются у серии, а не у переопределения вхождения",
+	}
+	ErrOutboxNotFound = ErrorResp{
+		http.StatusNotFound,
+		"сообщение outbox не найдено",
+	}
+	ErrOutboxNotRequeueable = ErrorResp{
+		http.StatusConflict,
+		"повторно отправить можно только сообщения в статусе FAILED или GAVE_UP",
+	}
+	ErrOutboxInvalidFilter = ErrorResp{
+		http.StatusBadRequest,
+		"недопустимый фильтр outbox",
+	}
+	ErrForbidden = ErrorResp{
+		http.StatusForbidden,
+		"недостаточно прав",
+	}
+	ErrUnauthorized = ErrorResp{
+		http.StatusUnauthorized,
+		"требуется авторизация",
+	}
+	ErrMalformedMessage = ErrorResp{
+		http.StatusBadRequest,
+		"некорректное сообщение Kafka",
+	}
+	ErrNoMessageHandler = ErrorResp{
+		http.StatusUnprocessableEntity,
+		"нет обработчика для сообщения Kafka",
+	}
+	ErrNotificationSettingsNotFound = ErrorResp{
+		http.StatusNotFound,
+		"настройки уведомлений не найдены",
+	}
+	ErrNoNotificationTemplate = ErrorResp{
+		http.StatusUnprocessableEntity,
+		"нет шаблона уведомления для сообщения",
+	}
+	ErrWebhookURLNotAllowed = ErrorResp{
+		http.StatusBadRequest,
+		"webhookURL должен быть публичным http(s) адресом (не loopback и не частная сеть)",
+	}
+	ErrAttendeeNotFound = ErrorResp{
+		http.StatusNotFound,
+		"участник события не найден",
+	}
+	ErrAttendeeIsOrganizer = ErrorResp{
+		http.StatusUnprocessableEntity,
+		"организатор события не может быть его участником",
+	}
+	ErrCalendarNotFound = ErrorResp{
+		http.StatusNotFound,
+		"календарь не найден",
+	}
+	ErrDefaultCalendar = ErrorResp{
+		http.StatusConflict,
+		"основной календарь нельзя удалить",
+	}
+	ErrCalendarNotEmpty = ErrorResp{
+		http.StatusConflict,
+		"в календаре есть события",
+	}
+	ErrCalendarOwner = ErrorResp{
+		http.StatusUnprocessableEntity,
+		"календарь принадлежит другому пользователю",
+	}
 	ErrEventFormatDate = ErrorResp{
 		StatusCode: http.StatusBadRequest,
 		StatusDesc: "не верный формат даты, должен быть YYYY-MM-DD",
@@ -2,19 +2,25 @@ package application
 
 import (
 	"calendar/internal/application/common"
+	"calendar/internal/application/entity"
+	"calendar/internal/application/notification"
 	"calendar/internal/application/repo"
 	"calendar/internal/application/service"
 	"calendar/internal/application/use-cases"
 	"calendar/internal/controllers/cron"
 	"calendar/internal/controllers/handler"
 	"calendar/internal/controllers/listener"
+	"calendar/internal/transport/notify"
 	"calendar/internal/transport/producer"
+	"calendar/internal/transport/sink"
 	"calendar/pkg/broker"
 	"calendar/pkg/config"
 	"calendar/pkg/db"
+	"calendar/pkg/httpclient"
 	"calendar/pkg/metrics"
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
@@ -55,20 +61,36 @@ func NewApp(
 
 	store := repo.NewRepo(postgres, logger)
 	tx := repo.NewTransactions(store, logger)
-	kafkaProducer := producer.NewProducer(kafkaBroker, logger, conf.Broker.Kafka.MaxAttempts, m)
-	srv := service.NewService(store, tx, kafkaProducer, logger, &conf.Realay)
+	kafkaProducer := producer.NewProducer(kafkaBroker, logger, conf.Broker.Kafka.MaxAttempts, conf.Broker.Kafka.CloudEvents, m)
+	sinks := newSinks(conf, kafkaProducer, logger, m)
+	notifier := newNotifier(conf, logger, m)
+	srv := service.NewService(store, tx, kafkaProducer, sinks, notifier, logger, &conf.Realay)
 	uc := use_cases.NewUseCase(srv, logger, conf)
 	h := handler.NewEventHandler(uc, logger)
-	r := handler.NewRouter(h, httpServer, conf, logger)
+	dav := handler.NewDavHandler(uc, logger)
+	admin := handler.NewOutboxAdminHandler(uc, logger)
+	r := handler.NewRouter(h, dav, admin, httpServer, conf, logger)
 
 	// Инициализация cron контроллера
 	cronController := cron.NewController(ctx, logger)
 	if err := cronController.RegisterDeleteOldEventsJob(uc, conf.Cron); err != nil {
 		logger.Fatalf("не удалось зарегистрировать cron задачу: %v", err)
 	}
+	if err := cronController.RegisterOutboxRetentionJob(uc, conf.Cron, m); err != nil {
+		logger.Fatalf("не удалось зарегистрировать cron задачу: %v", err)
+	}
+	if err := cronController.RegisterReminderJob(uc, conf.Cron, m); err != nil {
+		logger.Fatalf("не удалось зарегистрировать cron задачу: %v", err)
+	}
+	if err := cronController.RegisterAgendaDigestJob(uc, conf.Cron, m); err != nil {
+		logger.Fatalf("не удалось зарегистрировать cron задачу: %v", err)
+	}
 	cronController.Start()
 
 	go uc.RunRelay(ctx)
+	if !conf.Notification.Disabled {
+		go uc.RunNotifier(ctx)
+	}
 
 	r.RegisterRouter()
 
@@ -81,11 +103,80 @@ func NewApp(
 		cronController: cronController,
 	}
 
-	go app.runConsumer(ctx, logger, uc, kafkaBroker)
+	go app.runConsumer(ctx, logger, uc, kafkaBroker, kafkaProducer, m)
 
 	return app
 }
 
+// newSinks собирает получателей relay: Kafka (если не выключен) и webhook (если задан URL)
+func newSinks(conf *config.Config, kafkaProducer producer.Producer, logger *zap.SugaredLogger, m *metrics.Metrics) *sink.Router {
+	sc := conf.Realay.Sinks
+	router := sink.NewRouter(logger, m)
+	if !sc.Kafka.Disabled {
+		router.Add(sink.NewKafkaSink(kafkaProducer), sc.Kafka.EventTypes)
+	}
+	if sc.Webhook.URL != "" {
+		client := httpclient.NewRetryClient(httpclient.NewClient(conf.HTTPClient), conf.HTTPClient.MaxRetries, logger)
+		source := conf.Broker.Kafka.CloudEvents.Source
+		if source == "" {
+			source = "/calendar"
+		}
+		router.Add(sink.NewWebhookSink(client, sc.Webhook, source, logger), sc.Webhook.EventTypes)
+	}
+	if len(router.Names()) == 0 {
+		logger.Warn("relay: нет ни одного sink, сообщения outbox будут отмечаться SENT без отправки")
+	}
+	return router
+}
+
+// newNotifier шаблоны и каналы уведомлений: email, если задан notification.smtp.addr, и webhook
+func newNotifier(conf *config.Config, logger *zap.SugaredLogger, m *metrics.Metrics) *service.Notifier {
+	nc := conf.Notification
+	templates, err := notification.NewTemplates(nc.DefaultLocale)
+	if err != nil {
+		logger.Fatalf("не удалось загрузить шаблоны уведомлений: %v", err)
+	}
+
+	var senders []notify.Sender
+	if nc.SMTP.Addr != "" {
+		smtpSender, err := notify.NewSMTPSender(nc.SMTP)
+		if err != nil {
+			logger.Fatalf("неверные настройки SMTP: %v", err)
+		}
+		senders = append(senders, smtpSender)
+	} else if !nc.Disabled {
+		logger.Warn("notification.smtp.addr не задан: email уведомления будут отмечаться SKIPPED")
+	}
+	senders = append(senders, notify.NewWebhookSender(httpclient.NewClient(conf.HTTPClient), nc.Webhook))
+
+	logger.Infow("notification templates loaded", "locales", templates.Locales())
+	return service.NewNotifier(templates, notify.NewSenders(senders...), nc, m)
+}
+
+// newHandlers реестр обработчиков консьюмера: readerTopic и broker.kafka.consumer.topics - команды и события
+// через inbox, event_reminder, agenda_digest, invitation_sent и rsvp_changed - в очередь уведомлений
+// (если они не выключены)
+func newHandlers(conf *config.Config, usecase use_cases.UseCaser) *listener.Registry {
+	handlers := listener.NewRegistry()
+	kc := conf.Broker.Kafka
+	topics := append([]string{kc.ReaderTopic}, strings.Split(kc.Consumer.Topics, ",")...)
+	for _, topic := range topics {
+		topic = strings.TrimSpace(topic)
+		if topic == "" {
+			continue
+		}
+		handlers.Register(topic, entity.CommandEventType, usecase.ConsumeCommand)
+		if !conf.Notification.Disabled {
+			handlers.Register(topic, string(entity.EventReminder), usecase.ConsumeNotification)
+			handlers.Register(topic, string(entity.AgendaDigest), usecase.ConsumeNotification)
+			handlers.Register(topic, string(entity.InvitationSent), usecase.ConsumeNotification)
+			handlers.Register(topic, string(entity.RSVPChanged), usecase.ConsumeNotification)
+		}
+		handlers.Register(topic, "", usecase.ConsumerMessage)
+	}
+	return handlers
+}
+
 func (a *App) Run() error {
 	return a.httpServer.Listen(fmt.Sprintf(":%s", a.conf.Server.Port))
 }
@@ -98,14 +189,14 @@ func (a *App) Shutdown() error {
 	return a.httpServer.Shutdown()
 }
 
-func (a *App) runConsumer(ctx context.Context, logger *zap.SugaredLogger, usecase use_cases.UseCaser, kafkaBroker *broker.KafkaBroker) {
-	logger.Infof("🚀 Запуск consumer для топика: %s", kafkaBroker.ConsumerTopic)
-
-	kafkaBrokerConsumer := listener.NewKafkaBrokerConsumer(usecase, logger)
+func (a *App) runConsumer(ctx context.Context, logger *zap.SugaredLogger, usecase use_cases.UseCaser, kafkaBroker *broker.KafkaBroker, kafkaProducer producer.Producer, m *metrics.Metrics) {
+	kafkaBrokerConsumer := listener.NewKafkaBrokerConsumer(newHandlers(a.conf, usecase), kafkaProducer, a.conf.Broker.Kafka, logger, m)
+	topics := kafkaBrokerConsumer.Topics()
+	logger.Infof("🚀 Запуск consumer для топиков: %v", topics)
 
 	for {
 		logger.Infof("🔄 Попытка подключения к consumer group...")
-		err := kafkaBroker.ConsumerGroup.Consume(ctx, []string{kafkaBroker.ConsumerTopic}, kafkaBrokerConsumer)
+		err := kafkaBroker.ConsumerGroup.Consume(ctx, topics, kafkaBrokerConsumer)
 		if err != nil {
 			logger.Errorf("Ошибка consumer: %v", err)
 		}
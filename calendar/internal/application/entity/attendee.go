@@ -0,0 +1,84 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type AttendeeRole string
+
+const (
+	AttendeeRequired AttendeeRole = "required"
+	AttendeeOptional AttendeeRole = "optional"
+)
+
+// PartStat статус участия по RFC 5545 (PARTSTAT) в нижнем регистре
+type PartStat string
+
+const (
+	PartStatNeedsAction PartStat = "needs-action"
+	PartStatAccepted    PartStat = "accepted"
+	PartStatDeclined    PartStat = "declined"
+	PartStatTentative   PartStat = "tentative"
+)
+
+// InvitationChannel канал уведомлений о приглашениях и ответах на них
+const InvitationChannel = ReminderEmail
+
+// Attendee участник события. Участники задаются событию или серии целиком; участник видит событие
+// (и вхождения серии) в своей выдаче за период. Comment - комментарий участника к ответу.
+type Attendee struct {
+	EventID     uuid.UUID    `json:"eventID"`
+	UserID      string       `json:"userID"`
+	Role        AttendeeRole `json:"role"`
+	PartStat    PartStat     `json:"partstat"`
+	Comment     string       `json:"comment,omitempty"`
+	InvitedAt   time.Time    `json:"invitedAt"`
+	RespondedAt *time.Time   `json:"respondedAt,omitempty"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+// AttendeeInvite приглашаемый участник; пустая роль - required
+type AttendeeInvite struct {
+	UserID string       `json:"userID" validate:"required,min=1,max=100"`
+	Role   AttendeeRole `json:"role,omitempty" validate:"omitempty,oneof=required optional"`
+}
+
+// InviteRequest приглашение участников. Уже приглашённому меняется роль, его ответ сохраняется.
+type InviteRequest struct {
+	Attendees []AttendeeInvite `json:"attendees" validate:"required,min=1,max=100,dive"`
+}
+
+// RSVPRequest ответ участника на приглашение
+type RSVPRequest struct {
+	PartStat PartStat `json:"partstat" validate:"required,oneof=needs-action accepted declined tentative"`
+	Comment  string   `json:"comment,omitempty" validate:"omitempty,max=1000"`
+}
+
+// InvitationSentPayload тело invitation_sent: участник UserID приглашён организатором Organizer
+type InvitationSentPayload struct {
+	ID           uuid.UUID    `json:"id"`
+	UserID       string       `json:"userID"`
+	Organizer    string       `json:"organizer"`
+	Role         AttendeeRole `json:"role"`
+	Title        string       `json:"title"`
+	DateEvent    time.Time    `json:"dateEvent"`
+	EndDateEvent time.Time    `json:"durationEvent"`
+	RRule        string       `json:"rrule,omitempty"`
+	InvitedAt    time.Time    `json:"invitedAt"`
+}
+
+// RSVPChangedPayload тело rsvp_changed: участник UserID изменил ответ; уведомление уходит организатору
+type RSVPChangedPayload struct {
+	ID               uuid.UUID `json:"id"`
+	UserID           string    `json:"userID"`
+	Organizer        string    `json:"organizer"`
+	PartStat         PartStat  `json:"partstat"`
+	PreviousPartStat PartStat  `json:"previousPartstat"`
+	Comment          string    `json:"comment,omitempty"`
+	Title            string    `json:"title"`
+	DateEvent        time.Time `json:"dateEvent"`
+	EndDateEvent     time.Time `json:"durationEvent"`
+	RespondedAt      time.Time `json:"respondedAt"`
+}
@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// DefaultCalendarName название основного календаря, который создаётся пользователю с первым событием
+const DefaultCalendarName = "Основной"
+
+// Calendar календарь пользователя. Основной календарь (IsDefault) у пользователя один: в него попадают
+// события без calendarID, его нельзя удалить.
+type Calendar struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    string    `json:"userID"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color,omitempty"`
+	TimeZone  string    `json:"timeZone,omitempty"`
+	IsDefault bool      `json:"isDefault"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// CalendarCreate создание календаря; IsDefault делает его основным вместо прежнего
+type CalendarCreate struct {
+	UserID    string `json:"userID" validate:"required,min=1,max=100"`
+	Name      string `json:"name" validate:"required,min=1,max=100"`
+	Color     string `json:"color,omitempty" validate:"omitempty,hexcolor,max=7"`
+	TimeZone  string `json:"timeZone,omitempty" validate:"omitempty,timezone"`
+	IsDefault bool   `json:"isDefault"`
+}
+
+// CalendarPatch изменение календаря: nil - поле не меняется, пустые color и timeZone сбрасывают значение.
+// isDefault: true делает календарь основным, снять признак можно только назначив основным другой.
+type CalendarPatch struct {
+	Name      *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+	Color     *string `json:"color,omitempty" validate:"omitnil,max=7,hexcolor|len=0"`
+	TimeZone  *string `json:"timeZone,omitempty" validate:"omitnil,timezone|len=0"`
+	IsDefault *bool   `json:"isDefault,omitempty"`
+}
+
+// EventFilter фильтр выдачи событий за период: пусто - все события
+type EventFilter struct {
+	UserID      string      // события пользователя и события, где он участник
+	CalendarIDs []uuid.UUID // события этих календарей
+}
@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+type CommandType string
+
+// Команды над событиями из broker.kafka.readerTopic
+const (
+	CommandCreateEvent CommandType = "create_event"
+	CommandUpdateEvent CommandType = "update_event"
+	CommandDeleteEvent CommandType = "delete_event"
+)
+
+// CommandIDHeader заголовок Kafka с ID команды: по нему сообщение распознаётся как команда,
+// дедуплицируется в inbox и связывается с ответом в broker.kafka.replyTopic
+const CommandIDHeader = "command_id"
+
+// CommandEventType тип сообщения-команды для реестра обработчиков консьюмера
+const CommandEventType = "command"
+
+type CommandStatus string
+
+const (
+	CommandOK        CommandStatus = "ok"
+	CommandError     CommandStatus = "error"
+	CommandDuplicate CommandStatus = "duplicate" // команда с этим ID уже выполнена, повтор проигнорирован
+)
+
+// EventCommand тело команды. Event - для create_event и update_event (те же поля и валидация, что в REST),
+// ID - для delete_event.
+type EventCommand struct {
+	Type  CommandType `json:"type"`
+	Event *Event      `json:"event,omitempty"`
+	ID    string      `json:"id,omitempty"`
+}
+
+// CommandResult ответ на команду
+type CommandResult struct {
+	CommandID   string        `json:"commandID"`
+	Type        CommandType   `json:"type,omitempty"`
+	Status      CommandStatus `json:"status"`
+	EventID     string        `json:"eventID,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Details     []string      `json:"details,omitempty"`
+	ProcessedAt time.Time     `json:"processedAt"`
+}
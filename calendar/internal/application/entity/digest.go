@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// userNamespace пространство имён UUID v5 для агрегатов outbox без собственного UUID (пользователь)
+var userNamespace = uuid.Must(uuid.FromString("6f1f8c2e-3b0a-5d7e-9c41-2a8e5b7d0c13"))
+
+// UserAggregateID постоянный aggregate_id outbox для сообщений пользователя (agenda_digest)
+func UserAggregateID(userID string) uuid.UUID {
+	return uuid.NewV5(userNamespace, userID)
+}
+
+// AgendaItem событие (или вхождение серии) в сводке; время - в часовом поясе пользователя
+type AgendaItem struct {
+	ID               uuid.UUID  `json:"id"`
+	Title            string     `json:"title"`
+	DateEvent        time.Time  `json:"dateEvent"`
+	EndDateEvent     time.Time  `json:"durationEvent"`
+	DescriptionEvent string     `json:"descriptionEvent,omitempty"`
+	SeriesID         *uuid.UUID `json:"seriesID,omitempty"`
+	RecurrenceID     *time.Time `json:"recurrenceID,omitempty"`
+}
+
+// AgendaDigestPayload тело agenda_digest: события локального дня Date пользователя по порядку начала
+type AgendaDigestPayload struct {
+	UserID      string          `json:"userID"`
+	Channel     ReminderChannel `json:"channel"`
+	Date        string          `json:"date"` // YYYY-MM-DD в часовом поясе TimeZone
+	TimeZone    string          `json:"timeZone"`
+	Events      []AgendaItem    `json:"events"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+}
+
+// AgendaDay локальный день пользователя, в котором находится now: дата и границы [start, end) в UTC.
+// Длина дня при переходе на летнее/зимнее время - 23 или 25 часов.
+func AgendaDay(now time.Time, loc *time.Location) (date string, start, end time.Time) {
+	local := now.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	return day.Format(time.DateOnly), day.UTC(), day.AddDate(0, 0, 1).UTC()
+}
+
+// NewAgendaItem событие для сводки во времени loc
+func NewAgendaItem(e *EventResponse, loc *time.Location) AgendaItem {
+	item := AgendaItem{
+		ID:               e.ID,
+		Title:            e.Title,
+		DateEvent:        e.DateEvent.In(loc),
+		EndDateEvent:     e.EndDateEvent.In(loc),
+		DescriptionEvent: e.DescriptionEvent,
+		SeriesID:         e.SeriesID,
+	}
+	if e.RecurrenceID != nil {
+		recurrenceID := e.RecurrenceID.In(loc)
+		item.RecurrenceID = &recurrenceID
+	}
+	return item
+}
@@ -16,6 +16,23 @@ type Event struct {
 	UserID              string    `json:"userID" validate:"required,min=1,max=100"`
 	TimeForNotification string    `json:"timeForNotification" validate:"omitempty,rfc3339_optional"`
 	RqTm                string    `json:"RqTm" validate:"omitempty,rfc3339_optional"` //time request
+
+	// Календарь владельца; пусто - основной календарь пользователя (в PATCH - календарь не меняется,
+	// при смене userID событие переходит в основной календарь нового владельца)
+	CalendarID *uuid.UUID `json:"calendarID,omitempty"`
+
+	// Напоминания: за offset до начала или в момент at, по каналу channel. timeForNotification - сокращённая
+	// запись ещё одного напоминания по email. В PATCH не-nil список заменяет напоминания события,
+	// timeForNotification при этом не затрагивается.
+	Reminders []Reminder `json:"reminders,omitempty" validate:"omitempty,max=10,dive"`
+
+	// Повторение по RFC 5545: RRULE без DTSTART (DTSTART = dateEvent), RDATE и EXDATE в RFC3339.
+	// В PATCH nil не меняет правило, пустая строка его сбрасывает.
+	RRule  *string  `json:"rrule,omitempty" validate:"omitempty,max=1000"`
+	RDate  []string `json:"rdate,omitempty" validate:"omitempty,dive,rfc3339"`
+	ExDate []string `json:"exdate,omitempty" validate:"omitempty,dive,rfc3339"`
+	// Часовой пояс IANA, в котором разворачивается серия; пусто - пояс календаря (в PATCH - не меняется)
+	TimeZone string `json:"timeZone,omitempty" validate:"omitempty,timezone"`
 }
 
 type EventResponse struct {
@@ -28,4 +45,94 @@ type EventResponse struct {
 	UserID              string    `json:"userID"`
 	TimeForNotification time.Time `json:"timeForNotification"`
 	RqTm                time.Time `json:"RqTm"` //time request
+	UpdatedAt           time.Time `json:"updatedAt"`
+	CalendarID          uuid.UUID `json:"calendarID"`
+
+	// Напоминания события, включая timeForNotification
+	Reminders []ReminderResponse `json:"reminders,omitempty"`
+
+	// Серия: правило повторения и явные включения/исключения дат
+	RRule    string      `json:"rrule,omitempty"`
+	RDate    []time.Time `json:"rdate,omitempty"`
+	ExDate   []time.Time `json:"exdate,omitempty"`
+	TimeZone string      `json:"timeZone,omitempty"`
+
+	// Вхождение серии: ID серии и исходное время начала вхождения.
+	// Для развёрнутых вхождений ID совпадает с SeriesID, для переопределений - свой ID.
+	SeriesID     *uuid.UUID `json:"seriesID,omitempty"`
+	RecurrenceID *time.Time `json:"recurrenceID,omitempty"`
+	Cancelled    bool       `json:"cancelled,omitempty"`
+}
+
+// EventOccurrence переопределение (override) одного вхождения серии.
+// Пустые поля наследуются от серии, Cancelled отменяет вхождение.
+type EventOccurrence struct {
+	SeriesID            uuid.UUID `json:"-"`
+	RecurrenceID        string    `json:"recurrenceID" validate:"required,rfc3339"`
+	Title               string    `json:"title" validate:"omitempty,max=200"`
+	DateEvent           string    `json:"dateEvent" validate:"omitempty,rfc3339_optional"`
+	EndDateEvent        string    `json:"durationEvent" validate:"omitempty,rfc3339_optional"`
+	DescriptionEvent    string    `json:"descriptionEvent" validate:"omitempty,max=1000"`
+	TimeForNotification string    `json:"timeForNotification" validate:"omitempty,rfc3339_optional"`
+	Cancelled           bool      `json:"cancelled"`
+}
+
+// Changes возвращает поля, которые меняет PATCH (как в UpdateEvent: пустые строки не меняются,
+// не-nil rrule и списки rdate/exdate заменяются), с ключами по JSON-тегам
+func (e *Event) Changes() map[string]any {
+	changes := make(map[string]any)
+	set := func(key, value string) {
+		if value != "" {
+			changes[key] = value
+		}
+	}
+	set("title", e.Title)
+	set("descriptionEvent", e.DescriptionEvent)
+	set("userID", e.UserID)
+	set("dateEvent", e.DateEvent)
+	set("durationEvent", e.EndDateEvent)
+	set("creationDate", e.CreationDate)
+	set("RqTm", e.RqTm)
+	set("timeForNotification", e.TimeForNotification)
+	if e.RRule != nil {
+		changes["rrule"] = *e.RRule
+	}
+	if e.CalendarID != nil {
+		changes["calendarID"] = e.CalendarID
+	}
+	if e.RDate != nil {
+		changes["rdate"] = e.RDate
+	}
+	if e.ExDate != nil {
+		changes["exdate"] = e.ExDate
+	}
+	set("timeZone", e.TimeZone)
+	if e.Reminders != nil {
+		changes["reminders"] = e.Reminders
+	}
+	return changes
+}
+
+// RRuleValue правило повторения события, пустая строка - без правила
+func (e *Event) RRuleValue() string {
+	if e.RRule == nil {
+		return ""
+	}
+	return *e.RRule
+}
+
+// OccurrenceID детерминированный ID вхождения серии: ID его переопределения и UID вхождения в выгрузке .ics
+func OccurrenceID(seriesID uuid.UUID, recurrenceID time.Time) uuid.UUID {
+	return uuid.NewV5(seriesID, recurrenceID.UTC().Format(time.RFC3339))
+}
+
+// IsSeries возвращает true для события-серии
+func (e *EventResponse) IsSeries() bool {
+	return e.RRule != "" || len(e.RDate) > 0
+}
+
+// CollectionState состояние набора событий пользователя: меняется при любом создании, изменении или удалении
+type CollectionState struct {
+	Count     int
+	UpdatedAt time.Time
 }
@@ -0,0 +1,41 @@
+package entity
+
+import "github.com/gofrs/uuid"
+
+type ImportStatus string
+
+const (
+	ImportCreated   ImportStatus = "created"
+	ImportDuplicate ImportStatus = "duplicate"
+	ImportRejected  ImportStatus = "rejected"
+)
+
+// ImportResult результат импорта одного VEVENT
+type ImportResult struct {
+	UID          string       `json:"uid"`
+	RecurrenceID string       `json:"recurrenceID,omitempty"` // для переопределений вхождений серии
+	EventID      uuid.UUID    `json:"eventID,omitempty"`
+	Status       ImportStatus `json:"status"`
+	Errors       []string     `json:"errors,omitempty"`
+}
+
+// ImportResponse ответ на импорт .ics
+type ImportResponse struct {
+	Created    int            `json:"created"`
+	Duplicates int            `json:"duplicates"`
+	Rejected   int            `json:"rejected"`
+	Items      []ImportResult `json:"items"`
+}
+
+// Add добавляет результат и обновляет счётчики
+func (r *ImportResponse) Add(res ImportResult) {
+	switch res.Status {
+	case ImportCreated:
+		r.Created++
+	case ImportDuplicate:
+		r.Duplicates++
+	case ImportRejected:
+		r.Rejected++
+	}
+	r.Items = append(r.Items, res)
+}
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// InboxMessage входящее сообщение Kafka для inbox консьюмера.
+// Для команд ID - "command:<command_id>": повтор команды с тем же ID не выполняется.
+type InboxMessage struct {
+	ID         string          `json:"id" db:"message_id"` // "<ce_source>:<ce_id>" или "<topic>/<partition>/<offset>"
+	Topic      string          `json:"topic" db:"topic"`
+	Partition  int32           `json:"partition" db:"partition"`
+	Offset     int64           `json:"offset" db:"offset"`
+	EventType  string          `json:"eventType,omitempty" db:"event_type"` // ce_type или тип команды
+	CommandID  string          `json:"commandID,omitempty"`                 // заголовок command_id: сообщение - команда
+	Payload    json.RawMessage `json:"payload,omitempty"`                   // data CloudEvent (в inbox не хранится)
+	ReceivedAt time.Time       `json:"receivedAt"`
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type NotificationStatus string
+
+const (
+	NotificationPending NotificationStatus = "PENDING"
+	NotificationSent    NotificationStatus = "SENT"
+	NotificationFailed  NotificationStatus = "FAILED"  // попытки исчерпаны или постоянная ошибка
+	NotificationSkipped NotificationStatus = "SKIPPED" // канал не настроен или нет адреса получателя
+)
+
+const (
+	DefaultDigestTime    = "08:00"
+	DefaultDigestChannel = ReminderEmail
+)
+
+// NotificationSettings настройки уведомлений пользователя. Пустой адрес - канал пользователю не доставляется
+// (для webhook используется notification.webhook.url), пустой locale - notification.defaultLocale.
+// Сводка событий дня (DigestEnabled) уходит в DigestTime по часовому поясу TimeZone (пусто - UTC).
+type NotificationSettings struct {
+	UserID        string          `json:"userID" validate:"required"`
+	Email         string          `json:"email,omitempty" validate:"omitempty,email"`
+	WebhookURL    string          `json:"webhookURL,omitempty" validate:"omitempty,url"`
+	Locale        string          `json:"locale,omitempty" validate:"omitempty,oneof=ru en"`
+	TimeZone      string          `json:"timeZone,omitempty" validate:"omitempty,timezone"`
+	DigestEnabled bool            `json:"digestEnabled"`
+	DigestTime    string          `json:"digestTime,omitempty" validate:"omitempty,datetime=15:04"`
+	DigestChannel ReminderChannel `json:"digestChannel,omitempty" validate:"omitempty,oneof=email push webhook"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+}
+
+// NotificationContent текст уведомления по шаблону типа сообщения и языка
+type NotificationContent struct {
+	Locale  string
+	Subject string
+	Text    string
+	HTML    string // пусто - у шаблона нет HTML-версии
+}
+
+// Notification уведомление к доставке одному получателю по одному каналу
+type Notification struct {
+	ID            int64
+	MessageID     string
+	EventType     OutboxEventType
+	UserID        string
+	Channel       ReminderChannel
+	Recipient     string // email или URL webhook
+	Content       NotificationContent
+	Payload       json.RawMessage // тело исходного сообщения, уходит в webhook как data
+	Status        NotificationStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+}
@@ -19,22 +19,72 @@ type OutboxAggregate string
 
 const (
 	AggregateEvent OutboxAggregate = "event"
+	AggregateUser  OutboxAggregate = "user" // aggregate_id - UserAggregateID(userID)
 )
 
 type OutboxEventType string
 
 const (
 	EventCreated OutboxEventType = "event_created"
+	EventUpdated OutboxEventType = "event_updated"
+	EventDeleted OutboxEventType = "event_deleted"
+
+	EventReminder OutboxEventType = "event_reminder"
+	AgendaDigest  OutboxEventType = "agenda_digest"
+
+	InvitationSent OutboxEventType = "invitation_sent"
+	RSVPChanged    OutboxEventType = "rsvp_changed"
 )
 
 type OutboxEvent struct {
-	ID            int             `db:"id"`
-	AggregateID   uuid.UUID       `db:"aggregate_id"`   // FK -> events.id
-	AggregateType OutboxAggregate `db:"aggregate_type"` // "event"
-	EventType     OutboxEventType `db:"event_type"`     // "event_created" / ...
-	Payload       json.RawMessage `db:"payload"`        // JSONB для Kafka
-	Status        OutboxStatus    `db:"status"`         // NEW | SENT | FAILED
-	Attempts      int             `db:"attempts"`
-	NextAttemptAt time.Time       `db:"next_attempt_at"`
-	CreatedAt     time.Time       `db:"created_at"`
+	ID            int             `json:"id" db:"id"`
+	AggregateID   uuid.UUID       `json:"aggregateID" db:"aggregate_id"`     // events.id (без FK: сообщение переживает удаление события)
+	AggregateType OutboxAggregate `json:"aggregateType" db:"aggregate_type"` // "event"
+	EventType     OutboxEventType `json:"eventType" db:"event_type"`         // "event_created" / ...
+	Payload       json.RawMessage `json:"payload,omitempty" db:"payload"`    // JSONB для Kafka
+	Status        OutboxStatus    `json:"status" db:"status"`                // NEW | SENT | FAILED
+	Attempts      int             `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt" db:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+	LastError     string          `json:"lastError,omitempty" db:"last_error"`   // причина последней неудачной отправки
+	ErrorClass    string          `json:"errorClass,omitempty" db:"error_class"` // класс ошибки (producer.ClassifyRetry)
+}
+
+// OutboxFilter фильтр сообщений outbox для администрирования
+type OutboxFilter struct {
+	Statuses    []OutboxStatus
+	EventType   OutboxEventType
+	AggregateID *uuid.UUID
+	OlderThan   time.Duration // created_at не позже now() - OlderThan
+	NewerThan   time.Duration // created_at не раньше now() - NewerThan
+	AfterID     int           // keyset-пагинация: id > AfterID
+	Limit       int
+}
+
+// OutboxPage страница списка outbox; NextAfterID - значение afterID для следующей страницы
+type OutboxPage struct {
+	Items       []OutboxEvent `json:"items"`
+	NextAfterID int           `json:"nextAfterID,omitempty"`
+}
+
+// EventUpdatedPayload тело event_updated: только изменённые поля события (ключи - JSON-поля entity.Event).
+// Для переопределения вхождения серии ID - ID серии, а изменения лежат в Occurrence.
+// Replaced - событие заменено целиком (CalDAV PUT): Changes содержит все поля, Occurrences - все переопределения.
+type EventUpdatedPayload struct {
+	ID          uuid.UUID          `json:"id"`
+	Changes     map[string]any     `json:"changes,omitempty"`
+	Occurrence  *EventOccurrence   `json:"occurrence,omitempty"`
+	Replaced    bool               `json:"replaced,omitempty"`
+	Occurrences []*EventOccurrence `json:"occurrences,omitempty"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+}
+
+// EventDeletedPayload тело event_deleted (tombstone). Удаление серии удаляет и переопределения её вхождений,
+// для удалённого переопределения заполнен SeriesID.
+type EventDeletedPayload struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    string     `json:"userID"`
+	SeriesID  *uuid.UUID `json:"seriesID,omitempty"`
+	Deleted   bool       `json:"deleted"`
+	DeletedAt time.Time  `json:"deletedAt"`
 }
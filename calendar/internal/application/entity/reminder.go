@@ -0,0 +1,138 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type ReminderStatus string
+
+const (
+	ReminderPending ReminderStatus = "PENDING"
+	ReminderFired   ReminderStatus = "FIRED"
+	ReminderMissed  ReminderStatus = "MISSED" // время прошло больше чем на cron.reminderMaxLateness, не отправлено
+)
+
+type ReminderChannel string
+
+const (
+	ReminderEmail   ReminderChannel = "email"
+	ReminderPush    ReminderChannel = "push"
+	ReminderWebhook ReminderChannel = "webhook"
+)
+
+// NotificationReminderKey ключ напоминания из timeForNotification (сокращённая запись одного напоминания)
+const NotificationReminderKey = "timeForNotification"
+
+// NotificationReminderChannel канал напоминания из timeForNotification
+const NotificationReminderChannel = ReminderEmail
+
+// MaxReminders максимум напоминаний у события, не считая timeForNotification
+const MaxReminders = 10
+
+// Reminder напоминание в запросе: Offset - за сколько до начала события (Go duration, например 10m или 24h)
+// либо At - абсолютное время (RFC3339), ровно одно из двух
+type Reminder struct {
+	Offset  string          `json:"offset,omitempty" validate:"omitempty,duration"`
+	At      string          `json:"at,omitempty" validate:"omitempty,rfc3339"`
+	Channel ReminderChannel `json:"channel" validate:"required,oneof=email push webhook"`
+}
+
+// ReminderResponse напоминание события: определение и ближайшее (или сработавшее) время
+type ReminderResponse struct {
+	Offset   string          `json:"offset,omitempty"`
+	At       *time.Time      `json:"at,omitempty"`
+	Channel  ReminderChannel `json:"channel"`
+	RemindAt time.Time       `json:"remindAt"`
+	Status   ReminderStatus  `json:"status"`
+}
+
+// ReminderSpec нормализованное напоминание для записи в БД: Key одинаков для равных напоминаний
+// (10m и 600s - одно напоминание), поэтому повторная запись не сбрасывает уже сработавшее
+type ReminderSpec struct {
+	Key           string
+	Channel       ReminderChannel
+	OffsetSeconds *int64
+	At            *time.Time
+}
+
+// Spec нормализует напоминание; формат полей проверяется валидатором
+func (r Reminder) Spec() (ReminderSpec, error) {
+	spec := ReminderSpec{Channel: r.Channel}
+	switch {
+	case r.Offset != "" && r.At != "":
+		return spec, fmt.Errorf("напоминание: нужно указать offset или at, но не оба")
+	case r.Offset != "":
+		d, err := time.ParseDuration(r.Offset)
+		if err != nil {
+			return spec, fmt.Errorf("неверный формат offset напоминания: %w", err)
+		}
+		if d < 0 {
+			return spec, fmt.Errorf("offset напоминания не может быть отрицательным")
+		}
+		seconds := int64(d / time.Second)
+		spec.OffsetSeconds = &seconds
+		spec.Key = fmt.Sprintf("%s|offset:%d", r.Channel, seconds)
+	case r.At != "":
+		at, err := time.Parse(time.RFC3339, r.At)
+		if err != nil {
+			return spec, fmt.Errorf("неверный формат at напоминания: %w", err)
+		}
+		at = at.UTC()
+		spec.At = &at
+		spec.Key = fmt.Sprintf("%s|at:%s", r.Channel, at.Format(time.RFC3339))
+	default:
+		return spec, fmt.Errorf("напоминание: нужно указать offset или at")
+	}
+	return spec, nil
+}
+
+// ReminderSpecs нормализованные напоминания события без timeForNotification (оно хранится отдельной строкой)
+func (e *Event) ReminderSpecs() ([]ReminderSpec, error) {
+	specs := make([]ReminderSpec, 0, len(e.Reminders))
+	seen := make(map[string]struct{}, len(e.Reminders))
+	for _, r := range e.Reminders {
+		spec, err := r.Spec()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[spec.Key]; ok {
+			return nil, fmt.Errorf("напоминание повторяется: %s", spec.Key)
+		}
+		seen[spec.Key] = struct{}{}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// DueReminder наступившее напоминание вместе с полями события для сообщения event_reminder
+type DueReminder struct {
+	ID           int64
+	EventID      uuid.UUID
+	Channel      ReminderChannel
+	RemindAt     time.Time
+	UserID       string
+	Title        string
+	DateEvent    time.Time
+	EndDateEvent time.Time
+	SeriesID     *uuid.UUID
+	RecurrenceID *time.Time // начало вхождения, если это напоминание серии
+}
+
+// EventReminderPayload тело event_reminder. Для переопределения вхождения ID - ID переопределения,
+// SeriesID - ID серии; для вхождения серии без переопределения ID - ID серии, RecurrenceID - начало вхождения.
+type EventReminderPayload struct {
+	ID           uuid.UUID       `json:"id"`
+	ReminderID   int64           `json:"reminderID"`
+	Channel      ReminderChannel `json:"channel"`
+	UserID       string          `json:"userID"`
+	Title        string          `json:"title"`
+	DateEvent    time.Time       `json:"dateEvent"`
+	EndDateEvent time.Time       `json:"durationEvent"`
+	SeriesID     *uuid.UUID      `json:"seriesID,omitempty"`
+	RecurrenceID *time.Time      `json:"recurrenceID,omitempty"`
+	RemindAt     time.Time       `json:"remindAt"`
+	FiredAt      time.Time       `json:"firedAt"`
+}
@@ -0,0 +1,148 @@
+package notification
+
+import (
+	"bytes"
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+const (
+	DefaultLocale = "ru"
+
+	subjectSuffix = ".subject.tmpl"
+	textSuffix    = ".txt.tmpl"
+	htmlSuffix    = ".html.tmpl"
+)
+
+// files шаблоны templates/<locale>/<тип сообщения>.{subject,txt,html}.tmpl; subject и txt обязательны
+//
+//go:embed templates
+var files embed.FS
+
+type templateSet struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template // nil - письмо только текстом
+}
+
+// Templates шаблоны уведомлений по типу сообщения и языку
+type Templates struct {
+	sets          map[entity.OutboxEventType]map[string]templateSet
+	defaultLocale string
+}
+
+// NewTemplates разбирает встроенные шаблоны; defaultLocale - язык для пользователей без настроек
+// и для языков без шаблона
+func NewTemplates(defaultLocale string) (*Templates, error) {
+	if defaultLocale == "" {
+		defaultLocale = DefaultLocale
+	}
+	t := &Templates{sets: make(map[entity.OutboxEventType]map[string]templateSet), defaultLocale: defaultLocale}
+
+	subjects, err := fs.Glob(files, "templates/*/*"+subjectSuffix)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range subjects {
+		locale := path.Base(path.Dir(name))
+		eventType := entity.OutboxEventType(strings.TrimSuffix(path.Base(name), subjectSuffix))
+		set, err := parseSet(strings.TrimSuffix(name, subjectSuffix))
+		if err != nil {
+			return nil, fmt.Errorf("template %s/%s: %w", locale, eventType, err)
+		}
+		if t.sets[eventType] == nil {
+			t.sets[eventType] = make(map[string]templateSet)
+		}
+		t.sets[eventType][locale] = set
+	}
+
+	for eventType, locales := range t.sets {
+		if _, ok := locales[defaultLocale]; !ok {
+			return nil, fmt.Errorf("template %s: no default locale %q", eventType, defaultLocale)
+		}
+	}
+	return t, nil
+}
+
+func parseSet(base string) (templateSet, error) {
+	var set templateSet
+	var err error
+	if set.subject, err = texttemplate.ParseFS(files, base+subjectSuffix); err != nil {
+		return set, err
+	}
+	if set.text, err = texttemplate.ParseFS(files, base+textSuffix); err != nil {
+		return set, err
+	}
+	if _, statErr := fs.Stat(files, base+htmlSuffix); statErr == nil {
+		if set.html, err = htmltemplate.ParseFS(files, base+htmlSuffix); err != nil {
+			return set, err
+		}
+	}
+	return set, nil
+}
+
+// Has есть ли шаблон для типа сообщения
+func (t *Templates) Has(eventType entity.OutboxEventType) bool {
+	_, ok := t.sets[eventType]
+	return ok
+}
+
+// Locales языки, для которых есть хотя бы один шаблон
+func (t *Templates) Locales() []string {
+	seen := make(map[string]struct{})
+	for _, locales := range t.sets {
+		for locale := range locales {
+			seen[locale] = struct{}{}
+		}
+	}
+	res := make([]string, 0, len(seen))
+	for locale := range seen {
+		res = append(res, locale)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Render текст уведомления на языке locale (если шаблона на нём нет - на языке по умолчанию)
+func (t *Templates) Render(eventType entity.OutboxEventType, locale string, data any) (entity.NotificationContent, error) {
+	locales, ok := t.sets[eventType]
+	if !ok {
+		return entity.NotificationContent{}, fmt.Errorf("[type: %s] %w", eventType, appers.ErrNoNotificationTemplate)
+	}
+	set, ok := locales[locale]
+	if !ok {
+		locale = t.defaultLocale
+		set = locales[locale]
+	}
+
+	content := entity.NotificationContent{Locale: locale}
+	var buf bytes.Buffer
+	if err := set.subject.Execute(&buf, data); err != nil {
+		return content, fmt.Errorf("render subject %s/%s: %w", locale, eventType, err)
+	}
+	// тема письма - одна строка
+	content.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := set.text.Execute(&buf, data); err != nil {
+		return content, fmt.Errorf("render text %s/%s: %w", locale, eventType, err)
+	}
+	content.Text = buf.String()
+
+	if set.html != nil {
+		buf.Reset()
+		if err := set.html.Execute(&buf, data); err != nil {
+			return content, fmt.Errorf("render html %s/%s: %w", locale, eventType, err)
+		}
+		content.HTML = buf.String()
+	}
+	return content, nil
+}
@@ -0,0 +1,294 @@
+package recurrence
+
+import (
+	"calendar/internal/application/entity"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+const (
+	// MaxCount наибольший COUNT правила повторения
+	MaxCount = 1000
+	// MaxOccurrences наибольшее число вхождений серии, которое Expand возвращает за один вызов;
+	// остальные вхождения периода отбрасываются
+	MaxOccurrences = 5000
+	// maxIterations наибольшее число вхождений, которое перебирается при разворачивании одной серии,
+	// включая вхождения до начала периода. SeriesEnd, не дойдя до конца, считает серию бесконечной.
+	maxIterations = 500000
+)
+
+// ParseRule проверяет и разбирает RRULE (RFC 5545) без DTSTART.
+// Допускается как "FREQ=WEEKLY;BYDAY=MO", так и "RRULE:FREQ=WEEKLY;BYDAY=MO".
+func ParseRule(rule string) (*rrule.ROption, error) {
+	rule = strings.TrimSpace(rule)
+	if rule == "" {
+		return nil, fmt.Errorf("empty rrule")
+	}
+	opt, err := rrule.StrToROption(rule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rrule %q: %w", rule, err)
+	}
+	return opt, nil
+}
+
+// NewSet собирает набор вхождений серии: RRULE + RDATE - EXDATE.
+// По RFC 5545 DTSTART всегда является первым вхождением, даже если не подходит под правило.
+func NewSet(rule string, dtstart time.Time, rdate, exdate []time.Time) (*rrule.Set, error) {
+	set := &rrule.Set{}
+	set.DTStart(dtstart)
+
+	if rule != "" {
+		opt, err := ParseRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		opt.Dtstart = dtstart
+		r, err := rrule.NewRRule(*opt)
+		if err != nil {
+			return nil, fmt.Errorf("build rrule: %w", err)
+		}
+		set.RRule(r)
+	}
+
+	set.RDate(dtstart)
+	for _, d := range rdate {
+		set.RDate(d)
+	}
+	for _, d := range exdate {
+		set.ExDate(d)
+	}
+
+	return set, nil
+}
+
+// Location часовой пояс серии по имени IANA; пусто - UTC
+func Location(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", name, err)
+	}
+	return loc, nil
+}
+
+// seriesSet набор вхождений серии master с DTSTART в её часовом поясе: правило считается по местному
+// времени, и вхождения сохраняют местное время начала при переходе на летнее время
+func seriesSet(master *entity.EventResponse) (*rrule.Set, error) {
+	loc, err := Location(master.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	return NewSet(master.RRule, master.DateEvent.In(loc), master.RDate, master.ExDate)
+}
+
+// SeriesEnd возвращает окончание последнего вхождения серии master (без учёта переопределений);
+// nil - серия бесконечна (RRULE без UNTIL и COUNT)
+func SeriesEnd(master *entity.EventResponse) (*time.Time, error) {
+	if master.RRule != "" {
+		opt, err := ParseRule(master.RRule)
+		if err != nil {
+			return nil, err
+		}
+		if opt.Count == 0 && opt.Until.IsZero() {
+			return nil, nil
+		}
+	}
+
+	set, err := seriesSet(master)
+	if err != nil {
+		return nil, fmt.Errorf("[event: %s] %w", master.ID, err)
+	}
+	last := master.DateEvent
+	next := set.Iterator()
+	for i := 0; ; i++ {
+		occ, ok := next()
+		if !ok {
+			break
+		}
+		if i == maxIterations {
+			// слишком длинная серия: считаем бесконечной, она выбирается в любой период
+			return nil, nil
+		}
+		last = occ
+	}
+	end := last.Add(master.EndDateEvent.Sub(master.DateEvent)).UTC()
+	return &end, nil
+}
+
+// IsOccurrence проверяет, что t является вхождением серии master
+func IsOccurrence(master *entity.EventResponse, t time.Time) (bool, error) {
+	set, err := seriesSet(master)
+	if err != nil {
+		return false, err
+	}
+	t = t.Truncate(time.Second)
+	for _, occ := range between(set, t, t) {
+		if occ.Equal(t) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ReminderLead за сколько до начала вхождения серии master срабатывает напоминание: offset напоминания,
+// а для timeForNotification - разница между началом серии и временем уведомления (как в Expand).
+// false - напоминание с абсолютным временем, к вхождениям оно не относится.
+func ReminderLead(master *entity.EventResponse, key string, offset *time.Duration) (time.Duration, bool) {
+	switch {
+	case offset != nil:
+		return *offset, true
+	case key == entity.NotificationReminderKey && !master.TimeForNotification.IsZero():
+		return master.DateEvent.Sub(master.TimeForNotification), true
+	default:
+		return 0, false
+	}
+}
+
+// NextReminder возвращает начало вхождения серии master, о котором должно сработать следующее напоминание
+// за lead до начала: первое вхождение, время напоминания которого позже after. Переопределённые
+// (в том числе отменённые) вхождения пропускаются - у переопределения свои напоминания.
+// ok = false - вхождений с напоминанием позже after больше нет.
+func NextReminder(master *entity.EventResponse, overrides []*entity.EventResponse, lead time.Duration, after time.Time) (time.Time, bool, error) {
+	set, err := seriesSet(master)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("[event: %s] %w", master.ID, err)
+	}
+
+	overridden := make(map[int64]struct{}, len(overrides))
+	for _, o := range overrides {
+		if o.RecurrenceID != nil {
+			overridden[o.RecurrenceID.Truncate(time.Second).Unix()] = struct{}{}
+		}
+	}
+
+	from := after.Add(lead)
+	next := set.Iterator()
+	for i := 0; i < maxIterations; i++ {
+		occStart, ok := next()
+		if !ok {
+			break
+		}
+		if !occStart.After(from) {
+			continue
+		}
+		if _, ok := overridden[occStart.Unix()]; !ok {
+			return occStart.UTC(), true, nil
+		}
+	}
+	return time.Time{}, false, nil
+}
+
+// between вхождения набора в [start, end] в UTC: не больше MaxOccurrences и не дальше maxIterations от начала серии
+func between(set *rrule.Set, start, end time.Time) []time.Time {
+	var res []time.Time
+	next := set.Iterator()
+	for i := 0; i < maxIterations && len(res) < MaxOccurrences; i++ {
+		occ, ok := next()
+		if !ok || occ.After(end) {
+			break
+		}
+		if !occ.Before(start) {
+			res = append(res, occ.UTC())
+		}
+	}
+	return res
+}
+
+// Expand разворачивает серию master в вхождения, попадающие в период [start, end]
+// (как и для одиночных событий: начало >= start и окончание <= end).
+// Вхождения, для которых есть переопределение в overrides, заменяются им,
+// отменённые переопределения пропускаются.
+func Expand(master *entity.EventResponse, overrides []*entity.EventResponse, start, end time.Time) ([]*entity.EventResponse, error) {
+	set, err := seriesSet(master)
+	if err != nil {
+		return nil, fmt.Errorf("[event: %s] %w", master.ID, err)
+	}
+
+	overridden := make(map[int64]struct{}, len(overrides))
+	for _, o := range overrides {
+		if o.RecurrenceID != nil {
+			overridden[o.RecurrenceID.Truncate(time.Second).Unix()] = struct{}{}
+		}
+	}
+
+	duration := master.EndDateEvent.Sub(master.DateEvent)
+	var notifyBefore time.Duration
+	if !master.TimeForNotification.IsZero() {
+		notifyBefore = master.DateEvent.Sub(master.TimeForNotification)
+	}
+
+	res := make([]*entity.EventResponse, 0)
+	for _, occStart := range between(set, start, end) {
+		if _, ok := overridden[occStart.Unix()]; ok {
+			continue
+		}
+		occEnd := occStart.Add(duration)
+		if occEnd.After(end) {
+			continue
+		}
+
+		occ := *master
+		occ.DateEvent = occStart
+		occ.EndDateEvent = occEnd
+		if notifyBefore != 0 {
+			occ.TimeForNotification = occStart.Add(-notifyBefore)
+		}
+		seriesID := master.ID
+		recurrenceID := occStart
+		occ.SeriesID = &seriesID
+		occ.RecurrenceID = &recurrenceID
+		res = append(res, &occ)
+	}
+
+	for _, o := range overrides {
+		if o.Cancelled {
+			continue
+		}
+		if o.DateEvent.Before(start) || o.EndDateEvent.After(end) {
+			continue
+		}
+		res = append(res, o)
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].DateEvent.Before(res[j].DateEvent)
+	})
+
+	return res, nil
+}
+
+// Overlaps проверяет, пересекается ли хотя бы одно вхождение серии (с учётом переопределений)
+// с интервалом [start, end)
+func Overlaps(master *entity.EventResponse, overrides []*entity.EventResponse, start, end time.Time) (bool, error) {
+	set, err := seriesSet(master)
+	if err != nil {
+		return false, fmt.Errorf("[event: %s] %w", master.ID, err)
+	}
+
+	overridden := make(map[int64]struct{}, len(overrides))
+	for _, o := range overrides {
+		if o.RecurrenceID != nil {
+			overridden[o.RecurrenceID.Truncate(time.Second).Unix()] = struct{}{}
+		}
+		if !o.Cancelled && o.DateEvent.Before(end) && o.EndDateEvent.After(start) {
+			return true, nil
+		}
+	}
+
+	duration := master.EndDateEvent.Sub(master.DateEvent)
+	for _, occStart := range between(set, start.Add(-duration), end) {
+		if _, ok := overridden[occStart.Unix()]; ok {
+			continue
+		}
+		if occStart.Before(end) && occStart.Add(duration).After(start) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
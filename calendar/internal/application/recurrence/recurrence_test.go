@@ -0,0 +1,577 @@
+package recurrence
+
+import (
+	"calendar/internal/application/entity"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+var seriesID = uuid.Must(uuid.FromString("7c9e6679-7425-40de-944b-e07fc1f90ae7"))
+
+func ts(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t.Fatalf("parse %q: %v", s, err)
+	}
+	return v
+}
+
+func times(t *testing.T, ss ...string) []time.Time {
+	t.Helper()
+	res := make([]time.Time, 0, len(ss))
+	for _, s := range ss {
+		res = append(res, ts(t, s))
+	}
+	return res
+}
+
+// standup стендап по понедельникам, средам и пятницам с 2026-01-19 (пн) 09:00 на 15 минут
+func standup(t *testing.T, rule string) *entity.EventResponse {
+	t.Helper()
+	return &entity.EventResponse{
+		ID:           seriesID,
+		Title:        "Стендап",
+		DateEvent:    ts(t, "2026-01-19T09:00:00Z"),
+		EndDateEvent: ts(t, "2026-01-19T09:15:00Z"),
+		UserID:       "user123",
+		RRule:        rule,
+	}
+}
+
+func override(t *testing.T, recurrenceID, start, end string, cancelled bool) *entity.EventResponse {
+	t.Helper()
+	sid := seriesID
+	rid := ts(t, recurrenceID)
+	return &entity.EventResponse{
+		ID:           uuid.Must(uuid.NewV4()),
+		Title:        "Перенесённый стендап",
+		DateEvent:    ts(t, start),
+		EndDateEvent: ts(t, end),
+		SeriesID:     &sid,
+		RecurrenceID: &rid,
+		Cancelled:    cancelled,
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		wantErr bool
+	}{
+		{name: "weekly", rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{name: "with prefix", rule: "RRULE:FREQ=DAILY;COUNT=3"},
+		{name: "until", rule: "FREQ=DAILY;UNTIL=20260130T090000Z"},
+		{name: "empty", rule: "", wantErr: true},
+		{name: "spaces", rule: "   ", wantErr: true},
+		{name: "unknown freq", rule: "FREQ=SOMETIMES", wantErr: true},
+		{name: "garbage", rule: "not a rule", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRule(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRule(%q) error = %v, wantErr %v", tt.rule, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		name       string
+		rule       string
+		rdate      []string
+		exdate     []string
+		overrides  func(t *testing.T) []*entity.EventResponse
+		start, end string
+		want       []string
+	}{
+		{
+			name:  "weekly by day",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			start: "2026-01-19T00:00:00Z", end: "2026-01-25T23:59:59Z",
+			want: []string{"2026-01-19T09:00:00Z", "2026-01-21T09:00:00Z", "2026-01-23T09:00:00Z"},
+		},
+		{
+			name:  "period before series start",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			start: "2026-01-01T00:00:00Z", end: "2026-01-18T23:59:59Z",
+			want: []string{},
+		},
+		{
+			name:  "count limits occurrences",
+			rule:  "FREQ=DAILY;COUNT=3",
+			start: "2026-01-19T00:00:00Z", end: "2026-01-31T00:00:00Z",
+			want: []string{"2026-01-19T09:00:00Z", "2026-01-20T09:00:00Z", "2026-01-21T09:00:00Z"},
+		},
+		{
+			name:  "until limits occurrences",
+			rule:  "FREQ=DAILY;UNTIL=20260120T090000Z",
+			start: "2026-01-19T00:00:00Z", end: "2026-01-31T00:00:00Z",
+			want: []string{"2026-01-19T09:00:00Z", "2026-01-20T09:00:00Z"},
+		},
+		{
+			name:   "exdate removes occurrence",
+			rule:   "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			exdate: []string{"2026-01-21T09:00:00Z"},
+			start:  "2026-01-19T00:00:00Z", end: "2026-01-25T23:59:59Z",
+			want: []string{"2026-01-19T09:00:00Z", "2026-01-23T09:00:00Z"},
+		},
+		{
+			name:  "rdate adds occurrence",
+			rule:  "FREQ=WEEKLY;BYDAY=MO",
+			rdate: []string{"2026-01-22T14:00:00Z"},
+			start: "2026-01-19T00:00:00Z", end: "2026-01-25T23:59:59Z",
+			want: []string{"2026-01-19T09:00:00Z", "2026-01-22T14:00:00Z"},
+		},
+		{
+			name:  "rdate without rule",
+			rdate: []string{"2026-01-20T09:00:00Z", "2026-02-20T09:00:00Z"},
+			start: "2026-01-19T00:00:00Z", end: "2026-01-31T00:00:00Z",
+			want: []string{"2026-01-19T09:00:00Z", "2026-01-20T09:00:00Z"},
+		},
+		{
+			name:  "dtstart is always an occurrence",
+			rule:  "FREQ=WEEKLY;BYDAY=TU",
+			start: "2026-01-19T00:00:00Z", end: "2026-01-21T00:00:00Z",
+			want: []string{"2026-01-19T09:00:00Z", "2026-01-20T09:00:00Z"},
+		},
+		{
+			name:  "occurrence ending after period is skipped",
+			rule:  "FREQ=DAILY",
+			start: "2026-01-19T00:00:00Z", end: "2026-01-20T09:10:00Z",
+			want: []string{"2026-01-19T09:00:00Z"},
+		},
+		{
+			name: "override moves occurrence",
+			rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			overrides: func(t *testing.T) []*entity.EventResponse {
+				return []*entity.EventResponse{
+					override(t, "2026-01-21T09:00:00Z", "2026-01-21T10:00:00Z", "2026-01-21T10:15:00Z", false),
+				}
+			},
+			start: "2026-01-19T00:00:00Z", end: "2026-01-25T23:59:59Z",
+			want: []string{"2026-01-19T09:00:00Z", "2026-01-21T10:00:00Z", "2026-01-23T09:00:00Z"},
+		},
+		{
+			name: "override moved out of period",
+			rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			overrides: func(t *testing.T) []*entity.EventResponse {
+				return []*entity.EventResponse{
+					override(t, "2026-01-23T09:00:00Z", "2026-01-26T11:00:00Z", "2026-01-26T11:15:00Z", false),
+				}
+			},
+			start: "2026-01-19T00:00:00Z", end: "2026-01-25T23:59:59Z",
+			want: []string{"2026-01-19T09:00:00Z", "2026-01-21T09:00:00Z"},
+		},
+		{
+			name: "cancelled override",
+			rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			overrides: func(t *testing.T) []*entity.EventResponse {
+				return []*entity.EventResponse{
+					override(t, "2026-01-19T09:00:00Z", "2026-01-19T09:00:00Z", "2026-01-19T09:15:00Z", true),
+				}
+			},
+			start: "2026-01-19T00:00:00Z", end: "2026-01-25T23:59:59Z",
+			want: []string{"2026-01-21T09:00:00Z", "2026-01-23T09:00:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master := standup(t, tt.rule)
+			master.RDate = times(t, tt.rdate...)
+			master.ExDate = times(t, tt.exdate...)
+			var overrides []*entity.EventResponse
+			if tt.overrides != nil {
+				overrides = tt.overrides(t)
+			}
+
+			got, err := Expand(master, overrides, ts(t, tt.start), ts(t, tt.end))
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expand() returned %d occurrences, want %d: %v", len(got), len(tt.want), starts(got))
+			}
+			for i, occ := range got {
+				if !occ.DateEvent.Equal(ts(t, tt.want[i])) {
+					t.Errorf("occurrence %d starts at %s, want %s", i, occ.DateEvent.Format(time.RFC3339), tt.want[i])
+				}
+				if occ.SeriesID == nil || *occ.SeriesID != seriesID {
+					t.Errorf("occurrence %d seriesID = %v, want %s", i, occ.SeriesID, seriesID)
+				}
+				if occ.RecurrenceID == nil {
+					t.Errorf("occurrence %d has no recurrenceID", i)
+				}
+			}
+		})
+	}
+}
+
+func TestExpandKeepsDurationAndNotification(t *testing.T) {
+	master := standup(t, "FREQ=DAILY")
+	master.TimeForNotification = ts(t, "2026-01-19T08:50:00Z")
+
+	got, err := Expand(master, nil, ts(t, "2026-01-20T00:00:00Z"), ts(t, "2026-01-21T00:00:00Z"))
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Expand() returned %d occurrences, want 1", len(got))
+	}
+	occ := got[0]
+	if want := ts(t, "2026-01-20T09:15:00Z"); !occ.EndDateEvent.Equal(want) {
+		t.Errorf("end = %s, want %s", occ.EndDateEvent, want)
+	}
+	if want := ts(t, "2026-01-20T08:50:00Z"); !occ.TimeForNotification.Equal(want) {
+		t.Errorf("timeForNotification = %s, want %s", occ.TimeForNotification, want)
+	}
+	if want := ts(t, "2026-01-20T09:00:00Z"); !occ.RecurrenceID.Equal(want) {
+		t.Errorf("recurrenceID = %s, want %s", occ.RecurrenceID, want)
+	}
+}
+
+func TestExpandInvalidRule(t *testing.T) {
+	_, err := Expand(standup(t, "FREQ=SOMETIMES"), nil, ts(t, "2026-01-19T00:00:00Z"), ts(t, "2026-01-25T00:00:00Z"))
+	if err == nil {
+		t.Fatal("Expand() with invalid rule: want error")
+	}
+}
+
+func TestIsOccurrence(t *testing.T) {
+	master := standup(t, "FREQ=WEEKLY;BYDAY=MO,WE,FR")
+	master.ExDate = times(t, "2026-01-23T09:00:00Z")
+	master.RDate = times(t, "2026-01-24T12:00:00Z")
+
+	tests := []struct {
+		name string
+		at   string
+		want bool
+	}{
+		{name: "dtstart", at: "2026-01-19T09:00:00Z", want: true},
+		{name: "rule occurrence", at: "2026-01-21T09:00:00Z", want: true},
+		{name: "rdate", at: "2026-01-24T12:00:00Z", want: true},
+		{name: "exdate", at: "2026-01-23T09:00:00Z", want: false},
+		{name: "wrong day", at: "2026-01-20T09:00:00Z", want: false},
+		{name: "wrong time", at: "2026-01-21T10:00:00Z", want: false},
+		{name: "before series", at: "2026-01-16T09:00:00Z", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsOccurrence(master, ts(t, tt.at))
+			if err != nil {
+				t.Fatalf("IsOccurrence() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsOccurrence(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeriesEnd(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   string
+		rdate  []string
+		exdate []string
+		want   string // пусто - бесконечная серия
+	}{
+		{name: "infinite", rule: "FREQ=WEEKLY;BYDAY=MO"},
+		{name: "count", rule: "FREQ=DAILY;COUNT=3", want: "2026-01-21T09:15:00Z"},
+		{name: "until", rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR;UNTIL=20260130T090000Z", want: "2026-01-30T09:15:00Z"},
+		{name: "exdate on last", rule: "FREQ=DAILY;COUNT=3", exdate: []string{"2026-01-21T09:00:00Z"}, want: "2026-01-20T09:15:00Z"},
+		{name: "rdate after until", rule: "FREQ=DAILY;COUNT=2", rdate: []string{"2026-03-01T12:00:00Z"}, want: "2026-03-01T12:15:00Z"},
+		{name: "rdate only", rdate: []string{"2026-02-01T09:00:00Z", "2026-01-25T09:00:00Z"}, want: "2026-02-01T09:15:00Z"},
+		{name: "infinite with rdate", rule: "FREQ=DAILY", rdate: []string{"2026-03-01T12:00:00Z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master := standup(t, tt.rule)
+			master.RDate = times(t, tt.rdate...)
+			master.ExDate = times(t, tt.exdate...)
+
+			got, err := SeriesEnd(master)
+			if err != nil {
+				t.Fatalf("SeriesEnd() error = %v", err)
+			}
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("SeriesEnd() = %s, want infinite", got)
+			case tt.want != "" && got == nil:
+				t.Errorf("SeriesEnd() = infinite, want %s", tt.want)
+			case tt.want != "" && !got.Equal(ts(t, tt.want)):
+				t.Errorf("SeriesEnd() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	tests := []struct {
+		name       string
+		overrides  func(t *testing.T) []*entity.EventResponse
+		start, end string
+		want       bool
+	}{
+		{name: "inside occurrence", start: "2026-01-21T09:05:00Z", end: "2026-01-21T09:10:00Z", want: true},
+		{name: "occurrence started before interval", start: "2026-01-21T09:10:00Z", end: "2026-01-21T10:00:00Z", want: true},
+		{name: "touching end", start: "2026-01-21T09:15:00Z", end: "2026-01-21T10:00:00Z", want: false},
+		{name: "between occurrences", start: "2026-01-20T00:00:00Z", end: "2026-01-20T23:00:00Z", want: false},
+		{
+			name: "moved override",
+			overrides: func(t *testing.T) []*entity.EventResponse {
+				return []*entity.EventResponse{
+					override(t, "2026-01-21T09:00:00Z", "2026-01-20T15:00:00Z", "2026-01-20T15:15:00Z", false),
+				}
+			},
+			start: "2026-01-20T15:00:00Z", end: "2026-01-20T16:00:00Z", want: true,
+		},
+		{
+			name: "cancelled occurrence",
+			overrides: func(t *testing.T) []*entity.EventResponse {
+				return []*entity.EventResponse{
+					override(t, "2026-01-21T09:00:00Z", "2026-01-21T09:00:00Z", "2026-01-21T09:15:00Z", true),
+				}
+			},
+			start: "2026-01-21T09:00:00Z", end: "2026-01-21T09:15:00Z", want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var overrides []*entity.EventResponse
+			if tt.overrides != nil {
+				overrides = tt.overrides(t)
+			}
+			got, err := Overlaps(standup(t, "FREQ=WEEKLY;BYDAY=MO,WE,FR"), overrides, ts(t, tt.start), ts(t, tt.end))
+			if err != nil {
+				t.Fatalf("Overlaps() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Overlaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func starts(events []*entity.EventResponse) []string {
+	res := make([]string, 0, len(events))
+	for _, e := range events {
+		res = append(res, e.DateEvent.Format(time.RFC3339))
+	}
+	return res
+}
+
+func TestNextReminder(t *testing.T) {
+	tests := []struct {
+		name      string
+		rule      string
+		exdate    []string
+		overrides []*entity.EventResponse
+		lead      time.Duration
+		after     string
+		want      string // пусто - вхождений больше нет
+	}{
+		{
+			name:  "first occurrence before its reminder",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			lead:  10 * time.Minute,
+			after: "2026-01-01T00:00:00Z",
+			want:  "2026-01-19T09:00:00Z",
+		},
+		{
+			name:  "offset reminder advances after firing",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			lead:  10 * time.Minute,
+			after: "2026-01-19T08:50:00Z",
+			want:  "2026-01-21T09:00:00Z",
+		},
+		{
+			name:  "reminder time passed, occurrence not started",
+			rule:  "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			lead:  10 * time.Minute,
+			after: "2026-01-19T08:55:00Z",
+			want:  "2026-01-21T09:00:00Z",
+		},
+		{
+			name:  "lead longer than interval",
+			rule:  "FREQ=DAILY",
+			lead:  36 * time.Hour,
+			after: "2026-01-19T12:00:00Z",
+			want:  "2026-01-21T09:00:00Z",
+		},
+		{
+			name:   "exdate is skipped",
+			rule:   "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			exdate: []string{"2026-01-21T09:00:00Z"},
+			lead:   time.Hour,
+			after:  "2026-01-19T08:00:00Z",
+			want:   "2026-01-23T09:00:00Z",
+		},
+		{
+			name: "overridden and cancelled occurrences are skipped",
+			rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			overrides: []*entity.EventResponse{
+				override(t, "2026-01-21T09:00:00Z", "2026-01-21T11:00:00Z", "2026-01-21T11:15:00Z", false),
+				override(t, "2026-01-23T09:00:00Z", "2026-01-23T09:00:00Z", "2026-01-23T09:15:00Z", true),
+			},
+			lead:  10 * time.Minute,
+			after: "2026-01-19T08:50:00Z",
+			want:  "2026-01-26T09:00:00Z",
+		},
+		{
+			name:  "finished series",
+			rule:  "FREQ=DAILY;COUNT=3",
+			lead:  10 * time.Minute,
+			after: "2026-01-21T08:50:00Z",
+		},
+		{
+			name:  "zero lead",
+			rule:  "FREQ=DAILY;COUNT=3",
+			after: "2026-01-20T09:00:00Z",
+			want:  "2026-01-21T09:00:00Z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master := standup(t, tt.rule)
+			master.ExDate = times(t, tt.exdate...)
+
+			got, ok, err := NextReminder(master, tt.overrides, tt.lead, ts(t, tt.after))
+			if err != nil {
+				t.Fatalf("NextReminder() error = %v", err)
+			}
+			if tt.want == "" {
+				if ok {
+					t.Errorf("NextReminder() = %s, want no occurrence", got)
+				}
+				return
+			}
+			if !ok || !got.Equal(ts(t, tt.want)) {
+				t.Errorf("NextReminder() = %s, %v, want %s", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestReminderLead(t *testing.T) {
+	offset := 24 * time.Hour
+	master := standup(t, "FREQ=DAILY")
+	master.TimeForNotification = ts(t, "2026-01-19T08:45:00Z")
+
+	tests := []struct {
+		name   string
+		key    string
+		offset *time.Duration
+		want   time.Duration
+		ok     bool
+	}{
+		{name: "offset", key: "email|offset:86400", offset: &offset, want: offset, ok: true},
+		{name: "timeForNotification", key: entity.NotificationReminderKey, want: 15 * time.Minute, ok: true},
+		{name: "absolute", key: "push|at:2026-01-19T08:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ReminderLead(master, tt.key, tt.offset)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("ReminderLead() = %s, %v, want %s, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	// напоминание за сутки у ежедневной серии срабатывает для каждого вхождения по очереди
+	lead, _ := ReminderLead(master, "email|offset:86400", &offset)
+	after := ts(t, "2026-01-01T00:00:00Z")
+	var got []string
+	for i := 0; i < 3; i++ {
+		occ, ok, err := NextReminder(master, nil, lead, after)
+		if err != nil || !ok {
+			t.Fatalf("NextReminder() = %s, %v, %v", occ, ok, err)
+		}
+		got = append(got, occ.Format(time.RFC3339))
+		after = occ.Add(-lead)
+	}
+	want := []string{"2026-01-19T09:00:00Z", "2026-01-20T09:00:00Z", "2026-01-21T09:00:00Z"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("occurrences = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestExpandLimit(t *testing.T) {
+	// 1440 вхождений в день: правило проходит проверку FREQ, но разворачивается не больше MaxOccurrences
+	minutes := "0"
+	for m := 1; m < 60; m++ {
+		minutes += "," + strconv.Itoa(m)
+	}
+	rule := "FREQ=DAILY;BYHOUR=0,1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,23;BYMINUTE=" + minutes
+	master := standup(t, rule)
+	master.EndDateEvent = master.DateEvent.Add(time.Minute)
+
+	got, err := Expand(master, nil, ts(t, "2026-02-01T00:00:00Z"), ts(t, "2026-03-01T00:00:00Z"))
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if len(got) != MaxOccurrences {
+		t.Errorf("Expand() returned %d occurrences, want %d", len(got), MaxOccurrences)
+	}
+	if first := got[0].DateEvent; !first.Equal(ts(t, "2026-02-01T00:00:00Z")) {
+		t.Errorf("first occurrence = %s", first)
+	}
+
+	master.RRule = rule + ";UNTIL=21000101T000000Z"
+	end, err := SeriesEnd(master)
+	if err != nil {
+		t.Fatalf("SeriesEnd() error = %v", err)
+	}
+	if end != nil {
+		t.Errorf("SeriesEnd() = %s, want infinite for a series longer than the iteration limit", end)
+	}
+}
+
+func TestExpandTimeZone(t *testing.T) {
+	// еженедельно по понедельникам в 09:00 по Берлину; 2026-03-29 переход на летнее время (UTC+1 -> UTC+2)
+	master := standup(t, "FREQ=WEEKLY;BYDAY=MO;COUNT=3")
+	master.DateEvent = ts(t, "2026-03-23T08:00:00Z")
+	master.EndDateEvent = ts(t, "2026-03-23T08:15:00Z")
+
+	tests := []struct {
+		name     string
+		timeZone string
+		want     []string
+	}{
+		{name: "utc", want: []string{"2026-03-23T08:00:00Z", "2026-03-30T08:00:00Z", "2026-04-06T08:00:00Z"}},
+		{name: "europe/berlin keeps local time", timeZone: "Europe/Berlin",
+			want: []string{"2026-03-23T08:00:00Z", "2026-03-30T07:00:00Z", "2026-04-06T07:00:00Z"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master.TimeZone = tt.timeZone
+			got, err := Expand(master, nil, ts(t, "2026-03-01T00:00:00Z"), ts(t, "2026-05-01T00:00:00Z"))
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			if s := starts(got); strings.Join(s, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("occurrences = %v, want %v", s, tt.want)
+			}
+
+			ok, err := IsOccurrence(master, ts(t, tt.want[1]))
+			if err != nil || !ok {
+				t.Errorf("IsOccurrence(%s) = %v, %v; want true", tt.want[1], ok, err)
+			}
+		})
+	}
+
+	master.TimeZone = "Mars/Olympus"
+	if _, err := Expand(master, nil, ts(t, "2026-03-01T00:00:00Z"), ts(t, "2026-05-01T00:00:00Z")); err == nil {
+		t.Error("Expand() with unknown time zone: want error")
+	}
+}
@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ListAttendees участники события в порядке приглашения
+func (r *RepoImpl) ListAttendees(ctx context.Context, eventID uuid.UUID) ([]entity.Attendee, error) {
+	r.logger.Debugf("[event: %s] ListAttendees started", eventID)
+
+	rows, err := r.db.Query(ctx, listAttendeesSQL, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("list attendees: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]entity.Attendee, 0)
+	for rows.Next() {
+		a, err := scanAttendee(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan attendee: %w", err)
+		}
+		res = append(res, *a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list attendees: %w", err)
+	}
+	return res, nil
+}
+
+// UpsertAttendee приглашает участника или меняет роль уже приглашённого; true - участник добавлен.
+// a заполняется строкой из БД.
+func (r *RepoImpl) UpsertAttendee(ctx context.Context, a *entity.Attendee) (bool, error) {
+	r.logger.Debugf("[event: %s, user: %s] UpsertAttendee started", a.EventID, a.UserID)
+
+	var inserted bool
+	err := r.db.QueryRow(ctx, upsertAttendeeSQL, a.EventID, a.UserID, a.Role).Scan(
+		&a.EventID, &a.UserID, &a.Role, &a.PartStat, &a.Comment, &a.InvitedAt, &a.RespondedAt, &a.UpdatedAt, &inserted)
+	if err != nil {
+		return false, fmt.Errorf("upsert attendee: %w", err)
+	}
+	return inserted, nil
+}
+
+// GetAttendeeForUpdate участник события с блокировкой строки до конца транзакции;
+// appers.ErrAttendeeNotFound - пользователь не приглашён
+func (r *RepoImpl) GetAttendeeForUpdate(ctx context.Context, eventID uuid.UUID, userID string) (*entity.Attendee, error) {
+	r.logger.Debugf("[event: %s, user: %s] GetAttendeeForUpdate started", eventID, userID)
+
+	a, err := scanAttendee(r.db.QueryRow(ctx, getAttendeeForUpdateSQL, eventID, userID))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, appers.ErrAttendeeNotFound
+	case err != nil:
+		return nil, fmt.Errorf("get attendee: %w", err)
+	}
+	return a, nil
+}
+
+// UpdateAttendeeResponse сохраняет ответ участника (partstat и комментарий)
+func (r *RepoImpl) UpdateAttendeeResponse(ctx context.Context, a *entity.Attendee) error {
+	r.logger.Debugf("[event: %s, user: %s] UpdateAttendeeResponse started", a.EventID, a.UserID)
+
+	err := r.db.QueryRow(ctx, updateAttendeeResponseSQL, a.EventID, a.UserID, a.PartStat, nullIfEmpty(a.Comment)).
+		Scan(&a.RespondedAt, &a.UpdatedAt)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return appers.ErrAttendeeNotFound
+	case err != nil:
+		return fmt.Errorf("update attendee response: %w", err)
+	}
+	return nil
+}
+
+func scanAttendee(row pgx.Row) (*entity.Attendee, error) {
+	var a entity.Attendee
+	if err := row.Scan(&a.EventID, &a.UserID, &a.Role, &a.PartStat, &a.Comment, &a.InvitedAt, &a.RespondedAt,
+		&a.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateCalendar создаёт календарь; основной календарь должен быть снят заранее (ClearDefaultCalendar)
+func (r *RepoImpl) CreateCalendar(ctx context.Context, c *entity.Calendar) error {
+	r.logger.Debugf("[calendar: %s, user: %s] CreateCalendar started", c.ID, c.UserID)
+
+	err := r.db.QueryRow(ctx, insertCalendarSQL,
+		c.ID, c.UserID, c.Name, nullIfEmpty(c.Color), nullIfEmpty(c.TimeZone), c.IsDefault).Scan(&c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert calendar: %w", err)
+	}
+	return nil
+}
+
+// GetCalendar календарь по ID; appers.ErrCalendarNotFound - нет такого
+func (r *RepoImpl) GetCalendar(ctx context.Context, id uuid.UUID) (*entity.Calendar, error) {
+	r.logger.Debugf("[calendar: %s] GetCalendar started", id)
+
+	c, err := scanCalendar(r.db.QueryRow(ctx, getCalendarSQL, id))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, appers.ErrCalendarNotFound
+	case err != nil:
+		return nil, fmt.Errorf("get calendar: %w", err)
+	}
+	return c, nil
+}
+
+// ListCalendars календари пользователя, основной первым
+func (r *RepoImpl) ListCalendars(ctx context.Context, userID string) ([]entity.Calendar, error) {
+	r.logger.Debugf("[user: %s] ListCalendars started", userID)
+
+	rows, err := r.db.Query(ctx, listCalendarsSQL, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list calendars: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]entity.Calendar, 0)
+	for rows.Next() {
+		c, err := scanCalendar(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan calendar: %w", err)
+		}
+		res = append(res, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list calendars: %w", err)
+	}
+	return res, nil
+}
+
+// UpdateCalendar записывает название, цвет, часовой пояс и признак основного календаря
+func (r *RepoImpl) UpdateCalendar(ctx context.Context, c *entity.Calendar) error {
+	r.logger.Debugf("[calendar: %s] UpdateCalendar started", c.ID)
+
+	err := r.db.QueryRow(ctx, updateCalendarSQL,
+		c.ID, c.Name, nullIfEmpty(c.Color), nullIfEmpty(c.TimeZone), c.IsDefault).Scan(&c.UpdatedAt)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return appers.ErrCalendarNotFound
+	case err != nil:
+		return fmt.Errorf("update calendar: %w", err)
+	}
+	return nil
+}
+
+// ClearDefaultCalendar снимает признак основного со всех календарей пользователя, кроме keepID
+func (r *RepoImpl) ClearDefaultCalendar(ctx context.Context, userID string, keepID uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, clearDefaultCalendarSQL, userID, keepID); err != nil {
+		return fmt.Errorf("clear default calendar: %w", err)
+	}
+	return nil
+}
+
+// EnsureDefaultCalendar ID основного календаря пользователя; если его нет, он создаётся
+func (r *RepoImpl) EnsureDefaultCalendar(ctx context.Context, userID string) (uuid.UUID, error) {
+	r.logger.Debugf("[user: %s] EnsureDefaultCalendar started", userID)
+
+	// параллельная вставка: запрос дождётся её коммита (ON CONFLICT), но не увидит строку в своём снимке,
+	// второй запрос её увидит
+	for attempt := 0; ; attempt++ {
+		var id uuid.UUID
+		err := r.db.QueryRow(ctx, ensureDefaultCalendarSQL, uuid.Must(uuid.NewV4()), userID, entity.DefaultCalendarName).Scan(&id)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows) && attempt == 0:
+			continue
+		case err != nil:
+			return uuid.Nil, fmt.Errorf("ensure default calendar: %w", err)
+		}
+		return id, nil
+	}
+}
+
+// DeleteCalendar удаляет календарь; appers.ErrCalendarNotEmpty - в нём есть события
+func (r *RepoImpl) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	r.logger.Debugf("[calendar: %s] DeleteCalendar started", id)
+
+	result, err := r.db.Exec(ctx, deleteCalendarSQL, id)
+	switch {
+	case isForeignKeyError(err):
+		return appers.ErrCalendarNotEmpty
+	case err != nil:
+		return fmt.Errorf("delete calendar: %w", err)
+	case result.RowsAffected() == 0:
+		return appers.ErrCalendarNotFound
+	}
+	return nil
+}
+
+// UpdateOverridesCalendar переносит переопределения вхождений серии в календарь серии
+func (r *RepoImpl) UpdateOverridesCalendar(ctx context.Context, seriesID, calendarID uuid.UUID) error {
+	if _, err := r.db.Exec(ctx, updateOverridesCalendar, seriesID, calendarID); err != nil {
+		return fmt.Errorf("update overrides calendar: %w", err)
+	}
+	return nil
+}
+
+func scanCalendar(row pgx.Row) (*entity.Calendar, error) {
+	var c entity.Calendar
+	if err := row.Scan(&c.ID, &c.UserID, &c.Name, &c.Color, &c.TimeZone, &c.IsDefault, &c.CreatedAt,
+		&c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
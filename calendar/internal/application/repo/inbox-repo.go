@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"calendar/internal/application/common"
+	"calendar/internal/application/entity"
+	"context"
+	"fmt"
+	"time"
+)
+
+// InsertInbox записывает сообщение в inbox; false - сообщение с таким ID уже обработано
+func (r *RepoImpl) InsertInbox(ctx context.Context, m *entity.InboxMessage) (bool, error) {
+	r.logger.Debugf("[message: %s] InsertInbox started", m.ID)
+
+	result, err := r.db.Exec(ctx, insertInboxSQL, m.ID, m.Topic, m.Partition, m.Offset, nullIfEmpty(m.EventType))
+	if err != nil {
+		return false, fmt.Errorf("insert inbox_message: %w", err)
+	}
+
+	return result.RowsAffected() == 1, nil
+}
+
+// DeleteInboxBatch удаляет до limit записей inbox старше olderThan
+func (r *RepoImpl) DeleteInboxBatch(ctx context.Context, olderThan time.Duration, limit int) (int64, error) {
+	result, err := r.db.Exec(ctx, deleteInboxBatchSQL, common.PgInterval(olderThan), limit)
+	if err != nil {
+		return 0, fmt.Errorf("delete inbox_message: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
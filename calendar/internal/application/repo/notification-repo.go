@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/common"
+	"calendar/internal/application/entity"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetNotificationSettings настройки уведомлений пользователя; appers.ErrNotificationSettingsNotFound - не заданы
+func (r *RepoImpl) GetNotificationSettings(ctx context.Context, userID string) (*entity.NotificationSettings, error) {
+	r.logger.Debugf("[user: %s] GetNotificationSettings started", userID)
+
+	s, err := scanNotificationSettings(r.db.QueryRow(ctx, getNotificationSettingsSQL, userID))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, appers.ErrNotificationSettingsNotFound
+	case err != nil:
+		return nil, fmt.Errorf("get notification settings: %w", err)
+	}
+	return s, nil
+}
+
+func scanNotificationSettings(row pgx.Row) (*entity.NotificationSettings, error) {
+	var s entity.NotificationSettings
+	if err := row.Scan(&s.UserID, &s.Email, &s.WebhookURL, &s.Locale, &s.TimeZone, &s.DigestEnabled, &s.DigestTime,
+		&s.DigestChannel, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// UpsertNotificationSettings создаёт или заменяет настройки уведомлений пользователя
+func (r *RepoImpl) UpsertNotificationSettings(ctx context.Context, s *entity.NotificationSettings) error {
+	r.logger.Debugf("[user: %s] UpsertNotificationSettings started", s.UserID)
+
+	err := r.db.QueryRow(ctx, upsertNotificationSettingsSQL,
+		s.UserID, nullIfEmpty(s.Email), nullIfEmpty(s.WebhookURL), nullIfEmpty(s.Locale), nullIfEmpty(s.TimeZone),
+		s.DigestEnabled, s.DigestTime, s.DigestChannel).Scan(&s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("upsert notification settings: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueDigests блокирует до limit пользователей, которым пора отправить сводку; вызывается в транзакции
+func (r *RepoImpl) ClaimDueDigests(ctx context.Context, limit int) ([]entity.NotificationSettings, error) {
+	r.logger.Debugf("[limit: %d] ClaimDueDigests started", limit)
+
+	rows, err := r.db.Query(ctx, claimDueDigestsSQL, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim due digests: %w", err)
+	}
+	defer rows.Close()
+
+	var res []entity.NotificationSettings
+	for rows.Next() {
+		s, err := scanNotificationSettings(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan due digest: %w", err)
+		}
+		res = append(res, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim due digests: %w", err)
+	}
+	return res, nil
+}
+
+// MarkDigestSent запоминает локальную дату (YYYY-MM-DD) последней сводки пользователя
+func (r *RepoImpl) MarkDigestSent(ctx context.Context, userID, date string) error {
+	if _, err := r.db.Exec(ctx, markDigestSentSQL, userID, date); err != nil {
+		return fmt.Errorf("mark digest sent: %w", err)
+	}
+	return nil
+}
+
+// InsertNotification ставит уведомление в очередь доставки; false - уведомление по этому сообщению,
+// получателю и каналу уже есть
+func (r *RepoImpl) InsertNotification(ctx context.Context, n *entity.Notification) (bool, error) {
+	r.logger.Debugf("[message: %s, user: %s, channel: %s] InsertNotification started", n.MessageID, n.UserID, n.Channel)
+
+	result, err := r.db.Exec(ctx, insertNotificationSQL,
+		n.MessageID, n.EventType, n.UserID, n.Channel, nullIfEmpty(n.Recipient), n.Content.Locale,
+		n.Content.Subject, n.Content.Text, nullIfEmpty(n.Content.HTML), n.Payload, n.Status, nullIfEmpty(n.LastError))
+	if err != nil {
+		return false, fmt.Errorf("insert notification: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// ReserveNotifications забирает до limit наступивших уведомлений на время lease
+func (r *RepoImpl) ReserveNotifications(ctx context.Context, lease time.Duration, limit int) ([]entity.Notification, error) {
+	r.logger.Debugf("[lease: %s, limit: %d] ReserveNotifications started", lease, limit)
+
+	rows, err := r.db.Query(ctx, reserveNotificationsSQL, common.PgInterval(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("reserve notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var res []entity.Notification
+	for rows.Next() {
+		var n entity.Notification
+		if err := rows.Scan(
+			&n.ID, &n.MessageID, &n.EventType, &n.UserID, &n.Channel, &n.Recipient, &n.Content.Locale,
+			&n.Content.Subject, &n.Content.Text, &n.Content.HTML, &n.Payload, &n.Status, &n.Attempts,
+			&n.NextAttemptAt, &n.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan reserved notification: %w", err)
+		}
+		res = append(res, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("reserve notifications: %w", err)
+	}
+	return res, nil
+}
+
+// MarkNotificationSent отмечает уведомление доставленным
+func (r *RepoImpl) MarkNotificationSent(ctx context.Context, id int64) error {
+	if _, err := r.db.Exec(ctx, markNotificationSentSQL, id); err != nil {
+		return fmt.Errorf("notification mark sent: %w", err)
+	}
+	return nil
+}
+
+// MarkNotificationFailed засчитывает неудачную попытку: PENDING - повтор в nextAttemptAt, FAILED - без повторов
+func (r *RepoImpl) MarkNotificationFailed(ctx context.Context, id int64, status entity.NotificationStatus, nextAttemptAt time.Time, lastError string) error {
+	if _, err := r.db.Exec(ctx, markNotificationFailedSQL, id, status, nextAttemptAt, nullIfEmpty(lastError)); err != nil {
+		return fmt.Errorf("notification mark failed: %w", err)
+	}
+	return nil
+}
@@ -1,11 +1,16 @@
 package repo
 
 import (
+	"calendar/internal/appers"
 	"calendar/internal/application/common"
 	"calendar/internal/application/entity"
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 func (r *RepoImpl) InsertOutbox(ctx context.Context, e *entity.OutboxEvent) error {
@@ -20,6 +25,12 @@ func (r *RepoImpl) InsertOutbox(ctx context.Context, e *entity.OutboxEvent) erro
 	return nil
 }
 
+// ListenOutbox ждёт уведомлений о новых сообщениях outbox (триггер trg_outbox_event_notify).
+// Блокируется до ошибки соединения или отмены ctx.
+func (r *RepoImpl) ListenOutbox(ctx context.Context, notify chan<- struct{}) error {
+	return r.db.Listen(ctx, outboxNotifyChannel, notify)
+}
+
 func (r *RepoImpl) ReserveOutboxBatch(ctx context.Context, lease time.Duration, limit, maxAttempts int) ([]entity.OutboxEvent, error) {
 	r.logger.Debugf("[lease: %s, limit: %d, maxAttempts: %d] ReserveOutboxBatch started", lease, limit, maxAttempts)
 
@@ -37,6 +48,7 @@ func (r *RepoImpl) ReserveOutboxBatch(ctx context.Context, lease time.Duration,
 		if err := rows.Scan(
 			&e.ID, &e.AggregateID, &e.AggregateType, &e.EventType,
 			&e.Payload, &status, &e.Attempts, &e.NextAttemptAt, &e.CreatedAt,
+			&e.LastError, &e.ErrorClass,
 		); err != nil {
 			return nil, fmt.Errorf("scan reserved outbox: %w", err)
 		}
@@ -50,9 +62,9 @@ func (r *RepoImpl) ReserveOutboxBatch(ctx context.Context, lease time.Duration,
 	return res, nil
 }
 
-func (r *RepoImpl) MarkFailedWithBackoff(ctx context.Context, outboxID int, nextAttemptAt time.Time) error {
+func (r *RepoImpl) MarkFailedWithBackoff(ctx context.Context, outboxID int, nextAttemptAt time.Time, lastError, errorClass string) error {
 
-	_, err := r.db.Exec(ctx, markFailedSQL, outboxID, entity.OutboxFailed, nextAttemptAt)
+	_, err := r.db.Exec(ctx, markFailedSQL, outboxID, entity.OutboxFailed, nextAttemptAt, nullIfEmpty(lastError), nullIfEmpty(errorClass))
 	if err != nil {
 		return fmt.Errorf("outbox mark failed: %w", err)
 	}
@@ -60,12 +72,253 @@ func (r *RepoImpl) MarkFailedWithBackoff(ctx context.Context, outboxID int, next
 	return nil
 }
 
-func (r *RepoImpl) MarkGaveUp(ctx context.Context, outboxID int) error {
+func (r *RepoImpl) MarkGaveUp(ctx context.Context, outboxID int, lastError, errorClass string) error {
 
-	_, err := r.db.Exec(ctx, markGaveUpSQL, outboxID, entity.OutboxGaveUp)
+	_, err := r.db.Exec(ctx, markGaveUpSQL, outboxID, entity.OutboxGaveUp, nullIfEmpty(lastError), nullIfEmpty(errorClass))
 	if err != nil {
 		return fmt.Errorf("outbox mark gave_up: %w", err)
 	}
 
 	return nil
 }
+
+// ReleaseOutbox снимает аренду с зарезервированных, но не отправленных сообщений: они снова доступны relay
+func (r *RepoImpl) ReleaseOutbox(ctx context.Context, outboxIDs []int) error {
+	_, err := r.db.Exec(ctx, releaseOutboxSQL, outboxIDs)
+	if err != nil {
+		return fmt.Errorf("outbox release: %w", err)
+	}
+
+	return nil
+}
+
+// MarkSentBatch отмечает SENT отправленные сообщения одним UPDATE ... WHERE id = ANY($1)
+func (r *RepoImpl) MarkSentBatch(ctx context.Context, outboxIDs []int) (int64, error) {
+	r.logger.Debugf("[IDs %v] MarkSentBatch started", outboxIDs)
+
+	result, err := r.db.Exec(ctx, markSentBatchSQL, outboxIDs, entity.OutboxSent)
+	if err != nil {
+		return 0, fmt.Errorf("outbox mark sent: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// GetOutboxDeliveries возвращает sink-и, в которые сообщения уже доставлены: outbox_id -> sink
+func (r *RepoImpl) GetOutboxDeliveries(ctx context.Context, outboxIDs []int) (map[int]map[string]struct{}, error) {
+	r.logger.Debugf("[IDs %v] GetOutboxDeliveries started", outboxIDs)
+
+	rows, err := r.db.Query(ctx, getOutboxDeliveriesSQL, outboxIDs)
+	if err != nil {
+		return nil, fmt.Errorf("get outbox deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	res := make(map[int]map[string]struct{})
+	for rows.Next() {
+		var id int
+		var sink string
+		if err := rows.Scan(&id, &sink); err != nil {
+			return nil, fmt.Errorf("scan outbox delivery: %w", err)
+		}
+		if res[id] == nil {
+			res[id] = make(map[string]struct{})
+		}
+		res[id][sink] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get outbox deliveries: %w", err)
+	}
+
+	return res, nil
+}
+
+// InsertOutboxDeliveries запоминает доставку сообщений в sink
+func (r *RepoImpl) InsertOutboxDeliveries(ctx context.Context, sink string, outboxIDs []int) error {
+	r.logger.Debugf("[IDs %v, sink: %s] InsertOutboxDeliveries started", outboxIDs, sink)
+
+	if _, err := r.db.Exec(ctx, insertOutboxDeliveriesSQL, outboxIDs, sink); err != nil {
+		return fmt.Errorf("insert outbox_delivery: %w", err)
+	}
+
+	return nil
+}
+
+// InsertDeadLetter копирует сообщение с диагностикой в outbox_dead_letter
+func (r *RepoImpl) InsertDeadLetter(ctx context.Context, e *entity.OutboxEvent) error {
+	r.logger.Debugf("[ID %d] InsertDeadLetter started", e.ID)
+	_, err := r.db.Exec(ctx, insertDeadLetterSQL,
+		e.ID, e.AggregateID, e.AggregateType, e.EventType, []byte(e.Payload), e.Attempts,
+		nullIfEmpty(e.LastError), nullIfEmpty(e.ErrorClass), e.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert outbox_dead_letter: %w", err)
+	}
+
+	return nil
+}
+
+// ListOutbox страница сообщений outbox по фильтру (без payload), по возрастанию id
+func (r *RepoImpl) ListOutbox(ctx context.Context, f *entity.OutboxFilter) (*entity.OutboxPage, error) {
+	r.logger.Debugf("[filter: %+v] ListOutbox started", *f)
+
+	where, args := outboxFilterWhere(f)
+	if f.AfterID > 0 {
+		args = append(args, f.AfterID)
+		where = append(where, fmt.Sprintf("id > $%d", len(args)))
+	}
+	// лишняя строка показывает, что есть следующая страница
+	args = append(args, f.Limit+1)
+
+	sb := strings.Builder{}
+	sb.WriteString("SELECT " + outboxAdminColumns + " FROM outbox_event")
+	if len(where) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(where, " AND "))
+	}
+	sb.WriteString(fmt.Sprintf(" ORDER BY id LIMIT $%d", len(args)))
+
+	rows, err := r.db.Query(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("list outbox: %w", err)
+	}
+	defer rows.Close()
+
+	page := &entity.OutboxPage{Items: make([]entity.OutboxEvent, 0, f.Limit)}
+	for rows.Next() {
+		e, err := scanOutboxAdmin(rows, false)
+		if err != nil {
+			return nil, fmt.Errorf("scan outbox: %w", err)
+		}
+		page.Items = append(page.Items, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list outbox rows err: %w", err)
+	}
+
+	if len(page.Items) > f.Limit {
+		page.Items = page.Items[:f.Limit]
+		page.NextAfterID = page.Items[f.Limit-1].ID
+	}
+	return page, nil
+}
+
+// GetOutboxByID сообщение outbox вместе с payload
+func (r *RepoImpl) GetOutboxByID(ctx context.Context, id int) (*entity.OutboxEvent, error) {
+	r.logger.Debugf("[ID %d] GetOutboxByID started", id)
+
+	e, err := scanOutboxAdmin(r.db.QueryRow(ctx, getOutboxByIDSQL, id), true)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, appers.ErrOutboxNotFound
+	case err != nil:
+		return nil, fmt.Errorf("get outbox: %w", err)
+	}
+	return e, nil
+}
+
+// RequeueOutbox возвращает FAILED/GAVE_UP сообщение в NEW; false - сообщения в таком статусе нет
+func (r *RepoImpl) RequeueOutbox(ctx context.Context, id int) (bool, error) {
+	r.logger.Debugf("[ID %d] RequeueOutbox started", id)
+
+	result, err := r.db.Exec(ctx, requeueOutboxSQL, id)
+	if err != nil {
+		return false, fmt.Errorf("requeue outbox: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// RequeueOutboxByFilter возвращает в NEW все FAILED/GAVE_UP сообщения, подходящие под фильтр
+func (r *RepoImpl) RequeueOutboxByFilter(ctx context.Context, f *entity.OutboxFilter) (int64, error) {
+	r.logger.Debugf("[filter: %+v] RequeueOutboxByFilter started", *f)
+
+	where, args := outboxFilterWhere(f)
+	where = append(where, "status IN ('FAILED','GAVE_UP')")
+
+	query := "UPDATE outbox_event SET status = 'NEW', attempts = 0, next_attempt_at = now() WHERE " +
+		strings.Join(where, " AND ")
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("requeue outbox by filter: %w", err)
+	}
+	r.logger.Infof("requeued %d outbox rows", result.RowsAffected())
+	return result.RowsAffected(), nil
+}
+
+// PurgeSentOutbox удаляет SENT сообщения старше olderThan
+func (r *RepoImpl) PurgeSentOutbox(ctx context.Context, olderThan time.Duration) (int64, error) {
+	r.logger.Debugf("[olderThan: %s] PurgeSentOutbox started", olderThan)
+
+	result, err := r.db.Exec(ctx, purgeSentOutboxSQL, common.PgInterval(olderThan))
+	if err != nil {
+		return 0, fmt.Errorf("purge sent outbox: %w", err)
+	}
+	r.logger.Infof("purged %d sent outbox rows (older than %s)", result.RowsAffected(), olderThan)
+	return result.RowsAffected(), nil
+}
+
+// outboxFilterWhere условия WHERE и аргументы фильтра (без пагинации)
+func outboxFilterWhere(f *entity.OutboxFilter) ([]string, []any) {
+	where := make([]string, 0, 5)
+	args := make([]any, 0, 5)
+	add := func(cond string, value any) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+
+	if len(f.Statuses) > 0 {
+		statuses := make([]string, 0, len(f.Statuses))
+		for _, st := range f.Statuses {
+			statuses = append(statuses, string(st))
+		}
+		add("status = ANY($%d::text[])", statuses)
+	}
+	if f.EventType != "" {
+		add("event_type = $%d", string(f.EventType))
+	}
+	if f.AggregateID != nil {
+		add("aggregate_id = $%d", *f.AggregateID)
+	}
+	if f.OlderThan > 0 {
+		add("created_at <= now() - $%d::interval", common.PgInterval(f.OlderThan))
+	}
+	if f.NewerThan > 0 {
+		add("created_at >= now() - $%d::interval", common.PgInterval(f.NewerThan))
+	}
+	return where, args
+}
+
+// scanOutboxAdmin читает строку outboxAdminColumns (и payload последней колонкой, если withPayload)
+func scanOutboxAdmin(row pgx.Row, withPayload bool) (*entity.OutboxEvent, error) {
+	var e entity.OutboxEvent
+	var status string
+	dest := []any{
+		&e.ID, &e.AggregateID, &e.AggregateType, &e.EventType, &status,
+		&e.Attempts, &e.NextAttemptAt, &e.CreatedAt, &e.LastError, &e.ErrorClass,
+	}
+	if withPayload {
+		dest = append(dest, &e.Payload)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	e.Status = entity.OutboxStatus(status)
+	return &e, nil
+}
+
+// ArchiveSentOutboxBatch переносит в outbox_archive до limit SENT сообщений старше olderThan
+func (r *RepoImpl) ArchiveSentOutboxBatch(ctx context.Context, olderThan time.Duration, limit int) (int64, error) {
+	result, err := r.db.Exec(ctx, archiveSentOutboxSQL, common.PgInterval(olderThan), limit)
+	if err != nil {
+		return 0, fmt.Errorf("archive sent outbox: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
+
+// DeleteSentOutboxBatch удаляет до limit SENT сообщений старше olderThan
+func (r *RepoImpl) DeleteSentOutboxBatch(ctx context.Context, olderThan time.Duration, limit int) (int64, error) {
+	result, err := r.db.Exec(ctx, deleteSentOutboxBatchSQL, common.PgInterval(olderThan), limit)
+	if err != nil {
+		return 0, fmt.Errorf("delete sent outbox: %w", err)
+	}
+	return result.RowsAffected(), nil
+}
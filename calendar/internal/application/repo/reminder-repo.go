@@ -0,0 +1,249 @@
+package repo
+
+import (
+	"calendar/internal/application/entity"
+	"calendar/internal/application/recurrence"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SyncReminders приводит напоминания события в соответствие с событием: напоминание timeForNotification
+// ставится или снимается, время остальных пересчитывается от начала события, у отменённого вхождения
+// невыполненные напоминания снимаются
+func (r *RepoImpl) SyncReminders(ctx context.Context, eventID uuid.UUID) error {
+	r.logger.Debugf("[event: %s] SyncReminders started", eventID)
+
+	if _, err := r.db.Exec(ctx, upsertNotificationReminderSQL, eventID, entity.NotificationReminderChannel); err != nil {
+		return fmt.Errorf("upsert notification reminder: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, rescheduleRemindersSQL, eventID); err != nil {
+		return fmt.Errorf("reschedule reminders: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, cancelRemindersSQL, eventID); err != nil {
+		return fmt.Errorf("cancel reminders: %w", err)
+	}
+	return r.ScheduleSeriesReminders(ctx, eventID)
+}
+
+// ScheduleSeriesReminders переносит напоминания серии (offset и timeForNotification) на ближайшие вхождения,
+// напоминание о которых ещё не наступило; переопределённые вхождения пропускаются. Для одиночного события
+// и переопределения ничего не делает.
+func (r *RepoImpl) ScheduleSeriesReminders(ctx context.Context, seriesID uuid.UUID) error {
+	return r.scheduleSeriesReminders(ctx, seriesID, 0, time.Now().UTC())
+}
+
+// AdvanceSeriesReminder переносит сработавшее (или пропущенное) напоминание серии на следующее вхождение
+// после remindAt; если вхождений больше нет, напоминание остаётся в своём статусе
+func (r *RepoImpl) AdvanceSeriesReminder(ctx context.Context, seriesID uuid.UUID, reminderID int64, remindAt time.Time) error {
+	return r.scheduleSeriesReminders(ctx, seriesID, reminderID, remindAt)
+}
+
+// scheduleSeriesReminders ставит напоминания серии (или одно напоминание reminderID, если оно не 0)
+// на первое вхождение с временем напоминания позже after
+func (r *RepoImpl) scheduleSeriesReminders(ctx context.Context, seriesID uuid.UUID, reminderID int64, after time.Time) error {
+	events, err := r.GetEventWithOverrides(ctx, seriesID.String())
+	if err != nil {
+		return err
+	}
+	master := events[0]
+	if !master.IsSeries() || master.SeriesID != nil {
+		return nil
+	}
+	r.logger.Debugf("[event: %s] ScheduleSeriesReminders started, after: %s", seriesID, after)
+
+	rows, err := r.db.Query(ctx, getSeriesRemindersSQL, seriesID)
+	if err != nil {
+		return fmt.Errorf("get series reminders: %w", err)
+	}
+	type seriesReminder struct {
+		id     int64
+		key    string
+		offset *time.Duration
+	}
+	var reminders []seriesReminder
+	for rows.Next() {
+		var (
+			rem    seriesReminder
+			offset pgtype.Int8
+		)
+		if err := rows.Scan(&rem.id, &rem.key, &offset); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan series reminder: %w", err)
+		}
+		if offset.Valid {
+			d := time.Duration(offset.Int64) * time.Second
+			rem.offset = &d
+		}
+		reminders = append(reminders, rem)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("get series reminders: %w", err)
+	}
+
+	for _, rem := range reminders {
+		if reminderID != 0 && rem.id != reminderID {
+			continue
+		}
+		lead, ok := recurrence.ReminderLead(master, rem.key, rem.offset)
+		if !ok {
+			continue
+		}
+		occStart, ok, err := recurrence.NextReminder(master, events[1:], lead, after)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if _, err := r.db.Exec(ctx, finishReminderSQL, rem.id); err != nil {
+				return fmt.Errorf("finish series reminder: %w", err)
+			}
+			continue
+		}
+		if _, err := r.db.Exec(ctx, scheduleReminderSQL, rem.id, occStart.Add(-lead), occStart); err != nil {
+			return fmt.Errorf("schedule series reminder: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReplaceReminders заменяет напоминания события (кроме timeForNotification) набором specs.
+// Напоминания, которые есть и в старом, и в новом наборе, сохраняют своё состояние.
+func (r *RepoImpl) ReplaceReminders(ctx context.Context, eventID uuid.UUID, specs []entity.ReminderSpec) error {
+	r.logger.Debugf("[event: %s] ReplaceReminders started, count: %d", eventID, len(specs))
+
+	keys := make([]string, 0, len(specs))
+	channels := make([]string, 0, len(specs))
+	offsets := make([]*int64, 0, len(specs))
+	ats := make([]*time.Time, 0, len(specs))
+	for _, s := range specs {
+		keys = append(keys, s.Key)
+		channels = append(channels, string(s.Channel))
+		offsets = append(offsets, s.OffsetSeconds)
+		ats = append(ats, s.At)
+	}
+
+	if _, err := r.db.Exec(ctx, deleteRemindersExceptSQL, eventID, keys); err != nil {
+		return fmt.Errorf("delete reminders: %w", err)
+	}
+	if len(specs) == 0 {
+		return nil
+	}
+	if _, err := r.db.Exec(ctx, insertRemindersSQL, eventID, keys, channels, offsets, ats); err != nil {
+		return fmt.Errorf("insert reminders: %w", err)
+	}
+	return nil
+}
+
+// InheritReminders заменяет напоминания переопределения вхождения (кроме timeForNotification)
+// напоминаниями серии с offset
+func (r *RepoImpl) InheritReminders(ctx context.Context, overrideID, seriesID uuid.UUID) error {
+	r.logger.Debugf("[event: %s, series: %s] InheritReminders started", overrideID, seriesID)
+
+	if _, err := r.db.Exec(ctx, deleteNotInheritedRemindersSQL, overrideID, seriesID); err != nil {
+		return fmt.Errorf("delete not inherited reminders: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, inheritRemindersSQL, overrideID, seriesID); err != nil {
+		return fmt.Errorf("inherit reminders: %w", err)
+	}
+	return nil
+}
+
+// attachReminders заполняет Reminders событий; вхождения серии (ID = ID серии) получают напоминания серии
+func (r *RepoImpl) attachReminders(ctx context.Context, events []*entity.EventResponse) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(events))
+	seen := make(map[uuid.UUID]struct{}, len(events))
+	for _, e := range events {
+		if _, ok := seen[e.ID]; ok {
+			continue
+		}
+		seen[e.ID] = struct{}{}
+		ids = append(ids, e.ID.String())
+	}
+
+	rows, err := r.db.Query(ctx, getRemindersByEventsSQL, ids)
+	if err != nil {
+		return fmt.Errorf("get reminders: %w", err)
+	}
+	defer rows.Close()
+
+	byEvent := make(map[uuid.UUID][]entity.ReminderResponse)
+	for rows.Next() {
+		var (
+			eventID uuid.UUID
+			rem     entity.ReminderResponse
+			offset  pgtype.Int8
+			at      pgtype.Timestamp
+		)
+		if err := rows.Scan(&eventID, &rem.Channel, &offset, &at, &rem.RemindAt, &rem.Status); err != nil {
+			return fmt.Errorf("scan reminder: %w", err)
+		}
+		if offset.Valid {
+			rem.Offset = (time.Duration(offset.Int64) * time.Second).String()
+		}
+		if at.Valid {
+			rem.At = &at.Time
+		}
+		byEvent[eventID] = append(byEvent[eventID], rem)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("get reminders: %w", err)
+	}
+
+	for _, e := range events {
+		e.Reminders = byEvent[e.ID]
+	}
+	return nil
+}
+
+// ClaimDueReminders блокирует до limit наступивших напоминаний; вызывается в транзакции
+func (r *RepoImpl) ClaimDueReminders(ctx context.Context, limit int) ([]entity.DueReminder, error) {
+	r.logger.Debugf("[limit: %d] ClaimDueReminders started", limit)
+
+	rows, err := r.db.Query(ctx, claimDueRemindersSQL, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim due reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []entity.DueReminder
+	for rows.Next() {
+		var (
+			rem          entity.DueReminder
+			seriesID     uuid.NullUUID
+			recurrenceID pgtype.Timestamp
+		)
+		if err := rows.Scan(&rem.ID, &rem.EventID, &rem.Channel, &rem.RemindAt, &rem.UserID, &rem.Title,
+			&rem.DateEvent, &rem.EndDateEvent, &seriesID, &recurrenceID); err != nil {
+			return nil, fmt.Errorf("scan due reminder: %w", err)
+		}
+		if seriesID.Valid {
+			rem.SeriesID = &seriesID.UUID
+		}
+		if recurrenceID.Valid {
+			rem.RecurrenceID = &recurrenceID.Time
+		}
+		reminders = append(reminders, rem)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim due reminders: %w", err)
+	}
+	return reminders, nil
+}
+
+// MarkReminders отмечает напоминания FIRED или MISSED
+func (r *RepoImpl) MarkReminders(ctx context.Context, ids []int64, status entity.ReminderStatus) error {
+	r.logger.Debugf("[IDs %v] MarkReminders %s started", ids, status)
+
+	if _, err := r.db.Exec(ctx, markRemindersSQL, ids, status); err != nil {
+		return fmt.Errorf("mark reminders %s: %w", status, err)
+	}
+	return nil
+}
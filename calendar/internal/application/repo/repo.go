@@ -3,16 +3,19 @@ package repo
 import (
 	"calendar/internal/appers"
 	"calendar/internal/application/entity"
+	"calendar/internal/application/recurrence"
 	"calendar/pkg/db"
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"go.uber.org/zap"
 )
 
@@ -25,12 +28,67 @@ type Repo interface {
 	UpdateEvent(ctx context.Context, evt *entity.Event) error
 	DeleteEvent(ctx context.Context, id string) error
 	GetEvents(ctx context.Context, start, end time.Time) ([]*entity.EventResponse, error)
+	GetEventsByFilter(ctx context.Context, start, end time.Time, f *entity.EventFilter) ([]*entity.EventResponse, error)
+	GetEventByID(ctx context.Context, id string) (*entity.EventResponse, error)
+	GetEventWithOverrides(ctx context.Context, id string) ([]*entity.EventResponse, error)
+	GetUserEvents(ctx context.Context, userID string) ([]*entity.EventResponse, error)
+	GetUserCollectionState(ctx context.Context, userID string) (entity.CollectionState, error)
+	UpsertOccurrence(ctx context.Context, o *entity.EventOccurrence) (uuid.UUID, bool, error)
+	ReplaceEvent(ctx context.Context, evt *entity.Event) error
+	DeleteOverridesExcept(ctx context.Context, seriesID uuid.UUID, keep []time.Time) error
 	DeleteOldEvents(ctx context.Context, days *int) error
 
 	InsertOutbox(ctx context.Context, e *entity.OutboxEvent) error
+	ListenOutbox(ctx context.Context, notify chan<- struct{}) error
 	ReserveOutboxBatch(ctx context.Context, lease time.Duration, limit, maxAttempts int) ([]entity.OutboxEvent, error)
-	MarkFailedWithBackoff(ctx context.Context, outboxID int, nextAttemptAt time.Time) error
-	MarkGaveUp(ctx context.Context, outboxID int) error
+	MarkFailedWithBackoff(ctx context.Context, outboxID int, nextAttemptAt time.Time, lastError, errorClass string) error
+	MarkGaveUp(ctx context.Context, outboxID int, lastError, errorClass string) error
+	ReleaseOutbox(ctx context.Context, outboxIDs []int) error
+	MarkSentBatch(ctx context.Context, outboxIDs []int) (int64, error)
+	GetOutboxDeliveries(ctx context.Context, outboxIDs []int) (map[int]map[string]struct{}, error)
+	InsertOutboxDeliveries(ctx context.Context, sink string, outboxIDs []int) error
+	InsertDeadLetter(ctx context.Context, e *entity.OutboxEvent) error
+	ListOutbox(ctx context.Context, f *entity.OutboxFilter) (*entity.OutboxPage, error)
+	GetOutboxByID(ctx context.Context, id int) (*entity.OutboxEvent, error)
+	RequeueOutbox(ctx context.Context, id int) (bool, error)
+	RequeueOutboxByFilter(ctx context.Context, f *entity.OutboxFilter) (int64, error)
+	PurgeSentOutbox(ctx context.Context, olderThan time.Duration) (int64, error)
+	ArchiveSentOutboxBatch(ctx context.Context, olderThan time.Duration, limit int) (int64, error)
+	DeleteSentOutboxBatch(ctx context.Context, olderThan time.Duration, limit int) (int64, error)
+
+	InsertInbox(ctx context.Context, m *entity.InboxMessage) (bool, error)
+	DeleteInboxBatch(ctx context.Context, olderThan time.Duration, limit int) (int64, error)
+
+	SyncReminders(ctx context.Context, eventID uuid.UUID) error
+	ReplaceReminders(ctx context.Context, eventID uuid.UUID, specs []entity.ReminderSpec) error
+	InheritReminders(ctx context.Context, overrideID, seriesID uuid.UUID) error
+	ScheduleSeriesReminders(ctx context.Context, seriesID uuid.UUID) error
+	AdvanceSeriesReminder(ctx context.Context, seriesID uuid.UUID, reminderID int64, remindAt time.Time) error
+	ClaimDueReminders(ctx context.Context, limit int) ([]entity.DueReminder, error)
+	MarkReminders(ctx context.Context, ids []int64, status entity.ReminderStatus) error
+
+	GetNotificationSettings(ctx context.Context, userID string) (*entity.NotificationSettings, error)
+	UpsertNotificationSettings(ctx context.Context, s *entity.NotificationSettings) error
+	ClaimDueDigests(ctx context.Context, limit int) ([]entity.NotificationSettings, error)
+	MarkDigestSent(ctx context.Context, userID, date string) error
+	InsertNotification(ctx context.Context, n *entity.Notification) (bool, error)
+	ReserveNotifications(ctx context.Context, lease time.Duration, limit int) ([]entity.Notification, error)
+	MarkNotificationSent(ctx context.Context, id int64) error
+	MarkNotificationFailed(ctx context.Context, id int64, status entity.NotificationStatus, nextAttemptAt time.Time, lastError string) error
+
+	ListAttendees(ctx context.Context, eventID uuid.UUID) ([]entity.Attendee, error)
+	UpsertAttendee(ctx context.Context, a *entity.Attendee) (bool, error)
+	GetAttendeeForUpdate(ctx context.Context, eventID uuid.UUID, userID string) (*entity.Attendee, error)
+	UpdateAttendeeResponse(ctx context.Context, a *entity.Attendee) error
+
+	CreateCalendar(ctx context.Context, c *entity.Calendar) error
+	GetCalendar(ctx context.Context, id uuid.UUID) (*entity.Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]entity.Calendar, error)
+	UpdateCalendar(ctx context.Context, c *entity.Calendar) error
+	ClearDefaultCalendar(ctx context.Context, userID string, keepID uuid.UUID) error
+	EnsureDefaultCalendar(ctx context.Context, userID string) (uuid.UUID, error)
+	DeleteCalendar(ctx context.Context, id uuid.UUID) error
+	UpdateOverridesCalendar(ctx context.Context, seriesID, calendarID uuid.UUID) error
 
 	HealthCheck(ctx context.Context) error
 }
@@ -56,13 +114,28 @@ func (r *RepoImpl) HealthCheck(ctx context.Context) error {
 func (r *RepoImpl) CreateEvent(ctx context.Context, evt *entity.Event) (bool, error) {
 	r.logger.Debugf("[event: %s] start inserting into DB", evt.ID)
 
+	rdate, err := parseTimes(evt.RDate)
+	if err != nil {
+		return false, fmt.Errorf("invalid rdate: %w", err)
+	}
+	exdate, err := parseTimes(evt.ExDate)
+	if err != nil {
+		return false, fmt.Errorf("invalid exdate: %w", err)
+	}
+
 	var insertedID uuid.UUID
-	err := r.db.QueryRow(ctx, createEvent,
+	err = r.db.QueryRow(ctx, createEvent,
 		evt.ID, evt.Title, evt.DateEvent, evt.CreationDate, evt.EndDateEvent,
-		evt.DescriptionEvent, evt.UserID, evt.TimeForNotification, evt.RqTm).Scan(&insertedID)
+		evt.DescriptionEvent, evt.UserID, nullIfEmpty(evt.TimeForNotification), nullIfEmpty(evt.RqTm),
+		nullIfEmpty(evt.RRuleValue()), rdate, exdate, evt.CalendarID, nullIfEmpty(evt.TimeZone)).Scan(&insertedID)
 
 	switch {
 	case err == nil:
+		if evt.RRuleValue() != "" || len(evt.RDate) > 0 {
+			if err = r.syncSeriesEnd(ctx, evt.ID); err != nil {
+				return false, err
+			}
+		}
 		r.logger.Debugf("[event: %s] inserted into DB successfully", evt.ID)
 		return true, nil
 	case errors.Is(err, pgx.ErrNoRows):
@@ -80,7 +153,10 @@ func (r *RepoImpl) CreateEvent(ctx context.Context, evt *entity.Event) (bool, er
 
 func (r *RepoImpl) UpdateEvent(ctx context.Context, evt *entity.Event) error {
 	r.logger.Debugf("[event: %s] start updating in DB", evt.ID)
-	query, args := createPatchQuery(evt)
+	query, args, err := createPatchQuery(evt)
+	if err != nil {
+		return err
+	}
 	if query == "" {
 		r.logger.Warnf("[event: %s] no fields to update", evt.ID)
 		return nil
@@ -96,10 +172,33 @@ func (r *RepoImpl) UpdateEvent(ctx context.Context, evt *entity.Event) error {
 		r.logger.Warnf("[event: %s] no rows updated", evt.ID)
 		return appers.ErrEventNotFound
 	}
+	// правило, даты или длительность могли измениться: окончание серии пересчитывается
+	if err = r.syncSeriesEnd(ctx, evt.ID); err != nil {
+		return err
+	}
 	r.logger.Debugf("[event: %s] updated in DB successfully", evt.ID)
 	return nil
 }
 
+// syncSeriesEnd пересчитывает until_at события по его текущим RRULE/RDATE/EXDATE
+func (r *RepoImpl) syncSeriesEnd(ctx context.Context, id uuid.UUID) error {
+	evt, err := r.GetEventByID(ctx, id.String())
+	if err != nil {
+		return err
+	}
+	var until *time.Time
+	if evt.IsSeries() {
+		if until, err = recurrence.SeriesEnd(evt); err != nil {
+			return fmt.Errorf("series end: %w", err)
+		}
+	}
+	if _, err = r.db.Exec(ctx, updateSeriesEnd, id, until); err != nil {
+		r.logger.Errorf("[event: %s] error updating series end in DB: %v", id, err)
+		return fmt.Errorf("error updating series end in DB: %w", err)
+	}
+	return nil
+}
+
 func (r *RepoImpl) DeleteEvent(ctx context.Context, id string) error {
 	r.logger.Debugf("[event: %s] start deleting from DB", id)
 
@@ -116,32 +215,277 @@ func (r *RepoImpl) DeleteEvent(ctx context.Context, id string) error {
 	return nil
 }
 
+// ReplaceEvent заменяет событие целиком, в отличие от UpdateEvent пустые поля сбрасываются
+func (r *RepoImpl) ReplaceEvent(ctx context.Context, evt *entity.Event) error {
+	r.logger.Debugf("[event: %s] start replacing in DB", evt.ID)
+
+	rdate, err := parseTimes(evt.RDate)
+	if err != nil {
+		return fmt.Errorf("invalid rdate: %w", err)
+	}
+	exdate, err := parseTimes(evt.ExDate)
+	if err != nil {
+		return fmt.Errorf("invalid exdate: %w", err)
+	}
+
+	result, err := r.db.Exec(ctx, replaceEvent,
+		evt.ID, evt.Title, evt.DescriptionEvent, evt.DateEvent, evt.EndDateEvent,
+		nullIfEmpty(evt.TimeForNotification), nullIfEmpty(evt.RqTm),
+		nullIfEmpty(evt.RRuleValue()), rdate, exdate, nullIfEmpty(evt.TimeZone))
+	if err != nil {
+		r.logger.Errorf("[event: %s] error replacing in DB: %v", evt.ID, err)
+		return fmt.Errorf("error replacing in DB: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		r.logger.Warnf("[event: %s] no rows replaced", evt.ID)
+		return appers.ErrEventNotFound
+	}
+	if err = r.syncSeriesEnd(ctx, evt.ID); err != nil {
+		return err
+	}
+	r.logger.Debugf("[event: %s] replaced in DB successfully", evt.ID)
+	return nil
+}
+
+// DeleteOverridesExcept удаляет переопределения вхождений серии, кроме вхождений keep
+func (r *RepoImpl) DeleteOverridesExcept(ctx context.Context, seriesID uuid.UUID, keep []time.Time) error {
+	if keep == nil {
+		keep = []time.Time{}
+	}
+	result, err := r.db.Exec(ctx, deleteOverridesExcept, seriesID, keep)
+	if err != nil {
+		return fmt.Errorf("delete overrides: %w", err)
+	}
+	if n := result.RowsAffected(); n > 0 {
+		r.logger.Infof("[event: %s] %d overrides deleted", seriesID, n)
+	}
+	return nil
+}
+
 func (r *RepoImpl) GetEvents(ctx context.Context, start, end time.Time) ([]*entity.EventResponse, error) {
-	r.logger.Debugf("[start: %s, end: %s] start getting from DB", start, end)
+	return r.GetEventsByFilter(ctx, start, end, &entity.EventFilter{})
+}
+
+// GetEventsByFilter события периода с развёрнутыми вхождениями серий: пользователя (свои и те, где он участник)
+// и календарей фильтра; пустой фильтр - все события
+func (r *RepoImpl) GetEventsByFilter(ctx context.Context, start, end time.Time, f *entity.EventFilter) ([]*entity.EventResponse, error) {
+	r.logger.Debugf("[start: %s, end: %s, filter: %+v] start getting from DB", start, end, *f)
 
-	rows, err := r.db.Query(ctx, getEventsByPeriod, start, end)
+	var calendarIDs any
+	if len(f.CalendarIDs) > 0 {
+		ids := make([]string, 0, len(f.CalendarIDs))
+		for _, id := range f.CalendarIDs {
+			ids = append(ids, id.String())
+		}
+		calendarIDs = ids
+	}
+
+	events, err := r.queryEvents(ctx, getEventsByPeriod, start, end, nullIfEmpty(f.UserID), calendarIDs)
 	if err != nil {
 		r.logger.Errorf("[start: %s, end: %s] error getting from DB: %v", start, end, err)
 		return nil, fmt.Errorf("error getting from DB: %w", err)
 	}
 
-	events := make([]*entity.EventResponse, 0)
+	// Серии разворачиваем во вхождения периода с учётом переопределений
+	series, err := r.queryEvents(ctx, getSeriesInPeriod, start, end, nullIfEmpty(f.UserID), calendarIDs)
+	if err != nil {
+		r.logger.Errorf("[start: %s, end: %s] error getting series from DB: %v", start, end, err)
+		return nil, fmt.Errorf("error getting series from DB: %w", err)
+	}
+	if len(series) == 0 {
+		if err := r.attachReminders(ctx, events); err != nil {
+			r.logger.Errorf("[start: %s, end: %s] error getting reminders from DB: %v", start, end, err)
+			return nil, fmt.Errorf("error getting from DB: %w", err)
+		}
+		r.logger.Debugf("[start: %s, end: %s] got from DB successfully", start, end)
+		return events, nil
+	}
+
+	ids := make([]string, 0, len(series))
+	for _, s := range series {
+		ids = append(ids, s.ID.String())
+	}
+	overrides, err := r.queryEvents(ctx, getOverridesBySeries, ids)
+	if err != nil {
+		r.logger.Errorf("[start: %s, end: %s] error getting overrides from DB: %v", start, end, err)
+		return nil, fmt.Errorf("error getting overrides from DB: %w", err)
+	}
+	bySeries := make(map[uuid.UUID][]*entity.EventResponse, len(series))
+	for _, o := range overrides {
+		bySeries[*o.SeriesID] = append(bySeries[*o.SeriesID], o)
+	}
+
+	for _, s := range series {
+		occurrences, err := recurrence.Expand(s, bySeries[s.ID], start, end)
+		if err != nil {
+			// битое правило не должно ломать выдачу остальных событий
+			r.logger.Errorf("[event: %s] error expanding series: %v", s.ID, err)
+			continue
+		}
+		events = append(events, occurrences...)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].DateEvent.Before(events[j].DateEvent)
+	})
+
+	if err := r.attachReminders(ctx, events); err != nil {
+		r.logger.Errorf("[start: %s, end: %s] error getting reminders from DB: %v", start, end, err)
+		return nil, fmt.Errorf("error getting from DB: %w", err)
+	}
+
+	r.logger.Debugf("[start: %s, end: %s] got from DB successfully", start, end)
+	return events, nil
+}
+
+func (r *RepoImpl) GetEventByID(ctx context.Context, id string) (*entity.EventResponse, error) {
+	r.logger.Debugf("[event: %s] start getting by id from DB", id)
+
+	evt, err := scanEvent(r.db.QueryRow(ctx, getEventByID, id))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, appers.ErrEventNotFound
+	case err != nil:
+		r.logger.Errorf("[event: %s] error getting by id from DB: %v", id, err)
+		return nil, fmt.Errorf("error getting from DB: %w", err)
+	}
+	return evt, nil
+}
+
+// GetEventWithOverrides возвращает событие (первым элементом) и переопределения вхождений, если это серия
+func (r *RepoImpl) GetEventWithOverrides(ctx context.Context, id string) ([]*entity.EventResponse, error) {
+	r.logger.Debugf("[event: %s] start getting with overrides from DB", id)
+
+	events, err := r.queryEvents(ctx, getEventWithOverrides, id)
+	if err != nil {
+		r.logger.Errorf("[event: %s] error getting with overrides from DB: %v", id, err)
+		return nil, fmt.Errorf("error getting from DB: %w", err)
+	}
+	if len(events) == 0 || events[0].ID.String() != id {
+		return nil, appers.ErrEventNotFound
+	}
+	if err := r.attachReminders(ctx, events); err != nil {
+		r.logger.Errorf("[event: %s] error getting reminders from DB: %v", id, err)
+		return nil, fmt.Errorf("error getting from DB: %w", err)
+	}
+	return events, nil
+}
+
+// GetUserEvents возвращает все строки событий пользователя без разворачивания серий (вместе с переопределениями)
+func (r *RepoImpl) GetUserEvents(ctx context.Context, userID string) ([]*entity.EventResponse, error) {
+	r.logger.Debugf("[user: %s] start getting user events from DB", userID)
+
+	events, err := r.queryEvents(ctx, getUserEvents, userID)
+	if err != nil {
+		r.logger.Errorf("[user: %s] error getting user events from DB: %v", userID, err)
+		return nil, fmt.Errorf("error getting from DB: %w", err)
+	}
+	if err := r.attachReminders(ctx, events); err != nil {
+		r.logger.Errorf("[user: %s] error getting reminders from DB: %v", userID, err)
+		return nil, fmt.Errorf("error getting from DB: %w", err)
+	}
+	return events, nil
+}
+
+func (r *RepoImpl) GetUserCollectionState(ctx context.Context, userID string) (entity.CollectionState, error) {
+	var state entity.CollectionState
+	err := r.db.QueryRow(ctx, getUserCollectionState, userID).Scan(&state.Count, &state.UpdatedAt)
+	if err != nil {
+		r.logger.Errorf("[user: %s] error getting collection state from DB: %v", userID, err)
+		return state, fmt.Errorf("error getting collection state from DB: %w", err)
+	}
+	return state, nil
+}
+
+func (r *RepoImpl) UpsertOccurrence(ctx context.Context, o *entity.EventOccurrence) (uuid.UUID, bool, error) {
+	r.logger.Debugf("[event: %s, recurrenceID: %s] start upserting occurrence in DB", o.SeriesID, o.RecurrenceID)
+
+	recurrenceID, err := time.Parse(time.RFC3339, o.RecurrenceID)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("invalid recurrenceID: %w", err)
+	}
+	recurrenceID = recurrenceID.UTC()
+
+	// ID переопределения детерминирован: повторный запрос обновляет ту же строку
+	id := entity.OccurrenceID(o.SeriesID, recurrenceID)
+
+	var upsertedID uuid.UUID
+	err = r.db.QueryRow(ctx, upsertOccurrence,
+		id, recurrenceID, nullIfEmpty(o.Title), nullIfEmpty(o.DateEvent), nullIfEmpty(o.EndDateEvent),
+		nullIfEmpty(o.DescriptionEvent), nullIfEmpty(o.TimeForNotification), o.Cancelled, o.SeriesID).Scan(&upsertedID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// строки нет в ответе: либо нет серии, либо переопределение уже такое же
+		var exists bool
+		if err := r.db.QueryRow(ctx, eventExists, id).Scan(&exists); err != nil {
+			return uuid.Nil, false, fmt.Errorf("error checking occurrence in DB: %w", err)
+		}
+		if exists {
+			r.logger.Debugf("[event: %s, recurrenceID: %s] occurrence not changed", o.SeriesID, o.RecurrenceID)
+			return id, false, nil
+		}
+		r.logger.Warnf("[event: %s] series not found", o.SeriesID)
+		return uuid.Nil, false, appers.ErrEventNotFound
+	case err != nil:
+		r.logger.Errorf("[event: %s] error upserting occurrence in DB: %v", o.SeriesID, err)
+		return uuid.Nil, false, fmt.Errorf("error upserting occurrence in DB: %w", err)
+	}
+
+	r.logger.Debugf("[event: %s, recurrenceID: %s] occurrence upserted, id: %s", o.SeriesID, o.RecurrenceID, upsertedID)
+	return upsertedID, true, nil
+}
+
+func (r *RepoImpl) queryEvents(ctx context.Context, query string, args ...any) ([]*entity.EventResponse, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
+
+	events := make([]*entity.EventResponse, 0)
 	for rows.Next() {
-		var evt entity.EventResponse
-		var updatedAt time.Time // Поле updated_at из таблицы, но не используется в EventResponse
-		err := rows.Scan(&evt.ID, &evt.Title, &evt.DateEvent, &evt.CreationDate, &evt.EndDateEvent,
-			&evt.DescriptionEvent, &evt.UserID, &evt.TimeForNotification, &evt.RqTm, &updatedAt)
+		evt, err := scanEvent(rows)
 		if err != nil {
-			r.logger.Errorf("[start: %s, end: %s] error getting from DB: %v", start, end, err)
-			return nil, fmt.Errorf("error getting from DB: %w", err)
+			return nil, err
 		}
-		events = append(events, &evt)
+		events = append(events, evt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
-	r.logger.Debugf("[start: %s, end: %s] got from DB successfully", start, end)
 	return events, nil
 }
 
+// scanEvent сканирует строку с колонками eventColumns
+func scanEvent(row pgx.Row) (*entity.EventResponse, error) {
+	var (
+		evt                              entity.EventResponse
+		notification, rqTm, recurrenceID pgtype.Timestamp
+		rrule, timeZone                  pgtype.Text
+		seriesID, calendarID             uuid.NullUUID
+	)
+	err := row.Scan(&evt.ID, &evt.Title, &evt.DateEvent, &evt.CreationDate, &evt.EndDateEvent,
+		&evt.DescriptionEvent, &evt.UserID, &notification, &rqTm,
+		&rrule, &evt.RDate, &evt.ExDate, &seriesID, &recurrenceID, &evt.Cancelled, &evt.UpdatedAt, &calendarID,
+		&timeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	evt.TimeForNotification = notification.Time
+	evt.RqTm = rqTm.Time
+	evt.RRule = rrule.String
+	evt.TimeZone = timeZone.String
+	evt.CalendarID = calendarID.UUID
+	if seriesID.Valid {
+		evt.SeriesID = &seriesID.UUID
+	}
+	if recurrenceID.Valid {
+		evt.RecurrenceID = &recurrenceID.Time
+	}
+	return &evt, nil
+}
+
 func (r *RepoImpl) DeleteOldEvents(ctx context.Context, days *int) error {
 	d := defaultDeleteDays
 	if days != nil && *days > 0 {
@@ -168,7 +512,7 @@ func (r *RepoImpl) DeleteOldEvents(ctx context.Context, days *int) error {
 }
 
 // /
-func createPatchQuery(patch *entity.Event) (string, []any) {
+func createPatchQuery(patch *entity.Event) (string, []any, error) {
 	set := make([]string, 0, 8)
 	args := make([]any, 0, 8)
 	i := 1
@@ -203,9 +547,34 @@ func createPatchQuery(patch *entity.Event) (string, []any) {
 	if patch.TimeForNotification != "" {
 		add("time_for_notification", patch.TimeForNotification)
 	}
+	// пустая строка сбрасывает RRULE
+	if patch.RRule != nil {
+		add("rrule", nullIfEmpty(*patch.RRule))
+	}
+	if patch.CalendarID != nil {
+		add("calendar_id", *patch.CalendarID)
+	}
+	// пустой массив в запросе сбрасывает RDATE/EXDATE
+	if patch.RDate != nil {
+		rdate, err := parseTimes(patch.RDate)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid rdate: %w", err)
+		}
+		add("rdate", rdate)
+	}
+	if patch.ExDate != nil {
+		exdate, err := parseTimes(patch.ExDate)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid exdate: %w", err)
+		}
+		add("exdate", exdate)
+	}
+	if patch.TimeZone != "" {
+		add("time_zone", patch.TimeZone)
+	}
 
 	if len(set) == 0 {
-		return "", nil
+		return "", nil, nil
 	}
 
 	set = append(set, "updated_at = now()")
@@ -217,7 +586,31 @@ func createPatchQuery(patch *entity.Event) (string, []any) {
 	sb.WriteString(fmt.Sprint(i))
 	args = append(args, patch.ID)
 
-	return sb.String(), args
+	return sb.String(), args, nil
+}
+
+// nullIfEmpty превращает пустую строку в NULL для nullable колонок
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// parseTimes разбирает список RFC3339 дат; nil для пустого списка (NULL в БД)
+func parseTimes(in []string) ([]time.Time, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	res := make([]time.Time, 0, len(in))
+	for _, s := range in {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t.UTC())
+	}
+	return res, nil
 }
 
 // isDuplicateKeyError проверяет, является ли ошибка ошибкой дубликата ключа (SQLSTATE 23505)
@@ -225,3 +618,8 @@ func isDuplicateKeyError(err error) bool {
 	var pgErr *pgconn.PgError
 	return errors.As(err, &pgErr) && pgErr.Code == "23505"
 }
+
+func isForeignKeyError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
@@ -1,14 +1,107 @@
 package repo
 
+const eventColumns = `id, title, start_date_event, creation_date, end_date_event,
+       description_event, user_id, time_for_notification, rq_tm,
+       rrule, rdate, exdate, series_id, recurrence_id, cancelled, updated_at, calendar_id, time_zone`
+
+// createEvent без часового пояса $14 берёт пояс календаря $13
 const createEvent = `INSERT INTO events (
                     id, title, start_date_event, creation_date, end_date_event, 
-                    description_event, user_id, time_for_notification, rq_tm) 
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+                    description_event, user_id, time_for_notification, rq_tm,
+                    rrule, rdate, exdate, calendar_id, time_zone) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+        COALESCE($14, (SELECT time_zone FROM calendars WHERE id = $13)))
 ON CONFLICT (id) DO NOTHING
 RETURNING id;`
 
-const getEventsByPeriod = `SELECT * FROM events 
-WHERE start_date_event >= $1 and end_date_event <= $2`
+// getEventsByPeriod одиночные события (не серии и не переопределения вхождений);
+// $3 - пользователь (NULL - все): его события и события, где он участник; $4 - календари (NULL - все)
+const getEventsByPeriod = `SELECT ` + eventColumns + ` FROM events 
+WHERE start_date_event >= $1 and end_date_event <= $2
+  AND rrule IS NULL AND rdate IS NULL AND series_id IS NULL
+  AND ($3::varchar IS NULL OR user_id = $3 OR id IN (SELECT event_id FROM event_attendee WHERE user_id = $3))
+  AND ($4::uuid[] IS NULL OR calendar_id = ANY($4))`
+
+// getSeriesInPeriod серии, начавшиеся не позже конца периода $2 и не закончившиеся до его начала $1
+// (until_at, либо есть переопределение вхождения, перенесённое в период); вхождения разворачиваются в коде.
+// $3 - пользователь и $4 - календари, как в getEventsByPeriod
+const getSeriesInPeriod = `SELECT ` + eventColumns + ` FROM events m
+WHERE (rrule IS NOT NULL OR rdate IS NOT NULL) AND start_date_event <= $2
+  AND (until_at IS NULL OR until_at >= $1
+       OR EXISTS (SELECT 1 FROM events o WHERE o.series_id = m.id AND o.end_date_event >= $1))
+  AND ($3::varchar IS NULL OR user_id = $3 OR id IN (SELECT event_id FROM event_attendee WHERE user_id = $3))
+  AND ($4::uuid[] IS NULL OR calendar_id = ANY($4))`
+
+const getOverridesBySeries = `SELECT ` + eventColumns + ` FROM events
+WHERE series_id = ANY($1::uuid[])`
+
+const getEventByID = `SELECT ` + eventColumns + ` FROM events WHERE id = $1`
+
+// getEventWithOverrides событие и, для серии, все переопределения её вхождений
+const getEventWithOverrides = `SELECT ` + eventColumns + ` FROM events
+WHERE id = $1 OR series_id = $1
+ORDER BY series_id NULLS FIRST, recurrence_id`
+
+const getUserEvents = `SELECT ` + eventColumns + ` FROM events
+WHERE user_id = $1
+ORDER BY series_id NULLS FIRST, start_date_event`
+
+// getUserCollectionState состояние коллекции пользователя для CalDAV ctag/sync-token
+const getUserCollectionState = `SELECT count(*), COALESCE(max(updated_at), 'epoch'::timestamp) FROM events
+WHERE user_id = $1`
+
+// upsertOccurrence создаёт/обновляет переопределение вхождения серии $9; не изменившаяся строка
+// не обновляется и не возвращается.
+// Незаданные поля наследуются от серии, время вхождения сдвигается относительно recurrence_id.
+const upsertOccurrence = `INSERT INTO events (
+                    id, title, start_date_event, creation_date, end_date_event,
+                    description_event, user_id, time_for_notification, rq_tm,
+                    series_id, recurrence_id, cancelled, calendar_id, time_zone)
+SELECT $1,
+       COALESCE($3, m.title),
+       COALESCE($4::timestamp, $2::timestamp),
+       now(),
+       COALESCE($5::timestamp, $2::timestamp + (m.end_date_event - m.start_date_event)),
+       COALESCE($6, m.description_event),
+       m.user_id,
+       COALESCE($7::timestamp, $2::timestamp - (m.start_date_event - m.time_for_notification)),
+       now(),
+       m.id, $2, $8, m.calendar_id, m.time_zone
+FROM events m
+WHERE m.id = $9 AND (m.rrule IS NOT NULL OR m.rdate IS NOT NULL)
+ON CONFLICT (series_id, recurrence_id) WHERE series_id IS NOT NULL DO UPDATE SET
+    title = EXCLUDED.title,
+    start_date_event = EXCLUDED.start_date_event,
+    end_date_event = EXCLUDED.end_date_event,
+    description_event = EXCLUDED.description_event,
+    time_for_notification = EXCLUDED.time_for_notification,
+    cancelled = EXCLUDED.cancelled,
+    updated_at = now()
+WHERE (events.title, events.start_date_event, events.end_date_event, events.description_event,
+       events.time_for_notification, events.cancelled)
+    IS DISTINCT FROM (EXCLUDED.title, EXCLUDED.start_date_event, EXCLUDED.end_date_event,
+       EXCLUDED.description_event, EXCLUDED.time_for_notification, EXCLUDED.cancelled)
+RETURNING id;`
+
+const eventExists = `SELECT EXISTS (SELECT 1 FROM events WHERE id = $1)`
+
+// updateOverridesCalendar переопределения вхождений остаются в календаре серии
+const updateOverridesCalendar = `UPDATE events SET calendar_id = $2, updated_at = now() WHERE series_id = $1`
+
+// updateSeriesEnd окончание последнего вхождения серии (NULL - бесконечная серия или не серия)
+const updateSeriesEnd = `UPDATE events SET until_at = $2 WHERE id = $1`
+
+// replaceEvent замена события целиком (CalDAV PUT): пустые описание, время уведомления и поля серии сбрасываются.
+// Владелец, календарь и дата создания не меняются.
+const replaceEvent = `UPDATE events SET
+    title = $2, description_event = $3, start_date_event = $4, end_date_event = $5,
+    time_for_notification = $6, rq_tm = $7, rrule = $8, rdate = $9, exdate = $10,
+    time_zone = COALESCE($11, time_zone), updated_at = now()
+WHERE id = $1 AND series_id IS NULL`
+
+// deleteOverridesExcept переопределения вхождений серии $1, которых нет среди recurrence_id $2
+const deleteOverridesExcept = `DELETE FROM events
+WHERE series_id = $1 AND recurrence_id <> ALL($2::timestamp[])`
 
 const deleteEvent = `DELETE FROM events WHERE id = $1`
 
@@ -16,6 +109,9 @@ const deleteOldEvents = `DELETE FROM events
 		WHERE creation_date < now() - make_interval(days => $1)`
 
 // OUTBOX
+// outboxNotifyChannel канал pg_notify из триггера trg_outbox_event_notify
+const outboxNotifyChannel = "outbox_event"
+
 const insertOutboxQuery = `
 INSERT INTO outbox_event (
   aggregate_id, aggregate_type, event_type, payload, status, attempts, next_attempt_at, created_at
@@ -23,14 +119,28 @@ INSERT INTO outbox_event (
 RETURNING id
 `
 
+// reserveBatchSQL резервирует сообщения, готовые к отправке. Порядок по агрегату: строка берётся,
+// только если по тому же aggregate_id нет более ранней неотправленной (NEW/FAILED, в том числе
+// зарезервированной другим воркером). Поэтому в батч попадает не больше одной строки на агрегат,
+// а следующая ждёт, пока предыдущая не станет SENT или GAVE_UP.
+// GAVE_UP намеренно не блокирует агрегат: одно «отравленное» сообщение не останавливает событие навсегда,
+// следующие уходят без него (консьюмер видит пропуск), а requeue отправит его уже после них.
 const reserveBatchSQL = `
 WITH picked AS (
-	SELECT id
-  	FROM outbox_event
-  	WHERE status IN ('NEW','FAILED')
-		AND next_attempt_at <= now()
-    	AND attempts < $3             
-  	ORDER BY id
+	SELECT o.id
+  	FROM outbox_event o
+  	WHERE o.status IN ('NEW','FAILED')
+		AND o.next_attempt_at <= now()
+    	AND o.attempts < $3
+		AND NOT EXISTS (
+			SELECT 1
+			FROM outbox_event prev
+			WHERE prev.aggregate_id = o.aggregate_id
+				AND prev.id < o.id
+				AND prev.status IN ('NEW','FAILED')
+				AND prev.attempts < $3
+		)
+  	ORDER BY o.id
   	FOR UPDATE SKIP LOCKED
 	LIMIT $2
 )
@@ -38,18 +148,341 @@ UPDATE outbox_event AS o
 SET next_attempt_at = now() + $1::interval
 FROM picked
 WHERE o.id = picked.id
-RETURNING o.id, o.aggregate_id, o.aggregate_type, o.event_type, o.payload, o.status, o.attempts, o.next_attempt_at, o.created_at;
+RETURNING o.id, o.aggregate_id, o.aggregate_type, o.event_type, o.payload, o.status, o.attempts, o.next_attempt_at, o.created_at,
+	COALESCE(o.last_error, ''), COALESCE(o.error_class, '');
 `
 
 const markFailedSQL = `
 UPDATE outbox_event
-SET status=$2, attempts=attempts+1, next_attempt_at=$3
+SET status=$2, attempts=attempts+1, next_attempt_at=$3, last_error=$4, error_class=$5
 WHERE id=$1`
 
 const markGaveUpSQL = `
 UPDATE outbox_event
-SET status=$2, attempts=attempts+1, next_attempt_at = now()
+SET status=$2, attempts=attempts+1, next_attempt_at = now(), last_error=$3, error_class=$4
 WHERE id=$1
 `
 
-const markSentSQL = `UPDATE outbox_event SET status=$2 WHERE id=$1`
+const insertDeadLetterSQL = `
+INSERT INTO outbox_dead_letter (
+  outbox_id, aggregate_id, aggregate_type, event_type, payload, attempts, last_error, error_class, created_at
+) VALUES ($1, $2, $3, $4, ($5)::jsonb, $6, $7, $8, $9)
+`
+
+// releaseOutboxSQL возвращает строки, удержанные relay, в очередь без ожидания аренды
+const releaseOutboxSQL = `
+UPDATE outbox_event
+SET next_attempt_at = now()
+WHERE id = ANY($1) AND status IN ('NEW','FAILED')`
+
+// markSentBatchSQL отмечает SENT все успешно отправленные сообщения батча одним запросом
+const markSentBatchSQL = `UPDATE outbox_event SET status=$2 WHERE id = ANY($1)`
+
+// OUTBOX ADMIN
+const outboxAdminColumns = `id, aggregate_id, aggregate_type, event_type, status, attempts, next_attempt_at, created_at,
+       COALESCE(last_error, ''), COALESCE(error_class, '')`
+
+const getOutboxByIDSQL = `SELECT ` + outboxAdminColumns + `, payload FROM outbox_event WHERE id = $1`
+
+// requeueOutboxSQL возвращает FAILED/GAVE_UP сообщение в очередь relay с нуля попыток
+const requeueOutboxSQL = `
+UPDATE outbox_event
+SET status = 'NEW', attempts = 0, next_attempt_at = now()
+WHERE id = $1 AND status IN ('FAILED','GAVE_UP')`
+
+const purgeSentOutboxSQL = `
+DELETE FROM outbox_event
+WHERE status = 'SENT' AND created_at <= now() - $1::interval`
+
+// OUTBOX RETENTION
+// pickSentOutboxBatch пачка SENT сообщений старше $1, не занятых другим экземпляром
+const pickSentOutboxBatch = `
+	SELECT id
+	FROM outbox_event
+	WHERE status = 'SENT' AND created_at <= now() - $1::interval
+	ORDER BY id
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED`
+
+const archiveSentOutboxSQL = `
+WITH moved AS (
+	DELETE FROM outbox_event
+	WHERE id IN (` + pickSentOutboxBatch + `)
+	RETURNING id, aggregate_id, aggregate_type, event_type, payload, status, attempts, next_attempt_at, created_at,
+		last_error, error_class
+)
+INSERT INTO outbox_archive (
+  id, aggregate_id, aggregate_type, event_type, payload, status, attempts, next_attempt_at, created_at,
+  last_error, error_class
+)
+SELECT id, aggregate_id, aggregate_type, event_type, payload, status, attempts, next_attempt_at, created_at,
+	last_error, error_class
+FROM moved
+ON CONFLICT (id) DO NOTHING`
+
+const deleteSentOutboxBatchSQL = `
+DELETE FROM outbox_event
+WHERE id IN (` + pickSentOutboxBatch + `)`
+
+// getOutboxDeliveriesSQL sink-и, в которые сообщения уже доставлены
+const getOutboxDeliveriesSQL = `SELECT outbox_id, sink FROM outbox_delivery WHERE outbox_id = ANY($1)`
+
+const insertOutboxDeliveriesSQL = `
+INSERT INTO outbox_delivery (outbox_id, sink)
+SELECT unnest($1::bigint[]), $2
+ON CONFLICT (outbox_id, sink) DO NOTHING`
+
+// insertInboxSQL занимает message_id в inbox; конфликт - сообщение уже обработано (или обрабатывается
+// параллельно: вставка ждёт коммита другой транзакции)
+const insertInboxSQL = `
+INSERT INTO inbox_message (message_id, topic, partition, "offset", event_type)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (message_id) DO NOTHING`
+
+const deleteInboxBatchSQL = `
+DELETE FROM inbox_message
+WHERE message_id IN (
+	SELECT message_id
+	FROM inbox_message
+	WHERE processed_at <= now() - $1::interval
+	ORDER BY processed_at
+	LIMIT $2
+	FOR UPDATE SKIP LOCKED)`
+
+// REMINDERS
+// cancelRemindersSQL снимает невыполненные напоминания отменённого вхождения и напоминание timeForNotification,
+// если у события больше нет этого времени
+const cancelRemindersSQL = `
+DELETE FROM event_reminder r
+USING events e
+WHERE r.event_id = $1 AND e.id = r.event_id AND r.status = 'PENDING'
+  AND (e.cancelled OR (r.reminder_key = 'timeForNotification' AND e.time_for_notification IS NULL))`
+
+// upsertNotificationReminderSQL напоминание из time_for_notification события
+const upsertNotificationReminderSQL = `
+INSERT INTO event_reminder (event_id, reminder_key, channel, absolute_at, remind_at)
+SELECT id, 'timeForNotification', $2, time_for_notification, time_for_notification
+FROM events
+WHERE id = $1 AND time_for_notification IS NOT NULL AND NOT cancelled
+ON CONFLICT (event_id, reminder_key) DO UPDATE SET absolute_at = EXCLUDED.absolute_at`
+
+// rescheduleRemindersSQL пересчитывает время напоминаний события (absolute_at или начало события минус offset).
+// Если время изменилось, напоминание (в том числе уже сработавшее) снова ждёт; с тем же временем не трогается.
+// Напоминания серии переносятся по вхождениям в приложении (ScheduleSeriesReminders).
+const rescheduleRemindersSQL = `
+UPDATE event_reminder r
+SET remind_at = COALESCE(r.absolute_at, e.start_date_event - make_interval(secs => r.offset_seconds)),
+    occurrence_at = NULL,
+    status = 'PENDING',
+    fired_at = NULL
+FROM events e
+WHERE r.event_id = $1 AND e.id = r.event_id AND NOT e.cancelled
+  AND e.rrule IS NULL AND e.rdate IS NULL
+  AND (r.remind_at IS DISTINCT FROM COALESCE(r.absolute_at, e.start_date_event - make_interval(secs => r.offset_seconds))
+    OR r.occurrence_at IS NOT NULL)`
+
+// deleteRemindersExceptSQL удаляет напоминания события (кроме timeForNotification), которых нет в новом наборе
+const deleteRemindersExceptSQL = `
+DELETE FROM event_reminder
+WHERE event_id = $1 AND reminder_key <> 'timeForNotification' AND reminder_key <> ALL($2::text[])`
+
+// insertRemindersSQL добавляет напоминания; существующие с тем же ключом не трогает
+const insertRemindersSQL = `
+INSERT INTO event_reminder (event_id, reminder_key, channel, offset_seconds, absolute_at, remind_at)
+SELECT e.id, k.key, k.channel, k.offset_seconds, k.absolute_at,
+       COALESCE(k.absolute_at, e.start_date_event - make_interval(secs => k.offset_seconds))
+FROM events e,
+     unnest($2::text[], $3::text[], $4::bigint[], $5::timestamp[]) AS k(key, channel, offset_seconds, absolute_at)
+WHERE e.id = $1
+ON CONFLICT (event_id, reminder_key) DO NOTHING`
+
+// inheritRemindersSQL копирует в переопределение вхождения $1 напоминания серии $2 с offset
+// (абсолютные напоминания серии к вхождению не относятся)
+const inheritRemindersSQL = `
+INSERT INTO event_reminder (event_id, reminder_key, channel, offset_seconds, remind_at)
+SELECT e.id, r.reminder_key, r.channel, r.offset_seconds, e.start_date_event - make_interval(secs => r.offset_seconds)
+FROM events e
+JOIN event_reminder r ON r.event_id = $2 AND r.offset_seconds IS NOT NULL
+WHERE e.id = $1
+ON CONFLICT (event_id, reminder_key) DO NOTHING`
+
+// deleteNotInheritedRemindersSQL удаляет у переопределения напоминания, которых больше нет у серии
+const deleteNotInheritedRemindersSQL = `
+DELETE FROM event_reminder
+WHERE event_id = $1 AND reminder_key <> 'timeForNotification'
+  AND reminder_key NOT IN (SELECT reminder_key FROM event_reminder WHERE event_id = $2 AND offset_seconds IS NOT NULL)`
+
+// getSeriesRemindersSQL напоминания серии, повторяющиеся для каждого вхождения (offset и timeForNotification)
+const getSeriesRemindersSQL = `
+SELECT id, reminder_key, offset_seconds
+FROM event_reminder
+WHERE event_id = $1 AND (offset_seconds IS NOT NULL OR reminder_key = 'timeForNotification')`
+
+// scheduleReminderSQL переносит напоминание серии на вхождение $3; то же вхождение не трогается
+const scheduleReminderSQL = `
+UPDATE event_reminder
+SET remind_at = $2, occurrence_at = $3, status = 'PENDING'
+WHERE id = $1 AND (occurrence_at IS DISTINCT FROM $3 OR remind_at IS DISTINCT FROM $2)`
+
+// finishReminderSQL напоминание серии, у которой не осталось вхождений с этим напоминанием
+const finishReminderSQL = `UPDATE event_reminder SET status = 'MISSED' WHERE id = $1 AND status = 'PENDING'`
+
+const getRemindersByEventsSQL = `
+SELECT event_id, channel, offset_seconds, absolute_at, remind_at, status
+FROM event_reminder
+WHERE event_id = ANY($1::uuid[])
+ORDER BY remind_at, id`
+
+// claimDueRemindersSQL наступившие напоминания, не занятые другим экземпляром; строки заблокированы
+// до конца транзакции. Для напоминания серии время события - время вхождения occurrence_at, recurrence_id - его начало.
+const claimDueRemindersSQL = `
+SELECT r.id, r.event_id, r.channel, r.remind_at, e.user_id, e.title,
+       COALESCE(r.occurrence_at, e.start_date_event),
+       COALESCE(r.occurrence_at, e.start_date_event) + (e.end_date_event - e.start_date_event),
+       e.series_id,
+       CASE WHEN e.rrule IS NOT NULL OR e.rdate IS NOT NULL THEN COALESCE(r.occurrence_at, e.start_date_event) END
+FROM event_reminder r
+JOIN events e ON e.id = r.event_id
+WHERE r.status = 'PENDING' AND r.remind_at <= now()
+ORDER BY r.remind_at
+LIMIT $1
+FOR UPDATE OF r SKIP LOCKED`
+
+const markRemindersSQL = `UPDATE event_reminder SET status = $2, fired_at = now() WHERE id = ANY($1)`
+
+// NOTIFICATIONS
+
+const notificationSettingsColumns = `user_id, COALESCE(email, ''), COALESCE(webhook_url, ''), COALESCE(locale, ''),
+	COALESCE(time_zone, ''), digest_enabled, to_char(digest_time, 'HH24:MI'), digest_channel, updated_at`
+
+const getNotificationSettingsSQL = `
+SELECT ` + notificationSettingsColumns + `
+FROM notification_settings
+WHERE user_id = $1`
+
+// upsertNotificationSettingsSQL при изменении времени или часового пояса сводки digest_sent_on не сбрасывается:
+// сводка за уже отправленный день не уходит повторно
+const upsertNotificationSettingsSQL = `
+INSERT INTO notification_settings (user_id, email, webhook_url, locale, time_zone, digest_enabled, digest_time,
+	digest_channel, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7::time, $8, now())
+ON CONFLICT (user_id) DO UPDATE
+SET email = EXCLUDED.email, webhook_url = EXCLUDED.webhook_url, locale = EXCLUDED.locale,
+	time_zone = EXCLUDED.time_zone, digest_enabled = EXCLUDED.digest_enabled, digest_time = EXCLUDED.digest_time,
+	digest_channel = EXCLUDED.digest_channel, updated_at = EXCLUDED.updated_at
+RETURNING updated_at`
+
+// claimDueDigestsSQL пользователи, у которых по их часовому поясу наступило время сводки, а сводки за
+// сегодняшнюю локальную дату ещё не было; строки заблокированы до конца транзакции
+const claimDueDigestsSQL = `
+SELECT ` + notificationSettingsColumns + `
+FROM notification_settings
+WHERE digest_enabled
+  AND (now() AT TIME ZONE COALESCE(time_zone, 'UTC'))::time >= digest_time
+  AND (digest_sent_on IS NULL OR digest_sent_on < (now() AT TIME ZONE COALESCE(time_zone, 'UTC'))::date)
+ORDER BY user_id
+LIMIT $1
+FOR UPDATE SKIP LOCKED`
+
+const markDigestSentSQL = `UPDATE notification_settings SET digest_sent_on = $2::date WHERE user_id = $1`
+
+// insertNotificationSQL повторное сообщение (после отката inbox) не создаёт второе уведомление
+const insertNotificationSQL = `
+INSERT INTO notification (message_id, event_type, user_id, channel, recipient, locale, subject, body_text, body_html,
+	payload, status, last_error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+ON CONFLICT (message_id, user_id, channel) DO NOTHING`
+
+// reserveNotificationsSQL забирает наступившие уведомления и сдвигает next_attempt_at на аренду:
+// пока воркер отправляет, другие экземпляры строку не берут, а после падения она вернётся сама
+const reserveNotificationsSQL = `
+WITH picked AS (
+	SELECT id
+	FROM notification
+	WHERE status = 'PENDING' AND next_attempt_at <= now()
+	ORDER BY next_attempt_at, id
+	FOR UPDATE SKIP LOCKED
+	LIMIT $2
+)
+UPDATE notification AS n
+SET next_attempt_at = now() + $1::interval
+FROM picked
+WHERE n.id = picked.id
+RETURNING n.id, n.message_id, n.event_type, n.user_id, n.channel, COALESCE(n.recipient, ''), n.locale, n.subject,
+	n.body_text, COALESCE(n.body_html, ''), n.payload, n.status, n.attempts, n.next_attempt_at, n.created_at`
+
+const markNotificationSentSQL = `
+UPDATE notification
+SET status = 'SENT', attempts = attempts + 1, sent_at = now(), last_error = NULL
+WHERE id = $1`
+
+const markNotificationFailedSQL = `
+UPDATE notification
+SET status = $2, attempts = attempts + 1, next_attempt_at = $3, last_error = $4
+WHERE id = $1`
+
+// ATTENDEES
+const attendeeColumns = `event_id, user_id, role, partstat, COALESCE(comment, ''), invited_at, responded_at, updated_at`
+
+const listAttendeesSQL = `SELECT ` + attendeeColumns + ` FROM event_attendee
+WHERE event_id = $1
+ORDER BY invited_at, user_id`
+
+// upsertAttendeeSQL приглашает участника; уже приглашённому меняется только роль.
+// inserted (xmax = 0) - участник добавлен этим запросом
+const upsertAttendeeSQL = `
+INSERT INTO event_attendee (event_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (event_id, user_id) DO UPDATE
+SET role = EXCLUDED.role,
+    updated_at = CASE WHEN event_attendee.role = EXCLUDED.role THEN event_attendee.updated_at ELSE now() END
+RETURNING ` + attendeeColumns + `, (xmax = 0) AS inserted`
+
+const getAttendeeForUpdateSQL = `SELECT ` + attendeeColumns + ` FROM event_attendee
+WHERE event_id = $1 AND user_id = $2
+FOR UPDATE`
+
+const updateAttendeeResponseSQL = `
+UPDATE event_attendee
+SET partstat = $3, comment = $4, responded_at = now(), updated_at = now()
+WHERE event_id = $1 AND user_id = $2
+RETURNING responded_at, updated_at`
+
+// CALENDARS
+const calendarColumns = `id, user_id, name, COALESCE(color, ''), COALESCE(time_zone, ''), is_default, created_at, updated_at`
+
+const insertCalendarSQL = `
+INSERT INTO calendars (id, user_id, name, color, time_zone, is_default)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING created_at, updated_at`
+
+const getCalendarSQL = `SELECT ` + calendarColumns + ` FROM calendars WHERE id = $1`
+
+const listCalendarsSQL = `SELECT ` + calendarColumns + ` FROM calendars
+WHERE user_id = $1
+ORDER BY is_default DESC, created_at, id`
+
+const updateCalendarSQL = `
+UPDATE calendars
+SET name = $2, color = $3, time_zone = $4, is_default = $5, updated_at = now()
+WHERE id = $1
+RETURNING updated_at`
+
+// clearDefaultCalendarSQL снимает признак основного календаря перед назначением нового
+const clearDefaultCalendarSQL = `UPDATE calendars SET is_default = false, updated_at = now()
+WHERE user_id = $1 AND is_default AND id <> $2`
+
+// ensureDefaultCalendarSQL создаёт основной календарь пользователя, если его нет, и возвращает его ID
+const ensureDefaultCalendarSQL = `
+WITH ins AS (
+	INSERT INTO calendars (id, user_id, name, is_default)
+	VALUES ($1, $2, $3, true)
+	ON CONFLICT (user_id) WHERE is_default DO NOTHING
+	RETURNING id
+)
+SELECT id FROM ins
+UNION ALL
+SELECT id FROM calendars WHERE user_id = $2 AND is_default
+LIMIT 1`
+
+const deleteCalendarSQL = `DELETE FROM calendars WHERE id = $1`
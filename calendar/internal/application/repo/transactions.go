@@ -3,16 +3,32 @@ package repo
 import (
 	"calendar/internal/appers"
 	"calendar/internal/application/entity"
+	"calendar/internal/application/recurrence"
 	"calendar/pkg/config"
 	"context"
+	"encoding/json"
 	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
 	"go.uber.org/zap"
 )
 
 type Transactions interface {
-	CreateEvent(ctx context.Context, in *entity.Event, payload []byte) error
+	CreateEvent(ctx context.Context, in *entity.Event, occurrences []*entity.EventOccurrence, payload []byte) error
+	UpdateEvent(ctx context.Context, in *entity.Event, payload []byte) error
+	UpsertOccurrence(ctx context.Context, in *entity.EventOccurrence, payload []byte) (bool, error)
+	ReplaceEvent(ctx context.Context, in *entity.Event, occurrences []*entity.EventOccurrence, payload []byte) error
+	DeleteEvent(ctx context.Context, id string) error
 	GetOperationsFromOutbox(ctx context.Context, c config.RelayConfig) ([]entity.OutboxEvent, error)
-	MarkSentAndUpdateEvent(ctx context.Context, outboxID int) error
+	MarkGaveUpToDeadLetter(ctx context.Context, e *entity.OutboxEvent) error
+	MarkDelivered(ctx context.Context, partial map[string][]int, sentIDs []int) error
+	HandleInbox(ctx context.Context, m *entity.InboxMessage, handle func(ctx context.Context) error) (bool, error)
+	FireDueReminders(ctx context.Context, limit int, maxLateness time.Duration) (fired, missed int, err error)
+	SendDueDigests(ctx context.Context, limit int) (sent, empty int, err error)
+	InviteAttendees(ctx context.Context, event *entity.EventResponse, invites []entity.AttendeeInvite) ([]entity.Attendee, error)
+	RespondAttendee(ctx context.Context, event *entity.EventResponse, userID string, rsvp *entity.RSVPRequest) (*entity.Attendee, error)
+	SaveCalendar(ctx context.Context, c *entity.Calendar, create bool) error
 }
 type TransactionsImpl struct {
 	repo   *RepoImpl
@@ -23,7 +39,9 @@ func NewTransactions(repo *RepoImpl, logger *zap.SugaredLogger) *TransactionsImp
 	return &TransactionsImpl{repo: repo, logger: logger}
 }
 
-func (t *TransactionsImpl) CreateEvent(ctx context.Context, in *entity.Event, payload []byte) error {
+// CreateEvent создаёт событие и пишет event_created; переопределения вхождений новой серии (occurrences)
+// сохраняются в той же транзакции с одним event_updated после event_created
+func (t *TransactionsImpl) CreateEvent(ctx context.Context, in *entity.Event, occurrences []*entity.EventOccurrence, payload []byte) error {
 
 	if len(payload) == 0 {
 		t.logger.Warnf("[ID %s] empty payload for outbox", in.ID)
@@ -35,29 +53,266 @@ func (t *TransactionsImpl) CreateEvent(ctx context.Context, in *entity.Event, pa
 			t.logger.Errorf("[ID %s] insert event failed: %v", in.ID, err)
 			return err
 		}
+		if !inserted {
+			// запись уже существует
+			t.logger.Infof("[ID %s] idempotent hit: Event already exists", in.ID)
+			return appers.ErrEventAlreadyExists
+		}
 
-		if inserted {
-			evt := entity.OutboxEvent{
-				AggregateID:   in.ID,
-				AggregateType: entity.AggregateEvent,
-				EventType:     entity.EventCreated,
-				Payload:       payload,
-				Status:        entity.OutboxNew,
+		if err = t.insertOutbox(ctx, entity.AggregateEvent, in.ID, entity.EventCreated, payload); err != nil {
+			t.logger.Errorf("[ID %s] insert outbox failed: %v", in.ID, err)
+			return err
+		}
+		if in.TimeForNotification != "" || len(in.Reminders) > 0 {
+			if err = t.syncReminders(ctx, in); err != nil {
+				return err
 			}
+		}
+		if len(occurrences) == 0 {
+			return nil
+		}
 
-			if err = t.repo.InsertOutbox(ctx, &evt); err != nil {
-				t.logger.Errorf("[ID %s] insert outbox failed: %v", in.ID, err)
+		if _, err = t.checkOccurrences(ctx, in.ID, occurrences); err != nil {
+			return err
+		}
+		for _, o := range occurrences {
+			if _, err = t.upsertOccurrence(ctx, o); err != nil {
 				return err
 			}
-		} else {
-			// запись уже существует
-			t.logger.Infof("[ID %s] idempotent hit: Event already exists", in.ID)
-			return appers.ErrEventAlreadyExists
+		}
+		updated, err := json.Marshal(entity.EventUpdatedPayload{
+			ID:          in.ID,
+			Occurrences: occurrences,
+			UpdatedAt:   time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("marshal occurrences: %w", err)
+		}
+		if err = t.insertOutbox(ctx, entity.AggregateEvent, in.ID, entity.EventUpdated, updated); err != nil {
+			t.logger.Errorf("[ID %s] insert outbox failed: %v", in.ID, err)
+			return err
+		}
+		return nil
+	})
+}
+
+// UpdateEvent изменяет событие; для переопределения вхождения, как в DeleteEvent, агрегатом event_updated
+// остаётся серия
+func (t *TransactionsImpl) UpdateEvent(ctx context.Context, in *entity.Event, payload []byte) error {
+	return t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := t.repo.GetEventByID(ctx, in.ID.String())
+		if err != nil {
+			return err
+		}
+		if err := t.repo.UpdateEvent(ctx, in); err != nil {
+			return err
+		}
+		if in.CalendarID != nil {
+			if err := t.repo.UpdateOverridesCalendar(ctx, in.ID, *in.CalendarID); err != nil {
+				return err
+			}
+		}
+		if current.SeriesID == nil && (in.DateEvent != "" || in.TimeZone != "" || in.RRule != nil || in.RDate != nil || in.ExDate != nil) {
+			if err := t.pruneOverrides(ctx, in.ID); err != nil {
+				return err
+			}
+		}
+		// время начала могло измениться: напоминания с offset пересчитываются всегда
+		if err := t.syncReminders(ctx, in); err != nil {
+			return err
+		}
+
+		aggregateID := in.ID
+		if current.SeriesID != nil {
+			aggregateID = *current.SeriesID
+		}
+		if err := t.insertOutbox(ctx, entity.AggregateEvent, aggregateID, entity.EventUpdated, payload); err != nil {
+			t.logger.Errorf("[ID %s] insert outbox failed: %v", in.ID, err)
+			return err
+		}
+		return nil
+	})
+}
+
+// UpsertOccurrence изменение вхождения - изменение серии: event_updated с ID серии.
+// false - переопределение уже такое же, event_updated не пишется.
+func (t *TransactionsImpl) UpsertOccurrence(ctx context.Context, in *entity.EventOccurrence, payload []byte) (bool, error) {
+	var changed bool
+	err := t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if changed, err = t.upsertOccurrence(ctx, in); err != nil || !changed {
+			return err
+		}
+
+		if err := t.insertOutbox(ctx, entity.AggregateEvent, in.SeriesID, entity.EventUpdated, payload); err != nil {
+			t.logger.Errorf("[ID %s] insert outbox failed: %v", in.SeriesID, err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return changed, nil
+}
+
+// ReplaceEvent заменяет событие целиком вместе с набором переопределений вхождений (ресурс CalDAV):
+// переопределения, которых нет в occurrences, удаляются. Пишется один event_updated.
+func (t *TransactionsImpl) ReplaceEvent(ctx context.Context, in *entity.Event, occurrences []*entity.EventOccurrence, payload []byte) error {
+	return t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := t.repo.ReplaceEvent(ctx, in); err != nil {
+			return err
+		}
+
+		keep, err := t.checkOccurrences(ctx, in.ID, occurrences)
+		if err != nil {
+			return err
+		}
+		if err := t.repo.DeleteOverridesExcept(ctx, in.ID, keep); err != nil {
+			return err
+		}
+		if err := t.syncReminders(ctx, in); err != nil {
+			return err
+		}
+		for _, o := range occurrences {
+			if _, err := t.upsertOccurrence(ctx, o); err != nil {
+				return err
+			}
+		}
+
+		if err := t.insertOutbox(ctx, entity.AggregateEvent, in.ID, entity.EventUpdated, payload); err != nil {
+			t.logger.Errorf("[ID %s] insert outbox failed: %v", in.ID, err)
+			return err
+		}
+		return nil
+	})
+}
+
+// checkOccurrences проверяет, что recurrenceID каждого переопределения - вхождение серии seriesID,
+// и возвращает их в UTC; appers.ErrOccurrenceNotFound - такого вхождения нет
+func (t *TransactionsImpl) checkOccurrences(ctx context.Context, seriesID uuid.UUID, occurrences []*entity.EventOccurrence) ([]time.Time, error) {
+	keep := make([]time.Time, 0, len(occurrences))
+	if len(occurrences) == 0 {
+		return keep, nil
+	}
+	series, err := t.repo.GetEventByID(ctx, seriesID.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range occurrences {
+		recurrenceID, err := time.Parse(time.RFC3339, o.RecurrenceID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recurrenceID: %w", err)
+		}
+		recurrenceID = recurrenceID.UTC()
+		ok, err := recurrence.IsOccurrence(series, recurrenceID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			t.logger.Warnf("[event: %s, recurrenceID: %s] no such occurrence", seriesID, o.RecurrenceID)
+			return nil, appers.ErrOccurrenceNotFound
+		}
+		keep = append(keep, recurrenceID)
+	}
+	return keep, nil
+}
+
+// upsertOccurrence сохраняет переопределение вхождения и его напоминания: напоминания серии с offset
+// от нового начала, timeForNotification (своё или серии со сдвигом); у отменённого вхождения снимаются.
+// Напоминания пересчитываются, даже если строка не изменилась (false): при замене могла измениться серия.
+func (t *TransactionsImpl) upsertOccurrence(ctx context.Context, in *entity.EventOccurrence) (bool, error) {
+	overrideID, changed, err := t.repo.UpsertOccurrence(ctx, in)
+	if err != nil {
+		return false, err
+	}
+	if err := t.repo.InheritReminders(ctx, overrideID, in.SeriesID); err != nil {
+		return false, err
+	}
+	if err := t.repo.SyncReminders(ctx, overrideID); err != nil {
+		return false, err
+	}
+	// напоминания серии не должны срабатывать для переопределённого вхождения
+	return changed, t.repo.ScheduleSeriesReminders(ctx, in.SeriesID)
+}
+
+// DeleteEvent удаляет событие и пишет tombstone event_deleted. Для переопределения вхождения
+// агрегатом остаётся серия, чтобы сообщения по ней шли в одном порядке.
+func (t *TransactionsImpl) DeleteEvent(ctx context.Context, id string) error {
+	return t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		deleted, err := t.repo.GetEventByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = t.repo.DeleteEvent(ctx, id); err != nil {
+			return err
+		}
+
+		payload, err := json.Marshal(entity.EventDeletedPayload{
+			ID:        deleted.ID,
+			UserID:    deleted.UserID,
+			SeriesID:  deleted.SeriesID,
+			Deleted:   true,
+			DeletedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return fmt.Errorf("marshal tombstone: %w", err)
+		}
+
+		aggregateID := deleted.ID
+		if deleted.SeriesID != nil {
+			aggregateID = *deleted.SeriesID
+		}
+		if err = t.insertOutbox(ctx, entity.AggregateEvent, aggregateID, entity.EventDeleted, payload); err != nil {
+			t.logger.Errorf("[ID %s] insert outbox failed: %v", id, err)
+			return err
 		}
 		return nil
 	})
 }
 
+// pruneOverrides удаляет переопределения, чей recurrence_id после изменения начала или правила серии
+// больше не вхождение (у события, переставшего быть серией, - все)
+func (t *TransactionsImpl) pruneOverrides(ctx context.Context, seriesID uuid.UUID) error {
+	events, err := t.repo.GetEventWithOverrides(ctx, seriesID.String())
+	if err != nil {
+		return err
+	}
+	master, overrides := events[0], events[1:]
+	if len(overrides) == 0 {
+		return nil
+	}
+
+	keep := make([]time.Time, 0, len(overrides))
+	if master.IsSeries() {
+		for _, o := range overrides {
+			ok, err := recurrence.IsOccurrence(master, o.RecurrenceID.UTC())
+			if err != nil {
+				return err
+			}
+			if ok {
+				keep = append(keep, o.RecurrenceID.UTC())
+			}
+		}
+	}
+	return t.repo.DeleteOverridesExcept(ctx, seriesID, keep)
+}
+
+// syncReminders заменяет напоминания события, если они переданы (nil - не менять), и пересчитывает их время
+func (t *TransactionsImpl) syncReminders(ctx context.Context, in *entity.Event) error {
+	if in.Reminders != nil {
+		specs, err := in.ReminderSpecs()
+		if err != nil {
+			return err
+		}
+		if err := t.repo.ReplaceReminders(ctx, in.ID, specs); err != nil {
+			t.logger.Errorf("[ID %s] replace reminders failed: %v", in.ID, err)
+			return err
+		}
+	}
+	return t.repo.SyncReminders(ctx, in.ID)
+}
+
 func (t *TransactionsImpl) GetOperationsFromOutbox(ctx context.Context, c config.RelayConfig) ([]entity.OutboxEvent, error) {
 	var events []entity.OutboxEvent
 	err := t.repo.db.WithinTransaction(ctx, func(txCtx context.Context) error {
@@ -72,25 +327,334 @@ func (t *TransactionsImpl) GetOperationsFromOutbox(ctx context.Context, c config
 	return events, nil
 }
 
-func (t *TransactionsImpl) MarkSentAndUpdateEvent(ctx context.Context, outboxID int) error {
+// MarkDelivered одной транзакцией запоминает частичные доставки (partial: sink -> ID сообщений, которые
+// ещё не доставлены в другие sink-и) и отмечает SENT полностью доставленные сообщения
+func (t *TransactionsImpl) MarkDelivered(ctx context.Context, partial map[string][]int, sentIDs []int) error {
+	return t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		for sink, ids := range partial {
+			if err := t.repo.InsertOutboxDeliveries(ctx, sink, ids); err != nil {
+				return err
+			}
+		}
+		if len(sentIDs) == 0 {
+			return nil
+		}
+		n, err := t.repo.MarkSentBatch(ctx, sentIDs)
+		if err != nil {
+			return err
+		}
+		if n != int64(len(sentIDs)) {
+			t.logger.Warnf("[IDs %v] marked sent %d of %d rows", sentIDs, n, len(sentIDs))
+		}
+		return nil
+	})
+}
 
+// MarkGaveUpToDeadLetter переводит сообщение в GAVE_UP и паркует его в outbox_dead_letter одной транзакцией
+func (t *TransactionsImpl) MarkGaveUpToDeadLetter(ctx context.Context, e *entity.OutboxEvent) error {
+	return t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := t.repo.MarkGaveUp(ctx, e.ID, e.LastError, e.ErrorClass); err != nil {
+			return err
+		}
+		// в dead letter попадает итоговое число попыток, включая последнюю
+		dead := *e
+		dead.Attempts++
+		return t.repo.InsertDeadLetter(ctx, &dead)
+	})
+}
+
+// HandleInbox одной транзакцией проверяет дубль, обрабатывает сообщение и записывает его в inbox.
+// false - сообщение уже обработано, handle не вызывался. Ошибка handle откатывает и запись inbox:
+// повторная доставка обработает сообщение заново.
+func (t *TransactionsImpl) HandleInbox(ctx context.Context, m *entity.InboxMessage, handle func(ctx context.Context) error) (bool, error) {
+	var inserted bool
 	err := t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
-		t.logger.Infof("[ID %d] start transaction to mark event as sent", outboxID)
-		result, err := t.repo.db.Exec(ctx, markSentSQL, outboxID, entity.OutboxSent)
+		var err error
+		inserted, err = t.repo.InsertInbox(ctx, m)
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			t.logger.Infof("[message: %s] idempotent hit: inbox message already processed", m.ID)
+			return nil
+		}
+		return handle(ctx)
+	})
+	if err != nil {
+		return false, err
+	}
+	return inserted, nil
+}
+
+// FireDueReminders одной транзакцией забирает до limit наступивших напоминаний, пишет по ним event_reminder
+// в outbox и отмечает FIRED. Опоздавшие больше чем на maxLateness (если > 0) отмечаются MISSED без сообщения.
+// Напоминания серии после этого переносятся на следующее вхождение.
+// Строки заблокированы до коммита, поэтому другие экземпляры их пропускают, а после коммита не видят.
+func (t *TransactionsImpl) FireDueReminders(ctx context.Context, limit int, maxLateness time.Duration) (fired, missed int, err error) {
+	err = t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		reminders, err := t.repo.ClaimDueReminders(ctx, limit)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		var firedIDs, missedIDs []int64
+		for _, rem := range reminders {
+			if maxLateness > 0 && now.Sub(rem.RemindAt) > maxLateness {
+				t.logger.Warnf("[event: %s, reminder: %d] reminder missed, remindAt: %s", rem.EventID, rem.ID, rem.RemindAt)
+				missedIDs = append(missedIDs, rem.ID)
+				continue
+			}
+
+			payload, err := json.Marshal(entity.EventReminderPayload{
+				ID:           rem.EventID,
+				ReminderID:   rem.ID,
+				Channel:      rem.Channel,
+				UserID:       rem.UserID,
+				Title:        rem.Title,
+				DateEvent:    rem.DateEvent,
+				EndDateEvent: rem.EndDateEvent,
+				SeriesID:     rem.SeriesID,
+				RecurrenceID: rem.RecurrenceID,
+				RemindAt:     rem.RemindAt,
+				FiredAt:      now,
+			})
+			if err != nil {
+				return fmt.Errorf("marshal reminder: %w", err)
+			}
+
+			// агрегат - серия, как у остальных сообщений о вхождениях
+			aggregateID := rem.EventID
+			if rem.SeriesID != nil {
+				aggregateID = *rem.SeriesID
+			}
+			if err := t.insertOutbox(ctx, entity.AggregateEvent, aggregateID, entity.EventReminder, payload); err != nil {
+				t.logger.Errorf("[event: %s, reminder: %d] insert outbox failed: %v", rem.EventID, rem.ID, err)
+				return err
+			}
+			firedIDs = append(firedIDs, rem.ID)
+		}
+
+		if len(firedIDs) > 0 {
+			if err := t.repo.MarkReminders(ctx, firedIDs, entity.ReminderFired); err != nil {
+				return err
+			}
+		}
+		if len(missedIDs) > 0 {
+			if err := t.repo.MarkReminders(ctx, missedIDs, entity.ReminderMissed); err != nil {
+				return err
+			}
+		}
+		// напоминание серии - одна строка: после срабатывания переносится на следующее вхождение
+		for _, rem := range reminders {
+			if rem.RecurrenceID == nil {
+				continue
+			}
+			// после пропуска - сразу на ближайшее вхождение, а не по одному пропущенному за запуск
+			after := rem.RemindAt
+			if maxLateness > 0 && now.Sub(rem.RemindAt) > maxLateness {
+				after = now
+			}
+			if err := t.repo.AdvanceSeriesReminder(ctx, rem.EventID, rem.ID, after); err != nil {
+				t.logger.Errorf("[event: %s, reminder: %d] advance series reminder failed: %v", rem.EventID, rem.ID, err)
+				return err
+			}
+		}
+		fired, missed = len(firedIDs), len(missedIDs)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return fired, missed, nil
+}
+
+// SendDueDigests одной транзакцией забирает до limit пользователей, у которых наступило время сводки, собирает
+// события их локального дня (свои и те, где пользователь участник) через GetEventsByFilter и пишет agenda_digest в outbox. Пустой день сообщения не даёт,
+// но тоже отмечается в digest_sent_on, чтобы пользователь не выбирался повторно до следующего дня.
+func (t *TransactionsImpl) SendDueDigests(ctx context.Context, limit int) (sent, empty int, err error) {
+	err = t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		sent, empty = 0, 0
+
+		users, err := t.repo.ClaimDueDigests(ctx, limit)
 		if err != nil {
-			return fmt.Errorf("outbox mark sent: %w", err)
+			return err
 		}
-		rowsAffected := result.RowsAffected()
-		if rowsAffected == 0 {
-			return fmt.Errorf("[ID %d] outbox not found", outboxID)
+
+		now := time.Now().UTC()
+		for _, u := range users {
+			loc := time.UTC
+			if u.TimeZone != "" {
+				if loc, err = time.LoadLocation(u.TimeZone); err != nil {
+					t.logger.Warnf("[user: %s] unknown time zone %q, using UTC: %v", u.UserID, u.TimeZone, err)
+					loc = time.UTC
+				}
+			}
+			date, start, end := entity.AgendaDay(now, loc)
+
+			events, err := t.repo.GetEventsByFilter(ctx, start, end, &entity.EventFilter{UserID: u.UserID})
+			if err != nil {
+				return err
+			}
+
+			var items []entity.AgendaItem
+			for _, e := range events {
+				if !e.Cancelled {
+					items = append(items, entity.NewAgendaItem(e, loc))
+				}
+			}
+
+			if len(items) == 0 {
+				empty++
+			} else {
+				payload, err := json.Marshal(entity.AgendaDigestPayload{
+					UserID:      u.UserID,
+					Channel:     u.DigestChannel,
+					Date:        date,
+					TimeZone:    loc.String(),
+					Events:      items,
+					GeneratedAt: now,
+				})
+				if err != nil {
+					return fmt.Errorf("marshal agenda digest: %w", err)
+				}
+				if err := t.insertOutbox(ctx, entity.AggregateUser, entity.UserAggregateID(u.UserID), entity.AgendaDigest, payload); err != nil {
+					t.logger.Errorf("[user: %s, date: %s] insert outbox failed: %v", u.UserID, date, err)
+					return err
+				}
+				sent++
+			}
+
+			if err := t.repo.MarkDigestSent(ctx, u.UserID, date); err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return sent, empty, nil
+}
 
+// InviteAttendees одной транзакцией приглашает участников события и пишет invitation_sent в outbox по каждому
+// новому участнику. Уже приглашённому меняется роль, повторного приглашения нет.
+func (t *TransactionsImpl) InviteAttendees(ctx context.Context, event *entity.EventResponse, invites []entity.AttendeeInvite) ([]entity.Attendee, error) {
+	res := make([]entity.Attendee, 0, len(invites))
+	err := t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		res = res[:0]
+		for _, inv := range invites {
+			a := entity.Attendee{EventID: event.ID, UserID: inv.UserID, Role: inv.Role}
+			inserted, err := t.repo.UpsertAttendee(ctx, &a)
+			if err != nil {
+				t.logger.Errorf("[event: %s, user: %s] upsert attendee failed: %v", event.ID, inv.UserID, err)
+				return err
+			}
+			res = append(res, a)
+			if !inserted {
+				t.logger.Debugf("[event: %s, user: %s] attendee already invited", event.ID, inv.UserID)
+				continue
+			}
+
+			payload, err := json.Marshal(entity.InvitationSentPayload{
+				ID:           event.ID,
+				UserID:       a.UserID,
+				Organizer:    event.UserID,
+				Role:         a.Role,
+				Title:        event.Title,
+				DateEvent:    event.DateEvent,
+				EndDateEvent: event.EndDateEvent,
+				RRule:        event.RRule,
+				InvitedAt:    a.InvitedAt,
+			})
+			if err != nil {
+				return fmt.Errorf("marshal invitation: %w", err)
+			}
+			if err := t.insertOutbox(ctx, entity.AggregateEvent, event.ID, entity.InvitationSent, payload); err != nil {
+				t.logger.Errorf("[event: %s, user: %s] insert outbox failed: %v", event.ID, a.UserID, err)
+				return err
+			}
+		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RespondAttendee одной транзакцией сохраняет ответ участника и пишет rsvp_changed в outbox, если ответ
+// или комментарий изменились; appers.ErrAttendeeNotFound - пользователь не приглашён
+func (t *TransactionsImpl) RespondAttendee(ctx context.Context, event *entity.EventResponse, userID string, rsvp *entity.RSVPRequest) (*entity.Attendee, error) {
+	var res *entity.Attendee
+	err := t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		a, err := t.repo.GetAttendeeForUpdate(ctx, event.ID, userID)
+		if err != nil {
+			return err
+		}
+		res = a
+		if a.PartStat == rsvp.PartStat && a.Comment == rsvp.Comment {
+			t.logger.Debugf("[event: %s, user: %s] rsvp not changed", event.ID, userID)
+			return nil
+		}
+
+		previous := a.PartStat
+		a.PartStat, a.Comment = rsvp.PartStat, rsvp.Comment
+		if err := t.repo.UpdateAttendeeResponse(ctx, a); err != nil {
+			return err
+		}
 
+		payload, err := json.Marshal(entity.RSVPChangedPayload{
+			ID:               event.ID,
+			UserID:           userID,
+			Organizer:        event.UserID,
+			PartStat:         a.PartStat,
+			PreviousPartStat: previous,
+			Comment:          a.Comment,
+			Title:            event.Title,
+			DateEvent:        event.DateEvent,
+			EndDateEvent:     event.EndDateEvent,
+			RespondedAt:      *a.RespondedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("marshal rsvp: %w", err)
+		}
+		if err := t.insertOutbox(ctx, entity.AggregateEvent, event.ID, entity.RSVPChanged, payload); err != nil {
+			t.logger.Errorf("[event: %s, user: %s] insert outbox failed: %v", event.ID, userID, err)
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return res, nil
+}
 
-	return nil
+// insertOutbox сообщение outbox по агрегату; все сообщения транзакций пишутся через него
+func (t *TransactionsImpl) insertOutbox(ctx context.Context, aggregateType entity.OutboxAggregate, aggregateID uuid.UUID, eventType entity.OutboxEventType, payload []byte) error {
+	evt := entity.OutboxEvent{
+		AggregateID:   aggregateID,
+		AggregateType: aggregateType,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        entity.OutboxNew,
+	}
+	return t.repo.InsertOutbox(ctx, &evt)
+}
+
+// SaveCalendar создаёт (create) или обновляет календарь; основной календарь снимает признак с прежнего
+// основного календаря пользователя в той же транзакции
+func (t *TransactionsImpl) SaveCalendar(ctx context.Context, c *entity.Calendar, create bool) error {
+	return t.repo.db.WithinTransaction(ctx, func(ctx context.Context) error {
+		if c.IsDefault {
+			if err := t.repo.ClearDefaultCalendar(ctx, c.UserID, c.ID); err != nil {
+				return err
+			}
+		}
+		if create {
+			return t.repo.CreateCalendar(ctx, c)
+		}
+		return t.repo.UpdateCalendar(ctx, c)
+	})
 }
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"context"
+
+	"github.com/gofrs/uuid"
+)
+
+// ListAttendees участники события; appers.ErrEventNotFound - события нет
+func (s *ServiceImpl) ListAttendees(ctx context.Context, eventID uuid.UUID) ([]entity.Attendee, error) {
+	s.logger.Debugf("[event: %s] ListAttendees started", eventID)
+
+	if _, err := s.attendeeEvent(ctx, eventID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAttendees(ctx, eventID)
+}
+
+// InviteAttendees приглашает участников события; повтор пользователя в запросе - действует последний
+func (s *ServiceImpl) InviteAttendees(ctx context.Context, eventID uuid.UUID, req *entity.InviteRequest) ([]entity.Attendee, error) {
+	s.logger.Debugf("[event: %s] InviteAttendees started", eventID)
+
+	event, err := s.attendeeEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	invites := make([]entity.AttendeeInvite, 0, len(req.Attendees))
+	index := make(map[string]int, len(req.Attendees))
+	for _, inv := range req.Attendees {
+		if inv.UserID == event.UserID {
+			s.logger.Warnf("[event: %s, user: %s] organizer can't be invited", eventID, inv.UserID)
+			return nil, appers.ErrAttendeeIsOrganizer
+		}
+		if inv.Role == "" {
+			inv.Role = entity.AttendeeRequired
+		}
+		if i, ok := index[inv.UserID]; ok {
+			invites[i] = inv
+			continue
+		}
+		index[inv.UserID] = len(invites)
+		invites = append(invites, inv)
+	}
+
+	return s.transactions.InviteAttendees(ctx, event, invites)
+}
+
+// RespondAttendee ответ участника на приглашение; appers.ErrAttendeeNotFound - пользователь не приглашён
+func (s *ServiceImpl) RespondAttendee(ctx context.Context, eventID uuid.UUID, userID string, rsvp *entity.RSVPRequest) (*entity.Attendee, error) {
+	s.logger.Debugf("[event: %s, user: %s] RespondAttendee started", eventID, userID)
+
+	event, err := s.attendeeEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return s.transactions.RespondAttendee(ctx, event, userID, rsvp)
+}
+
+// attendeeEvent событие, которому задаются участники. Переопределение вхождения наследует участников серии,
+// своих участников у него нет.
+func (s *ServiceImpl) attendeeEvent(ctx context.Context, eventID uuid.UUID) (*entity.EventResponse, error) {
+	event, err := s.repo.GetEventByID(ctx, eventID.String())
+	if err != nil {
+		return nil, err
+	}
+	if event.SeriesID != nil {
+		s.logger.Warnf("[event: %s] attendees are set on the series %s", eventID, *event.SeriesID)
+		return nil, appers.ErrEventNotFound
+	}
+	return event, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"context"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+)
+
+func (s *ServiceImpl) CreateCalendar(ctx context.Context, in *entity.CalendarCreate) (*entity.Calendar, error) {
+	s.logger.Debugf("[user: %s] CreateCalendar started", in.UserID)
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("generate calendar id: %w", err)
+	}
+	c := &entity.Calendar{
+		ID:        id,
+		UserID:    in.UserID,
+		Name:      in.Name,
+		Color:     in.Color,
+		TimeZone:  in.TimeZone,
+		IsDefault: in.IsDefault,
+	}
+	if err := s.transactions.SaveCalendar(ctx, c, true); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *ServiceImpl) GetCalendar(ctx context.Context, id uuid.UUID) (*entity.Calendar, error) {
+	s.logger.Debugf("[calendar: %s] GetCalendar started", id)
+
+	return s.repo.GetCalendar(ctx, id)
+}
+
+func (s *ServiceImpl) ListCalendars(ctx context.Context, userID string) ([]entity.Calendar, error) {
+	s.logger.Debugf("[user: %s] ListCalendars started", userID)
+
+	return s.repo.ListCalendars(ctx, userID)
+}
+
+// UpdateCalendar применяет изменения к календарю; снять признак основного нельзя, только назначить другой
+func (s *ServiceImpl) UpdateCalendar(ctx context.Context, id uuid.UUID, patch *entity.CalendarPatch) (*entity.Calendar, error) {
+	s.logger.Debugf("[calendar: %s] UpdateCalendar started", id)
+
+	c, err := s.repo.GetCalendar(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if patch.Name != nil {
+		c.Name = *patch.Name
+	}
+	if patch.Color != nil {
+		c.Color = *patch.Color
+	}
+	if patch.TimeZone != nil {
+		c.TimeZone = *patch.TimeZone
+	}
+	if patch.IsDefault != nil && *patch.IsDefault {
+		c.IsDefault = true
+	}
+	if err := s.transactions.SaveCalendar(ctx, c, false); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// DeleteCalendar удаляет пустой неосновной календарь
+func (s *ServiceImpl) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	s.logger.Debugf("[calendar: %s] DeleteCalendar started", id)
+
+	c, err := s.repo.GetCalendar(ctx, id)
+	if err != nil {
+		return err
+	}
+	if c.IsDefault {
+		s.logger.Warnf("[calendar: %s] default calendar can't be deleted", id)
+		return appers.ErrDefaultCalendar
+	}
+	return s.repo.DeleteCalendar(ctx, id)
+}
+
+// resolveEventCalendar проверяет, что календарь события принадлежит его владельцу; без calendarID событие
+// попадает в основной календарь владельца. currentOwner - владелец до PATCH (пусто при создании).
+func (s *ServiceImpl) resolveEventCalendar(ctx context.Context, event *entity.Event, currentOwner string) error {
+	owner := event.UserID
+	if owner == "" {
+		owner = currentOwner
+	}
+
+	if event.CalendarID == nil {
+		id, err := s.repo.EnsureDefaultCalendar(ctx, owner)
+		if err != nil {
+			return err
+		}
+		event.CalendarID = &id
+		return nil
+	}
+
+	c, err := s.repo.GetCalendar(ctx, *event.CalendarID)
+	if err != nil {
+		return err
+	}
+	if c.UserID != owner {
+		s.logger.Warnf("[event: %s, calendar: %s] calendar belongs to %s, not %s", event.ID, c.ID, c.UserID, owner)
+		return appers.ErrCalendarOwner
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+)
+
+// SendAgendaDigests отправляет сводки событий дня пачками по batchSize, пока пачка полная
+func (s *ServiceImpl) SendAgendaDigests(ctx context.Context, batchSize int) (sent, empty int64, err error) {
+	s.logger.Debugf("[batch: %d] SendAgendaDigests started", batchSize)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return sent, empty, err
+		}
+
+		n, e, err := s.transactions.SendDueDigests(ctx, batchSize)
+		if err != nil {
+			return sent, empty, err
+		}
+		sent += int64(n)
+		empty += int64(e)
+		s.logger.Debugf("agenda digests batch: %d sent, %d empty", n, e)
+
+		if n+e < batchSize {
+			return sent, empty, nil
+		}
+	}
+}
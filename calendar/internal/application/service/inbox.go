@@ -0,0 +1,41 @@
+package service
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// HandleInboxMessage обрабатывает входящее сообщение ровно один раз: дедупликация по inbox_message, обработка
+// и запись в inbox в одной транзакции. handle - обработка (например, команда use case-а), nil - applyInboxMessage.
+// false - дубль, сообщение уже обработано.
+func (s *ServiceImpl) HandleInboxMessage(ctx context.Context, m *entity.InboxMessage, handle func(ctx context.Context) error) (bool, error) {
+	s.logger.Debugf("[message: %s] HandleInboxMessage started, topic: %s, partition: %d, offset: %d", m.ID, m.Topic, m.Partition, m.Offset)
+
+	if handle == nil {
+		handle = func(ctx context.Context) error {
+			return s.applyInboxMessage(ctx, m)
+		}
+	}
+	return s.transactions.HandleInbox(ctx, m, handle)
+}
+
+// PublishCommandResult отправляет ответ на команду в reply topic
+func (s *ServiceImpl) PublishCommandResult(ctx context.Context, res *entity.CommandResult) error {
+	s.logger.Debugf("[command %s] PublishCommandResult started, status: %s", res.CommandID, res.Status)
+
+	return s.kafkaProducer.ProduceReply(ctx, res)
+}
+
+// applyInboxMessage побочные эффекты сообщения; выполняется в транзакции inbox, поэтому все изменения
+// в БД должны идти через ctx (вложенные WithinTransaction становятся savepoint-ами)
+func (s *ServiceImpl) applyInboxMessage(ctx context.Context, m *entity.InboxMessage) error {
+	if !json.Valid(m.Payload) {
+		// повтор не поможет: сообщение уйдёт в DLQ консьюмера
+		return fmt.Errorf("[message: %s] payload is not JSON: %w", m.ID, appers.ErrMalformedMessage)
+	}
+	s.logger.Infof("[message: %s] consumed %s: %s", m.ID, m.EventType, m.Payload)
+	return nil
+}
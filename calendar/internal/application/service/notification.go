@@ -0,0 +1,288 @@
+package service
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/common"
+	"calendar/internal/application/entity"
+	"calendar/internal/application/notification"
+	"calendar/internal/transport/notify"
+	"calendar/pkg/config"
+	"calendar/pkg/metrics"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNotifierWorkers     = 2
+	defaultNotifierBatchSize   = 10
+	defaultNotifierPollPeriod  = 5 * time.Second
+	defaultNotifierLease       = 5 * time.Minute
+	defaultNotifierMaxAttempts = 10
+
+	// дальше NextBackoffWithJitter упирается в потолок 30m
+	maxNotificationBackoffAttempt = 11
+)
+
+// Notifier зависимости воркера уведомлений
+type Notifier struct {
+	templates *notification.Templates
+	senders   notify.Senders
+	cfg       config.Notification
+	m         *metrics.Metrics
+}
+
+// NewNotifier заполняет значения notification.* по умолчанию
+func NewNotifier(templates *notification.Templates, senders notify.Senders, cfg config.Notification, m *metrics.Metrics) *Notifier {
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultNotifierWorkers
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultNotifierBatchSize
+	}
+	if cfg.PollPeriod <= 0 {
+		cfg.PollPeriod = defaultNotifierPollPeriod
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = defaultNotifierLease
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = defaultNotifierMaxAttempts
+	}
+	return &Notifier{templates: templates, senders: senders, cfg: cfg, m: m}
+}
+
+func (s *ServiceImpl) GetNotificationSettings(ctx context.Context, userID string) (*entity.NotificationSettings, error) {
+	s.logger.Debugf("[user: %s] GetNotificationSettings started", userID)
+
+	return s.repo.GetNotificationSettings(ctx, userID)
+}
+
+func (s *ServiceImpl) UpdateNotificationSettings(ctx context.Context, settings *entity.NotificationSettings) error {
+	s.logger.Debugf("[user: %s] UpdateNotificationSettings started", settings.UserID)
+
+	if settings.DigestTime == "" {
+		settings.DigestTime = entity.DefaultDigestTime
+	}
+	if settings.DigestChannel == "" {
+		settings.DigestChannel = entity.DefaultDigestChannel
+	}
+	if settings.WebhookURL != "" {
+		if err := notify.CheckWebhookURL(ctx, settings.WebhookURL); err != nil {
+			s.logger.Warnf("[user: %s] webhookURL rejected: %v", settings.UserID, err)
+			return appers.ErrWebhookURLNotAllowed
+		}
+	}
+	return s.repo.UpsertNotificationSettings(ctx, settings)
+}
+
+// EnqueueNotifications ставит уведомления по сообщению Kafka в очередь доставки; выполняется в транзакции inbox.
+// Текст отрисовывается сразу: повтор доставки отправит то же самое, даже если событие уже изменилось.
+func (s *ServiceImpl) EnqueueNotifications(ctx context.Context, m *entity.InboxMessage) error {
+	switch entity.OutboxEventType(m.EventType) {
+	case entity.EventReminder:
+		var p entity.EventReminderPayload
+		if err := json.Unmarshal(m.Payload, &p); err != nil {
+			return fmt.Errorf("[message: %s] invalid %s payload: %v: %w", m.ID, m.EventType, err, appers.ErrMalformedMessage)
+		}
+		return s.enqueueNotification(ctx, m, p.UserID, p.Channel, p)
+	case entity.AgendaDigest:
+		var p entity.AgendaDigestPayload
+		if err := json.Unmarshal(m.Payload, &p); err != nil {
+			return fmt.Errorf("[message: %s] invalid %s payload: %v: %w", m.ID, m.EventType, err, appers.ErrMalformedMessage)
+		}
+		return s.enqueueNotification(ctx, m, p.UserID, p.Channel, p)
+	case entity.InvitationSent:
+		var p entity.InvitationSentPayload
+		if err := json.Unmarshal(m.Payload, &p); err != nil {
+			return fmt.Errorf("[message: %s] invalid %s payload: %v: %w", m.ID, m.EventType, err, appers.ErrMalformedMessage)
+		}
+		return s.enqueueNotification(ctx, m, p.UserID, entity.InvitationChannel, p)
+	case entity.RSVPChanged:
+		// об ответе узнаёт организатор
+		var p entity.RSVPChangedPayload
+		if err := json.Unmarshal(m.Payload, &p); err != nil {
+			return fmt.Errorf("[message: %s] invalid %s payload: %v: %w", m.ID, m.EventType, err, appers.ErrMalformedMessage)
+		}
+		return s.enqueueNotification(ctx, m, p.Organizer, entity.InvitationChannel, p)
+	default:
+		return fmt.Errorf("[message: %s, type: %s] %w", m.ID, m.EventType, appers.ErrNoMessageHandler)
+	}
+}
+
+// enqueueNotification уведомление одному пользователю по каналу; data - данные шаблона типа сообщения
+func (s *ServiceImpl) enqueueNotification(ctx context.Context, m *entity.InboxMessage, userID string, channel entity.ReminderChannel, data any) error {
+	settings, err := s.repo.GetNotificationSettings(ctx, userID)
+	switch {
+	case errors.Is(err, appers.ErrNotificationSettingsNotFound):
+		settings = &entity.NotificationSettings{UserID: userID}
+	case err != nil:
+		return err
+	}
+
+	eventType := entity.OutboxEventType(m.EventType)
+	content, err := s.notifier.templates.Render(eventType, settings.Locale, data)
+	if err != nil {
+		s.logger.Errorf("[message: %s] render notification failed: %v", m.ID, err)
+		return err
+	}
+
+	n := entity.Notification{
+		MessageID: m.ID,
+		EventType: eventType,
+		UserID:    userID,
+		Channel:   channel,
+		Content:   content,
+		Payload:   m.Payload,
+		Status:    entity.NotificationPending,
+	}
+	var reason string
+	if n.Recipient, reason = s.notificationRecipient(channel, settings); reason != "" {
+		n.Status = entity.NotificationSkipped
+		n.LastError = reason
+	}
+
+	inserted, err := s.repo.InsertNotification(ctx, &n)
+	if err != nil {
+		s.logger.Errorf("[message: %s] insert notification failed: %v", m.ID, err)
+		return err
+	}
+	switch {
+	case !inserted:
+		s.logger.Warnf("[message: %s, user: %s, channel: %s] notification already exists", m.ID, userID, channel)
+	case n.Status == entity.NotificationSkipped:
+		s.logger.Infof("[message: %s, user: %s, channel: %s] notification skipped: %s", m.ID, userID, channel, reason)
+		s.countNotification(channel, "skipped")
+	default:
+		s.logger.Infof("[message: %s, user: %s, channel: %s] notification queued", m.ID, userID, channel)
+	}
+	return nil
+}
+
+// notificationRecipient адрес получателя для канала; непустая причина - доставлять некуда
+func (s *ServiceImpl) notificationRecipient(channel entity.ReminderChannel, settings *entity.NotificationSettings) (string, string) {
+	if _, ok := s.notifier.senders[channel]; !ok {
+		return "", fmt.Sprintf("канал %s не настроен", channel)
+	}
+	switch channel {
+	case entity.ReminderEmail:
+		if settings.Email == "" {
+			return "", "у пользователя не задан email"
+		}
+		return settings.Email, ""
+	case entity.ReminderWebhook:
+		if settings.WebhookURL != "" {
+			return settings.WebhookURL, ""
+		}
+		if s.notifier.cfg.Webhook.URL == "" {
+			return "", "у пользователя не задан webhookURL"
+		}
+		return s.notifier.cfg.Webhook.URL, ""
+	default:
+		return "", fmt.Sprintf("канал %s не поддерживается", channel)
+	}
+}
+
+// RunNotifier доставляет уведомления из таблицы notification, пока не отменён ctx: батч резервируется на
+// notification.lease и отправляется notification.workers воркерами; полный батч - сразу следующий
+func (s *ServiceImpl) RunNotifier(ctx context.Context) {
+	cfg := s.notifier.cfg
+	s.logger.Infow("notifier started", "workers", cfg.Workers, "batch", cfg.BatchSize, "lease", cfg.Lease.String(),
+		"maxAttempts", cfg.MaxAttempts)
+
+	ticker := time.NewTicker(cfg.PollPeriod)
+	defer ticker.Stop()
+
+	for {
+		if s.deliverNotificationBatch(ctx) < cfg.BatchSize {
+			select {
+			case <-ctx.Done():
+				s.logger.Infow("notifier stopping")
+				return
+			case <-ticker.C:
+			}
+		} else if ctx.Err() != nil {
+			s.logger.Infow("notifier stopping")
+			return
+		}
+	}
+}
+
+// deliverNotificationBatch резервирует и отправляет батч, возвращает его размер
+func (s *ServiceImpl) deliverNotificationBatch(ctx context.Context) int {
+	cfg := s.notifier.cfg
+	batch, err := s.repo.ReserveNotifications(ctx, cfg.Lease, cfg.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.logger.Errorw("reserve notifications failed", "err", err)
+		}
+		return 0
+	}
+
+	jobs := make(chan entity.Notification)
+	var wg sync.WaitGroup
+	for i := 0; i < min(cfg.Workers, len(batch)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range jobs {
+				s.deliverNotification(ctx, n)
+			}
+		}()
+	}
+	for _, n := range batch {
+		jobs <- n
+	}
+	close(jobs)
+	wg.Wait()
+
+	return len(batch)
+}
+
+// deliverNotification отправляет уведомление и сохраняет результат: SENT, повтор с backoff или FAILED
+func (s *ServiceImpl) deliverNotification(ctx context.Context, n entity.Notification) {
+	sender, ok := s.notifier.senders[n.Channel]
+	var err error
+	if ok {
+		err = sender.Send(ctx, &n)
+	} else {
+		err = fmt.Errorf("канал %s не настроен", n.Channel)
+	}
+
+	if err == nil {
+		if err = s.repo.MarkNotificationSent(context.Background(), n.ID); err != nil {
+			// уведомление уже ушло: после аренды оно будет отправлено ещё раз
+			s.logger.Errorf("[notification %d] mark sent failed: %v", n.ID, err)
+		}
+		s.logger.Infof("[notification %d] sent to %s, user: %s", n.ID, n.Channel, n.UserID)
+		s.countNotification(n.Channel, "sent")
+		return
+	}
+	if ctx.Err() != nil {
+		// остановка: попытку не засчитываем, строка вернётся после аренды
+		return
+	}
+
+	status := entity.NotificationPending
+	result := "retry"
+	if !ok || n.Attempts+1 >= s.notifier.cfg.MaxAttempts || notify.IsPermanent(err) {
+		status = entity.NotificationFailed
+		result = "failed"
+	}
+	backoff := common.NextBackoffWithJitter(min(n.Attempts+1, maxNotificationBackoffAttempt))
+	s.logger.Errorf("[notification %d] send to %s failed, attempt: %d, status: %s, err: %v", n.ID, n.Channel, n.Attempts+1, status, err)
+
+	if err := s.repo.MarkNotificationFailed(context.Background(), n.ID, status, time.Now().UTC().Add(backoff), truncateError(err.Error())); err != nil {
+		s.logger.Errorf("[notification %d] mark failed: %v", n.ID, err)
+	}
+	s.countNotification(n.Channel, result)
+}
+
+func (s *ServiceImpl) countNotification(channel entity.ReminderChannel, result string) {
+	if s.notifier.m != nil {
+		s.notifier.m.Notification.DeliveriesTotal.WithLabelValues(string(channel), result).Inc()
+	}
+}
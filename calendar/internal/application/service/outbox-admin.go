@@ -0,0 +1,127 @@
+package service
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	defaultOutboxPageSize = 50
+	maxOutboxPageSize     = 500
+)
+
+func (s *ServiceImpl) ListOutbox(ctx context.Context, f *entity.OutboxFilter) (*entity.OutboxPage, error) {
+	s.logger.Debugf("[filter: %+v] ListOutbox started", *f)
+
+	switch {
+	case f.Limit <= 0:
+		f.Limit = defaultOutboxPageSize
+	case f.Limit > maxOutboxPageSize:
+		f.Limit = maxOutboxPageSize
+	}
+	return s.repo.ListOutbox(ctx, f)
+}
+
+func (s *ServiceImpl) GetOutbox(ctx context.Context, id int) (*entity.OutboxEvent, error) {
+	s.logger.Debugf("[ID %d] GetOutbox started", id)
+
+	return s.repo.GetOutboxByID(ctx, id)
+}
+
+// RequeueOutbox возвращает FAILED/GAVE_UP сообщение в очередь relay: attempts = 0, next_attempt_at = now()
+func (s *ServiceImpl) RequeueOutbox(ctx context.Context, id int) error {
+	s.logger.Debugf("[ID %d] RequeueOutbox started", id)
+
+	ok, err := s.repo.RequeueOutbox(ctx, id)
+	if err != nil {
+		return err
+	}
+	if ok {
+		s.logger.Infof("[ID %d] outbox requeued", id)
+		return nil
+	}
+
+	// не обновилось: либо сообщения нет, либо оно не в FAILED/GAVE_UP
+	if _, err = s.repo.GetOutboxByID(ctx, id); err != nil {
+		return err
+	}
+	return appers.ErrOutboxNotRequeueable
+}
+
+// RequeueOutboxByFilter массовый requeue; в фильтре допустимы только статусы FAILED и GAVE_UP
+func (s *ServiceImpl) RequeueOutboxByFilter(ctx context.Context, f *entity.OutboxFilter) (int64, error) {
+	s.logger.Debugf("[filter: %+v] RequeueOutboxByFilter started", *f)
+
+	for _, st := range f.Statuses {
+		if st != entity.OutboxFailed && st != entity.OutboxGaveUp {
+			return 0, appers.ErrOutboxNotRequeueable
+		}
+	}
+	return s.repo.RequeueOutboxByFilter(ctx, f)
+}
+
+// PurgeSentOutbox удаляет отправленные сообщения старше olderThan (0 - все отправленные)
+func (s *ServiceImpl) PurgeSentOutbox(ctx context.Context, olderThan time.Duration) (int64, error) {
+	s.logger.Debugf("[olderThan: %s] PurgeSentOutbox started", olderThan)
+
+	if olderThan < 0 {
+		return 0, errors.Join(appers.ErrOutboxInvalidFilter, errors.New("olderThan must not be negative"))
+	}
+	return s.repo.PurgeSentOutbox(ctx, olderThan)
+}
+
+// CleanupSentOutbox переносит в архив (archive) или удаляет SENT сообщения старше retention пачками по batchSize,
+// пока не закончатся подходящие строки. Каждая пачка - отдельная короткая транзакция.
+func (s *ServiceImpl) CleanupSentOutbox(ctx context.Context, retention time.Duration, batchSize int, archive bool) (int64, error) {
+	s.logger.Debugf("[retention: %s, batch: %d, archive: %t] CleanupSentOutbox started", retention, batchSize, archive)
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		var n int64
+		var err error
+		if archive {
+			n, err = s.repo.ArchiveSentOutboxBatch(ctx, retention, batchSize)
+		} else {
+			n, err = s.repo.DeleteSentOutboxBatch(ctx, retention, batchSize)
+		}
+		if err != nil {
+			return total, err
+		}
+		total += n
+		s.logger.Debugf("outbox cleanup batch: %d rows", n)
+
+		if n < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
+
+// CleanupInbox удаляет записи inbox старше retention пачками по batchSize, пока не закончатся подходящие строки
+func (s *ServiceImpl) CleanupInbox(ctx context.Context, retention time.Duration, batchSize int) (int64, error) {
+	s.logger.Debugf("[retention: %s, batch: %d] CleanupInbox started", retention, batchSize)
+
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+
+		n, err := s.repo.DeleteInboxBatch(ctx, retention, batchSize)
+		if err != nil {
+			return total, err
+		}
+		total += n
+		s.logger.Debugf("inbox cleanup batch: %d rows", n)
+
+		if n < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
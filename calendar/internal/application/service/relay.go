@@ -3,18 +3,76 @@ package service
 import (
 	"calendar/internal/application/common"
 	"calendar/internal/application/entity"
+	"calendar/internal/transport/producer"
+	"calendar/internal/transport/sink"
 	"context"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	deadLetterTopic = "topic"
+	deadLetterTable = "table"
+
+	// errorClassMarkSent сообщение отправлено, но не удалось отметить его SENT
+	errorClassMarkSent = producer.ErrorClassMarkSent
+
+	maxLastErrorLength = 2000
 )
 
+// inflightAggregates агрегаты, сообщения которых сейчас у воркеров этого relay.
+// Порядок между экземплярами обеспечивает reserveBatchSQL, а внутри процесса строка
+// с истёкшей арендой не должна уйти к другому воркеру, пока предыдущая ещё отправляется.
+type inflightAggregates struct {
+	mu  sync.Mutex
+	ids map[uuid.UUID]struct{}
+}
+
+func (a *inflightAggregates) acquire(id uuid.UUID) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.ids[id]; ok {
+		return false
+	}
+	a.ids[id] = struct{}{}
+	return true
+}
+
+func (a *inflightAggregates) release(id uuid.UUID) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.ids, id)
+}
+
 func (s *ServiceImpl) RelayEventRun(ctx context.Context) {
-	s.logger.Infow("relay started", "workers", s.cfg.Workers, "batch", s.cfg.BatchSize, "lease", s.cfg.Lease.String())
+	s.logger.Infow("relay started", "workers", s.cfg.Workers, "batch", s.cfg.BatchSize, "lease", s.cfg.Lease.String(),
+		"deadLetter", s.cfg.DeadLetter, "sinks", s.sinks.Names())
+	if s.cfg.DeadLetter == deadLetterTopic && s.cfg.DeadLetterTopic == "" {
+		s.logger.Warn("relay.deadLetter=topic, but relay.deadLetterTopic is empty: dead letters are disabled")
+		s.cfg.DeadLetter = ""
+	}
 
-	jobs := make(chan entity.OutboxEvent, s.cfg.BatchSize*2)
+	// воркер получает батч целиком и отправляет его одним SendMessages
+	jobs := make(chan []entity.OutboxEvent, s.cfg.Workers)
+	inflight := &inflightAggregates{ids: make(map[uuid.UUID]struct{})}
+
+	// wake будит цикл relay: уведомление LISTEN или воркер, разобравший очередь
+	// (следующее сообщение агрегата могло ждать отправки предыдущего). Без notify - nil, только ticker.
+	var wake chan struct{}
+	listenErr := make(chan error, 1)
+	listening := false
+	if s.cfg.Notify {
+		wake = make(chan struct{}, 1)
+		listening = true
+		go s.listenOutbox(ctx, wake, listenErr)
+	}
 
 	// стартуем воркеров
 	for i := 0; i < s.cfg.Workers; i++ {
-		go s.worker(ctx, i, jobs)
+		go s.worker(ctx, i, jobs, inflight, wake)
 	}
 
 	ticker := time.NewTicker(s.cfg.PollPeriod)
@@ -25,67 +83,208 @@ func (s *ServiceImpl) RelayEventRun(ctx context.Context) {
 		case <-ctx.Done():
 			s.logger.Infow("relay stopping")
 			return
+		case err := <-listenErr:
+			// до переподключения на следующем тике работаем только по ticker
+			listening = false
+			s.logger.Warnw("relay listener stopped, falling back to polling", "err", err)
+		case <-wake:
+			if !s.dispatchBatch(ctx, jobs, inflight, wake) {
+				return
+			}
 		case <-ticker.C:
-			events, err := s.transactions.GetOperationsFromOutbox(ctx, *s.cfg)
-			if err != nil {
-				s.logger.Errorw("get operations from outbox failed", "err", err)
-				continue
+			if s.cfg.Notify && !listening {
+				listening = true
+				go s.listenOutbox(ctx, wake, listenErr)
 			}
-
-			s.logger.Debugf("len jobs: %d, len events: %d", len(jobs), len(events))
-			for _, e := range events {
-				select {
-				case jobs <- e:
-				case <-ctx.Done():
-					return
-				}
+			if !s.dispatchBatch(ctx, jobs, inflight, wake) {
+				return
 			}
 		}
 	}
 }
 
-func (s *ServiceImpl) worker(ctx context.Context, id int, jobs <-chan entity.OutboxEvent) {
+// listenOutbox держит LISTEN до ошибки соединения; ошибка уходит в errCh, переподключение - по ticker
+func (s *ServiceImpl) listenOutbox(ctx context.Context, wake chan<- struct{}, errCh chan<- error) {
+	s.logger.Infow("relay listener started")
+	err := s.repo.ListenOutbox(ctx, wake)
+	if ctx.Err() != nil {
+		return
+	}
+	errCh <- err
+}
+
+// dispatchBatch резервирует батч и передаёт его воркеру; false - контекст отменён
+func (s *ServiceImpl) dispatchBatch(ctx context.Context, jobs chan<- []entity.OutboxEvent, inflight *inflightAggregates, wake chan struct{}) bool {
+	events, err := s.transactions.GetOperationsFromOutbox(ctx, *s.cfg)
+	if err != nil {
+		s.logger.Errorw("get operations from outbox failed", "err", err)
+		return true
+	}
+
+	s.logger.Debugf("len jobs: %d, len events: %d", len(jobs), len(events))
+	batch := make([]entity.OutboxEvent, 0, len(events))
+	var held []int
+	for _, e := range events {
+		if !inflight.acquire(e.AggregateID) {
+			// предыдущее сообщение агрегата ещё у воркера: строку не держим до истечения аренды,
+			// воркер разбудит цикл, когда закончит
+			s.logger.Debugf("[ID %d] aggregate %s is in flight, held back", e.ID, e.AggregateID)
+			held = append(held, e.ID)
+			continue
+		}
+		batch = append(batch, e)
+	}
+	if len(held) > 0 {
+		if err := s.repo.ReleaseOutbox(ctx, held); err != nil {
+			s.logger.Warnf("[IDs %v] release held back outbox rows failed, they wait for lease: %v", held, err)
+		}
+	}
+	if len(batch) > 0 {
+		select {
+		case jobs <- batch:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// полный батч - в outbox, скорее всего, есть ещё сообщения: не ждём следующего уведомления.
+	// Батч только из удержанных строк снова выбрал бы их же - ждём воркера
+	if len(events) == s.cfg.BatchSize && len(batch) > 0 {
+		signal(wake)
+	}
+	return true
+}
+
+// signal неблокирующе будит цикл relay
+func signal(wake chan<- struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *ServiceImpl) worker(ctx context.Context, id int, jobs <-chan []entity.OutboxEvent, inflight *inflightAggregates, wake chan<- struct{}) {
 	s.logger.Infow("worker started", "id", id)
 	for {
 		select {
 		case <-ctx.Done():
 			s.logger.Infow("worker stopping", "id", id)
 			return
-		case e := <-jobs:
-			s.ProcessOne(ctx, id, e)
+		case batch := <-jobs:
+			s.ProcessBatch(ctx, id, batch)
+			for _, e := range batch {
+				inflight.release(e.AggregateID)
+			}
+			if len(jobs) == 0 {
+				signal(wake)
+			}
 		}
 	}
 }
 
-// ProcessOne обрабатывает одно событие из outbox (экспортируем для тестирования)
-func (s *ServiceImpl) ProcessOne(ctx context.Context, wid int, e entity.OutboxEvent) {
-	s.logger.Debugf("[ID %d] relay-process started, workerID: %d", e.ID, wid)
+// ProcessBatch отправляет зарезервированный батч во все sink-и, подходящие по типу сообщения, и отмечает SENT
+// доставленные везде сообщения одним UPDATE. Неотправленные получают собственный backoff, а их успешные доставки
+// запоминаются в outbox_delivery: повтор уйдёт только в оставшиеся sink-и (экспортируем для тестирования)
+func (s *ServiceImpl) ProcessBatch(ctx context.Context, wid int, events []entity.OutboxEvent) {
+	s.logger.Debugf("relay-process started, workerID: %d, batch: %d", wid, len(events))
 
-	// Отправка сообщения в Kafka
-	if err := s.kafkaProducer.ProduceMessage(ctx, e.ID, e.Payload); err != nil {
-		s.logger.Errorf("[ID %d] kafka send failed, err: %v", e.ID, err)
-		_ = s.markOutboxFailedOrGaveUp(context.Background(), e.ID, e.Attempts, s.cfg.MaxAttempts, common.NextBackoffWithJitter(e.Attempts))
+	// доставки бывают только у сообщений, которые уже пытались отправить
+	retried := make([]int, 0, len(events))
+	for _, e := range events {
+		if e.Attempts > 0 || e.ErrorClass != "" {
+			retried = append(retried, e.ID)
+		}
+	}
+	var delivered sink.Delivered
+	if len(retried) > 0 {
+		var err error
+		if delivered, err = s.repo.GetOutboxDeliveries(ctx, retried); err != nil {
+			// без списка доставок повтор продублирует сообщения: ждём истечения аренды
+			s.logger.Errorf("[IDs %v] get outbox deliveries failed, err: %v", retried, err)
+			return
+		}
+	}
 
-		return
+	// Отправка батча в sink-и
+	succeeded, failed := s.sinks.Deliver(ctx, events, delivered)
+
+	sent := make([]int, 0, len(events))
+	for _, e := range events {
+		if _, ok := failed[e.ID]; !ok {
+			sent = append(sent, e.ID)
+		}
+	}
+
+	// частичные доставки: сообщение не ушло в другой sink
+	partial := make(map[string][]int)
+	for name, ids := range succeeded {
+		for _, id := range ids {
+			if _, ok := failed[id]; ok {
+				partial[name] = append(partial[name], id)
+			}
+		}
 	}
-	s.logger.Infof("[ID %d] sent to kafka", e.ID)
 
 	//обновление в БД
-	if err := s.transactions.MarkSentAndUpdateEvent(ctx, e.ID); err != nil {
-		// сообщение уже ушло — повторно слать нельзя; статус апдейтим при следующем цикле
-		s.logger.Errorf("[ID %d] mark sent & update Event failed, err:  %v", e.ID, err)
-		_ = s.repo.MarkGaveUp(ctx, e.ID)
+	if len(sent) > 0 || len(partial) > 0 {
+		if err := s.transactions.MarkDelivered(ctx, partial, sent); err != nil {
+			// сообщения уже ушли — повторно слать нельзя
+			s.logger.Errorf("[IDs %v] mark sent failed, err:  %v", sent, err)
+			for _, id := range sent {
+				_ = s.repo.MarkGaveUp(ctx, id, truncateError(err.Error()), errorClassMarkSent)
+			}
+		} else if len(sent) > 0 {
+			s.logger.Infof("[IDs %v] sent", sent)
+		}
+	}
 
-		return
+	for _, e := range events {
+		if err, ok := failed[e.ID]; ok {
+			s.logger.Errorf("[ID %d] send failed, err: %v", e.ID, err)
+			_ = s.markOutboxFailedOrGaveUp(context.Background(), e, err, common.NextBackoffWithJitter(e.Attempts))
+		}
+	}
+
+	s.logger.Infof("relay-process completed, workerID: %d, sent: %d, failed: %d", wid, len(sent), len(failed))
+}
+
+func (s *ServiceImpl) markOutboxFailedOrGaveUp(ctx context.Context, e entity.OutboxEvent, sendErr error, backoff time.Duration) error {
+	e.LastError = truncateError(sendErr.Error())
+	e.ErrorClass = sink.ClassifyError(sendErr)
+
+	// постоянные ошибки (размер, авторизация, 4xx webhook, ...) не повторяем
+	if e.Attempts+1 >= s.cfg.MaxAttempts || sink.IsPermanent(sendErr) {
+		return s.giveUp(ctx, e)
 	}
-	s.logger.Infof("[ID %d] updated Event", e.ID)
+	return s.repo.MarkFailedWithBackoff(ctx, e.ID, time.Now().UTC().Add(backoff), e.LastError, e.ErrorClass)
+}
 
-	s.logger.Infof("[ID %d] relay-process completed", e.ID)
+// giveUp переводит сообщение в GAVE_UP и, если настроено, отправляет его в dead letter.
+// Следующие сообщения агрегата после этого отправляются без него (см. reserveBatchSQL)
+func (s *ServiceImpl) giveUp(ctx context.Context, e entity.OutboxEvent) error {
+	s.logger.Errorf("[ID %d] gave up after %d attempts, class: %s, err: %s; later messages of aggregate %s go on without it",
+		e.ID, e.Attempts+1, e.ErrorClass, e.LastError, e.AggregateID)
+
+	switch s.cfg.DeadLetter {
+	case deadLetterTable:
+		if err := s.transactions.MarkGaveUpToDeadLetter(ctx, &e); err != nil {
+			s.logger.Errorf("[ID %d] move to dead letter table failed, err: %v", e.ID, err)
+			return err
+		}
+		return nil
+	case deadLetterTopic:
+		// строка в GAVE_UP с last_error остаётся и при ошибке отправки в DLQ
+		if err := s.kafkaProducer.ProduceDeadLetter(ctx, s.cfg.DeadLetterTopic, e); err != nil {
+			s.logger.Errorf("[ID %d] send to dead letter topic failed, err: %v", e.ID, err)
+		}
+	}
+	return s.repo.MarkGaveUp(ctx, e.ID, e.LastError, e.ErrorClass)
 }
 
-func (s *ServiceImpl) markOutboxFailedOrGaveUp(ctx context.Context, outboxID int, attempts, maxAttempts int, backoff time.Duration) error {
-	if attempts+1 >= maxAttempts {
-		return s.repo.MarkGaveUp(ctx, outboxID)
+// truncateError ограничивает длину текста ошибки для last_error
+func truncateError(msg string) string {
+	if len(msg) <= maxLastErrorLength {
+		return msg
 	}
-	return s.repo.MarkFailedWithBackoff(ctx, outboxID, time.Now().UTC().Add(backoff))
+	return strings.ToValidUTF8(msg[:maxLastErrorLength], "")
 }
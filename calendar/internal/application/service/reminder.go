@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// FireReminders отправляет наступившие напоминания пачками по batchSize, пока пачка полная
+func (s *ServiceImpl) FireReminders(ctx context.Context, batchSize int, maxLateness time.Duration) (fired, missed int64, err error) {
+	s.logger.Debugf("[batch: %d, maxLateness: %s] FireReminders started", batchSize, maxLateness)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return fired, missed, err
+		}
+
+		f, m, err := s.transactions.FireDueReminders(ctx, batchSize, maxLateness)
+		if err != nil {
+			return fired, missed, err
+		}
+		fired += int64(f)
+		missed += int64(m)
+		s.logger.Debugf("reminders batch: %d fired, %d missed", f, m)
+
+		if f+m < batchSize {
+			return fired, missed, nil
+		}
+	}
+}
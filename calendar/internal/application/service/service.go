@@ -1,26 +1,59 @@
 package service
 
 import (
+	"calendar/internal/appers"
 	"calendar/internal/application/entity"
+	"calendar/internal/application/recurrence"
 	"calendar/internal/application/repo"
 	"calendar/internal/transport/producer"
+	"calendar/internal/transport/sink"
 	"calendar/pkg/config"
 	"context"
 	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/gofrs/uuid"
 	"go.uber.org/zap"
 )
 
 type Service interface {
-	CreateEvent(ctx context.Context, event *entity.Event) error
-	GetEventsByPeriod(ctx context.Context, start, end time.Time) ([]*entity.EventResponse, error)
+	CreateEvent(ctx context.Context, event *entity.Event, occurrences []*entity.EventOccurrence) error
+	GetEventsByPeriod(ctx context.Context, f *entity.EventFilter, start, end time.Time) ([]*entity.EventResponse, error)
 	UpdateEvent(ctx context.Context, event *entity.Event) error
 	DeleteEvent(ctx context.Context, id string) error
+	UpsertOccurrence(ctx context.Context, occurrence *entity.EventOccurrence) (bool, error)
+	ReplaceEvent(ctx context.Context, event *entity.Event, occurrences []*entity.EventOccurrence) error
+	GetEventResource(ctx context.Context, id string) ([]*entity.EventResponse, error)
+	GetUserEvents(ctx context.Context, userID string) ([]*entity.EventResponse, error)
+	GetUserCollectionState(ctx context.Context, userID string) (entity.CollectionState, error)
 	DeleteOldEventsByYear(ctx context.Context, days *int)
 	RelayEventRun(ctx context.Context)
 
+	ListOutbox(ctx context.Context, f *entity.OutboxFilter) (*entity.OutboxPage, error)
+	GetOutbox(ctx context.Context, id int) (*entity.OutboxEvent, error)
+	RequeueOutbox(ctx context.Context, id int) error
+	RequeueOutboxByFilter(ctx context.Context, f *entity.OutboxFilter) (int64, error)
+	PurgeSentOutbox(ctx context.Context, olderThan time.Duration) (int64, error)
+	CleanupSentOutbox(ctx context.Context, retention time.Duration, batchSize int, archive bool) (int64, error)
+	CleanupInbox(ctx context.Context, retention time.Duration, batchSize int) (int64, error)
+	HandleInboxMessage(ctx context.Context, m *entity.InboxMessage, handle func(ctx context.Context) error) (bool, error)
+	PublishCommandResult(ctx context.Context, res *entity.CommandResult) error
+	FireReminders(ctx context.Context, batchSize int, maxLateness time.Duration) (fired, missed int64, err error)
+	SendAgendaDigests(ctx context.Context, batchSize int) (sent, empty int64, err error)
+	GetNotificationSettings(ctx context.Context, userID string) (*entity.NotificationSettings, error)
+	UpdateNotificationSettings(ctx context.Context, settings *entity.NotificationSettings) error
+	EnqueueNotifications(ctx context.Context, m *entity.InboxMessage) error
+	RunNotifier(ctx context.Context)
+	ListAttendees(ctx context.Context, eventID uuid.UUID) ([]entity.Attendee, error)
+	InviteAttendees(ctx context.Context, eventID uuid.UUID, req *entity.InviteRequest) ([]entity.Attendee, error)
+	RespondAttendee(ctx context.Context, eventID uuid.UUID, userID string, rsvp *entity.RSVPRequest) (*entity.Attendee, error)
+	CreateCalendar(ctx context.Context, in *entity.CalendarCreate) (*entity.Calendar, error)
+	GetCalendar(ctx context.Context, id uuid.UUID) (*entity.Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]entity.Calendar, error)
+	UpdateCalendar(ctx context.Context, id uuid.UUID, patch *entity.CalendarPatch) (*entity.Calendar, error)
+	DeleteCalendar(ctx context.Context, id uuid.UUID) error
+
 	HealthCheck(ctx context.Context) (dbHealthy bool, kafkaHealthy bool, err error)
 }
 
@@ -28,15 +61,19 @@ type ServiceImpl struct {
 	repo          repo.Repo
 	transactions  repo.Transactions
 	kafkaProducer producer.Producer
+	sinks         *sink.Router
+	notifier      *Notifier
 	logger        *zap.SugaredLogger
 	cfg           *config.RelayConfig
 }
 
-func NewService(repo repo.Repo, transactions repo.Transactions, kafkaProducer producer.Producer, logger *zap.SugaredLogger, cfg *config.RelayConfig) *ServiceImpl {
+func NewService(repo repo.Repo, transactions repo.Transactions, kafkaProducer producer.Producer, sinks *sink.Router, notifier *Notifier, logger *zap.SugaredLogger, cfg *config.RelayConfig) *ServiceImpl {
 	return &ServiceImpl{
 		repo:          repo,
 		transactions:  transactions,
 		kafkaProducer: kafkaProducer,
+		sinks:         sinks,
+		notifier:      notifier,
 		logger:        logger,
 		cfg:           cfg,
 	}
@@ -60,8 +97,12 @@ func (s *ServiceImpl) HealthCheck(ctx context.Context) (dbHealthy bool, kafkaHea
 	return dbHealthy, kafkaHealthy, nil
 }
 
-func (s *ServiceImpl) CreateEvent(ctx context.Context, event *entity.Event) error {
-	s.logger.Debugf("[event: %s] CreateEvent started", event.ID)
+func (s *ServiceImpl) CreateEvent(ctx context.Context, event *entity.Event, occurrences []*entity.EventOccurrence) error {
+	s.logger.Debugf("[event: %s] CreateEvent started, occurrences: %d", event.ID, len(occurrences))
+
+	if err := s.resolveEventCalendar(ctx, event, ""); err != nil {
+		return err
+	}
 
 	payload, err := json.Marshal(event)
 	if err != nil {
@@ -69,25 +110,149 @@ func (s *ServiceImpl) CreateEvent(ctx context.Context, event *entity.Event) erro
 		return fmt.Errorf("failed to marshal event: %w", err)
 	}
 
-	return s.transactions.CreateEvent(ctx, event, payload)
+	return s.transactions.CreateEvent(ctx, event, occurrences, payload)
 }
 
-func (s *ServiceImpl) GetEventsByPeriod(ctx context.Context, start, end time.Time) ([]*entity.EventResponse, error) {
-	s.logger.Debugf("[start: %s, end: %s] GetPaymentsByPeriod started", start, end)
+// GetEventsByPeriod события периода по фильтру: с UserID - только события пользователя и те, где он участник,
+// с CalendarIDs - только события этих календарей
+func (s *ServiceImpl) GetEventsByPeriod(ctx context.Context, f *entity.EventFilter, start, end time.Time) ([]*entity.EventResponse, error) {
+	s.logger.Debugf("[start: %s, end: %s, filter: %+v] GetPaymentsByPeriod started", start, end, *f)
 
-	return s.repo.GetEvents(ctx, start, end)
+	return s.repo.GetEventsByFilter(ctx, start, end, f)
 }
 
 func (s *ServiceImpl) UpdateEvent(ctx context.Context, event *entity.Event) error {
 	s.logger.Debugf("[event: %s] UpdateEventstatus started", event.ID)
 
-	return s.repo.UpdateEvent(ctx, event)
+	current, err := s.repo.GetEventByID(ctx, event.ID.String())
+	if err != nil {
+		return err
+	}
+	// правило повторения задаётся у серии: строка переопределения его не содержит
+	if current.SeriesID != nil && (event.RRule != nil || event.RDate != nil || event.ExDate != nil || event.TimeZone != "") {
+		s.logger.Warnf("[event: %s, series: %s] series fields in override update", event.ID, *current.SeriesID)
+		return appers.ErrOverrideSeriesFields
+	}
+	// календарь и владелец общие для всей серии: у одного вхождения они не меняются
+	if current.SeriesID != nil && (event.CalendarID != nil || (event.UserID != "" && event.UserID != current.UserID)) {
+		s.logger.Warnf("[event: %s, series: %s] calendar or owner change in override update", event.ID, *current.SeriesID)
+		return appers.ErrOverrideCalendar
+	}
+
+	// календарь проверяется, только если он или владелец меняются
+	if event.CalendarID != nil || (event.UserID != "" && event.UserID != current.UserID) {
+		if err := s.resolveEventCalendar(ctx, event, current.UserID); err != nil {
+			return err
+		}
+	}
+
+	changes := event.Changes()
+	if len(changes) == 0 {
+		s.logger.Warnf("[event: %s] no fields to update", event.ID)
+		return nil
+	}
+
+	payload, err := json.Marshal(entity.EventUpdatedPayload{
+		ID:        event.ID,
+		Changes:   changes,
+		UpdatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		s.logger.Errorf("[event: %s] failed to marshal event to JSON: %v", event.ID, err)
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return s.transactions.UpdateEvent(ctx, event, payload)
 }
 
 func (s *ServiceImpl) DeleteEvent(ctx context.Context, id string) error {
 	s.logger.Debugf("[event: %s] DeleteEvent started", id)
 
-	return s.repo.DeleteEvent(ctx, id)
+	return s.transactions.DeleteEvent(ctx, id)
+}
+
+// UpsertOccurrence сохраняет переопределение (или отмену) одного вхождения серии;
+// false - такое переопределение уже сохранено
+func (s *ServiceImpl) UpsertOccurrence(ctx context.Context, occurrence *entity.EventOccurrence) (bool, error) {
+	s.logger.Debugf("[event: %s, recurrenceID: %s] UpsertOccurrence started", occurrence.SeriesID, occurrence.RecurrenceID)
+
+	series, err := s.repo.GetEventByID(ctx, occurrence.SeriesID.String())
+	if err != nil {
+		return false, err
+	}
+	if !series.IsSeries() {
+		s.logger.Warnf("[event: %s] event is not a series", occurrence.SeriesID)
+		return false, appers.ErrOccurrenceNotFound
+	}
+
+	recurrenceID, err := time.Parse(time.RFC3339, occurrence.RecurrenceID)
+	if err != nil {
+		return false, fmt.Errorf("invalid recurrenceID: %w", err)
+	}
+	ok, err := recurrence.IsOccurrence(series, recurrenceID.UTC())
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		s.logger.Warnf("[event: %s, recurrenceID: %s] no such occurrence", occurrence.SeriesID, occurrence.RecurrenceID)
+		return false, appers.ErrOccurrenceNotFound
+	}
+
+	payload, err := json.Marshal(entity.EventUpdatedPayload{
+		ID:         occurrence.SeriesID,
+		Occurrence: occurrence,
+		UpdatedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		s.logger.Errorf("[event: %s] failed to marshal occurrence to JSON: %v", occurrence.SeriesID, err)
+		return false, fmt.Errorf("failed to marshal occurrence: %w", err)
+	}
+
+	return s.transactions.UpsertOccurrence(ctx, occurrence, payload)
+}
+
+// ReplaceEvent заменяет существующее событие целиком вместе с переопределениями вхождений (CalDAV PUT)
+func (s *ServiceImpl) ReplaceEvent(ctx context.Context, event *entity.Event, occurrences []*entity.EventOccurrence) error {
+	s.logger.Debugf("[event: %s] ReplaceEvent started, occurrences: %d", event.ID, len(occurrences))
+
+	changes := event.Changes()
+	// при замене пустые поля тоже меняются
+	changes["descriptionEvent"] = event.DescriptionEvent
+	changes["timeForNotification"] = event.TimeForNotification
+	changes["rrule"] = event.RRuleValue()
+
+	payload, err := json.Marshal(entity.EventUpdatedPayload{
+		ID:          event.ID,
+		Changes:     changes,
+		Replaced:    true,
+		Occurrences: occurrences,
+		UpdatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		s.logger.Errorf("[event: %s] failed to marshal event to JSON: %v", event.ID, err)
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return s.transactions.ReplaceEvent(ctx, event, occurrences, payload)
+}
+
+// GetEventResource возвращает событие вместе с переопределениями вхождений (ресурс CalDAV)
+func (s *ServiceImpl) GetEventResource(ctx context.Context, id string) ([]*entity.EventResponse, error) {
+	s.logger.Debugf("[event: %s] GetEventResource started", id)
+
+	return s.repo.GetEventWithOverrides(ctx, id)
+}
+
+func (s *ServiceImpl) GetUserEvents(ctx context.Context, userID string) ([]*entity.EventResponse, error) {
+	s.logger.Debugf("[user: %s] GetUserEvents started", userID)
+
+	return s.repo.GetUserEvents(ctx, userID)
+}
+
+func (s *ServiceImpl) GetUserCollectionState(ctx context.Context, userID string) (entity.CollectionState, error) {
+	s.logger.Debugf("[user: %s] GetUserCollectionState started", userID)
+
+	return s.repo.GetUserCollectionState(ctx, userID)
 }
 
 func (s *ServiceImpl) DeleteOldEventsByYear(ctx context.Context, days *int) {
@@ -7,17 +7,57 @@ import (
 	"context"
 	"time"
 
+	"github.com/gofrs/uuid"
 	"go.uber.org/zap"
 )
 
+const (
+	defaultOutboxRetention = 7 * 24 * time.Hour
+	defaultOutboxBatchSize = 1000
+	defaultInboxRetention  = 30 * 24 * time.Hour
+
+	defaultReminderBatchSize   = 100
+	defaultReminderMaxLateness = 24 * time.Hour
+
+	defaultDigestBatchSize = 100
+)
+
 type UseCaser interface {
-	CreateEvent(ctx context.Context, event entity.Event) error
-	GetEvent(ctx context.Context, start, end time.Time) ([]*entity.EventResponse, error)
+	CreateEvent(ctx context.Context, event entity.Event, occurrences []*entity.EventOccurrence) error
+	GetEvent(ctx context.Context, f entity.EventFilter, start, end time.Time) ([]*entity.EventResponse, error)
 	UpdateEvent(ctx context.Context, event entity.Event) error
 	DeleteEvent(ctx context.Context, id string) error
+	UpsertOccurrence(ctx context.Context, occurrence entity.EventOccurrence) (bool, error)
+	CancelOccurrence(ctx context.Context, seriesID uuid.UUID, recurrenceID string) error
+	ReplaceEvent(ctx context.Context, event entity.Event, occurrences []*entity.EventOccurrence) error
+	GetEventResource(ctx context.Context, id string) ([]*entity.EventResponse, error)
+	GetUserEvents(ctx context.Context, userID string) ([]*entity.EventResponse, error)
+	GetUserCollectionState(ctx context.Context, userID string) (entity.CollectionState, error)
 	DeleteOldEventsByYear(ctx context.Context)
 	RunRelay(ctx context.Context)
-	ConsumerMessage(ctx context.Context, msg []byte, msgTime time.Time)
+	ListOutbox(ctx context.Context, f entity.OutboxFilter) (*entity.OutboxPage, error)
+	GetOutbox(ctx context.Context, id int) (*entity.OutboxEvent, error)
+	RequeueOutbox(ctx context.Context, id int) error
+	RequeueOutboxByFilter(ctx context.Context, f entity.OutboxFilter) (int64, error)
+	PurgeSentOutbox(ctx context.Context, olderThan time.Duration) (int64, error)
+	CleanupOutbox(ctx context.Context) (int64, error)
+	CleanupInbox(ctx context.Context) (int64, error)
+	ConsumerMessage(ctx context.Context, msg entity.InboxMessage) (bool, error)
+	ConsumeCommand(ctx context.Context, msg entity.InboxMessage) (bool, error)
+	FireReminders(ctx context.Context) (fired, missed int64, err error)
+	SendAgendaDigests(ctx context.Context) (sent, empty int64, err error)
+	GetNotificationSettings(ctx context.Context, userID string) (*entity.NotificationSettings, error)
+	UpdateNotificationSettings(ctx context.Context, settings entity.NotificationSettings) error
+	ConsumeNotification(ctx context.Context, msg entity.InboxMessage) (bool, error)
+	RunNotifier(ctx context.Context)
+	ListAttendees(ctx context.Context, eventID uuid.UUID) ([]entity.Attendee, error)
+	InviteAttendees(ctx context.Context, eventID uuid.UUID, req entity.InviteRequest) ([]entity.Attendee, error)
+	RespondAttendee(ctx context.Context, eventID uuid.UUID, userID string, rsvp entity.RSVPRequest) (*entity.Attendee, error)
+	CreateCalendar(ctx context.Context, in entity.CalendarCreate) (*entity.Calendar, error)
+	GetCalendar(ctx context.Context, id uuid.UUID) (*entity.Calendar, error)
+	ListCalendars(ctx context.Context, userID string) ([]entity.Calendar, error)
+	UpdateCalendar(ctx context.Context, id uuid.UUID, patch entity.CalendarPatch) (*entity.Calendar, error)
+	DeleteCalendar(ctx context.Context, id uuid.UUID) error
 
 	HealthCheck(ctx context.Context) (dbHealthy bool, kafkaHealthy bool, err error)
 }
@@ -39,14 +79,16 @@ func (u *UseCase) HealthCheck(ctx context.Context) (dbHealthy bool, kafkaHealthy
 	return u.service.HealthCheck(ctx)
 }
 
-func (u *UseCase) CreateEvent(ctx context.Context, event entity.Event) error {
+// CreateEvent создаёт событие; occurrences - переопределения вхождений новой серии (ресурс CalDAV),
+// сохраняются в той же транзакции
+func (u *UseCase) CreateEvent(ctx context.Context, event entity.Event, occurrences []*entity.EventOccurrence) error {
 	u.logger.Debugf("[event: %s] CreateEvent started]", event.ID)
-	return u.service.CreateEvent(ctx, &event)
+	return u.service.CreateEvent(ctx, &event, occurrences)
 }
 
-func (u *UseCase) GetEvent(ctx context.Context, start, end time.Time) ([]*entity.EventResponse, error) {
-	u.logger.Debugf("[start: %s, end: %s] GetPaymentsByPeriod started]", start, end)
-	return u.service.GetEventsByPeriod(ctx, start, end)
+func (u *UseCase) GetEvent(ctx context.Context, f entity.EventFilter, start, end time.Time) ([]*entity.EventResponse, error) {
+	u.logger.Debugf("[start: %s, end: %s, filter: %+v] GetPaymentsByPeriod started]", start, end, f)
+	return u.service.GetEventsByPeriod(ctx, &f, start, end)
 }
 
 func (u *UseCase) UpdateEvent(ctx context.Context, event entity.Event) error {
@@ -59,6 +101,44 @@ func (u *UseCase) DeleteEvent(ctx context.Context, id string) error {
 	return u.service.DeleteEvent(ctx, id)
 }
 
+// UpsertOccurrence сохраняет переопределение вхождения; false - такое же уже сохранено, event_updated не пишется
+func (u *UseCase) UpsertOccurrence(ctx context.Context, occurrence entity.EventOccurrence) (bool, error) {
+	u.logger.Debugf("[event: %s, recurrenceID: %s] UpsertOccurrence started]", occurrence.SeriesID, occurrence.RecurrenceID)
+	return u.service.UpsertOccurrence(ctx, &occurrence)
+}
+
+func (u *UseCase) CancelOccurrence(ctx context.Context, seriesID uuid.UUID, recurrenceID string) error {
+	u.logger.Debugf("[event: %s, recurrenceID: %s] CancelOccurrence started]", seriesID, recurrenceID)
+	_, err := u.service.UpsertOccurrence(ctx, &entity.EventOccurrence{
+		SeriesID:     seriesID,
+		RecurrenceID: recurrenceID,
+		Cancelled:    true,
+	})
+	return err
+}
+
+// ReplaceEvent заменяет событие целиком: поля, не заданные в event, сбрасываются,
+// переопределения вхождений, которых нет в occurrences, удаляются
+func (u *UseCase) ReplaceEvent(ctx context.Context, event entity.Event, occurrences []*entity.EventOccurrence) error {
+	u.logger.Debugf("[event: %s] ReplaceEvent started]", event.ID)
+	return u.service.ReplaceEvent(ctx, &event, occurrences)
+}
+
+func (u *UseCase) GetEventResource(ctx context.Context, id string) ([]*entity.EventResponse, error) {
+	u.logger.Debugf("[event: %s] GetEventResource started]", id)
+	return u.service.GetEventResource(ctx, id)
+}
+
+func (u *UseCase) GetUserEvents(ctx context.Context, userID string) ([]*entity.EventResponse, error) {
+	u.logger.Debugf("[user: %s] GetUserEvents started]", userID)
+	return u.service.GetUserEvents(ctx, userID)
+}
+
+func (u *UseCase) GetUserCollectionState(ctx context.Context, userID string) (entity.CollectionState, error) {
+	u.logger.Debugf("[user: %s] GetUserCollectionState started]", userID)
+	return u.service.GetUserCollectionState(ctx, userID)
+}
+
 func (u *UseCase) DeleteOldEventsByYear(ctx context.Context) {
 	days := u.conf.Cron.DaysToDelete
 	u.logger.Infof("DeleteOldEventsByYear called with daysToDelete=%d", days)
@@ -69,6 +149,143 @@ func (u *UseCase) RunRelay(ctx context.Context) {
 	u.logger.Debug("relay started")
 	u.service.RelayEventRun(ctx)
 }
-func (u *UseCase) ConsumerMessage(ctx context.Context, msg []byte, msgTime time.Time) {
-	u.logger.Debugf("consumer message: %s, time: %v", msg, msgTime)
+func (u *UseCase) ListOutbox(ctx context.Context, f entity.OutboxFilter) (*entity.OutboxPage, error) {
+	u.logger.Debugf("[filter: %+v] ListOutbox started]", f)
+	return u.service.ListOutbox(ctx, &f)
+}
+
+func (u *UseCase) GetOutbox(ctx context.Context, id int) (*entity.OutboxEvent, error) {
+	u.logger.Debugf("[ID %d] GetOutbox started]", id)
+	return u.service.GetOutbox(ctx, id)
+}
+
+func (u *UseCase) RequeueOutbox(ctx context.Context, id int) error {
+	u.logger.Debugf("[ID %d] RequeueOutbox started]", id)
+	return u.service.RequeueOutbox(ctx, id)
+}
+
+func (u *UseCase) RequeueOutboxByFilter(ctx context.Context, f entity.OutboxFilter) (int64, error) {
+	u.logger.Debugf("[filter: %+v] RequeueOutboxByFilter started]", f)
+	return u.service.RequeueOutboxByFilter(ctx, &f)
+}
+
+func (u *UseCase) PurgeSentOutbox(ctx context.Context, olderThan time.Duration) (int64, error) {
+	u.logger.Debugf("[olderThan: %s] PurgeSentOutbox started]", olderThan)
+	return u.service.PurgeSentOutbox(ctx, olderThan)
+}
+
+// CleanupOutbox очистка отправленных сообщений outbox по настройкам cron.outbox*
+func (u *UseCase) CleanupOutbox(ctx context.Context) (int64, error) {
+	retention := u.conf.Cron.OutboxRetention
+	if retention <= 0 {
+		retention = defaultOutboxRetention
+	}
+	batchSize := u.conf.Cron.OutboxBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultOutboxBatchSize
+	}
+	u.logger.Infof("CleanupOutbox called with retention=%s, batchSize=%d, archive=%t", retention, batchSize, u.conf.Cron.OutboxArchive)
+	return u.service.CleanupSentOutbox(ctx, retention, batchSize, u.conf.Cron.OutboxArchive)
+}
+
+// CleanupInbox удаление обработанных сообщений inbox по настройкам cron.inboxRetention и cron.outboxBatchSize
+func (u *UseCase) CleanupInbox(ctx context.Context) (int64, error) {
+	retention := u.conf.Cron.InboxRetention
+	if retention <= 0 {
+		retention = defaultInboxRetention
+	}
+	batchSize := u.conf.Cron.OutboxBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultOutboxBatchSize
+	}
+	u.logger.Infof("CleanupInbox called with retention=%s, batchSize=%d", retention, batchSize)
+	return u.service.CleanupInbox(ctx, retention, batchSize)
+}
+
+// FireReminders отправка наступивших напоминаний по настройкам cron.reminder*
+func (u *UseCase) FireReminders(ctx context.Context) (fired, missed int64, err error) {
+	batchSize := u.conf.Cron.ReminderBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultReminderBatchSize
+	}
+	maxLateness := u.conf.Cron.ReminderMaxLateness
+	if maxLateness == 0 {
+		maxLateness = defaultReminderMaxLateness
+	}
+	u.logger.Debugf("FireReminders called with batchSize=%d, maxLateness=%s", batchSize, maxLateness)
+	return u.service.FireReminders(ctx, batchSize, maxLateness)
+}
+
+// SendAgendaDigests отправка сводок событий дня пользователям, у которых наступило время сводки
+func (u *UseCase) SendAgendaDigests(ctx context.Context) (sent, empty int64, err error) {
+	batchSize := u.conf.Cron.DigestBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultDigestBatchSize
+	}
+	u.logger.Debugf("SendAgendaDigests called with batchSize=%d", batchSize)
+	return u.service.SendAgendaDigests(ctx, batchSize)
+}
+
+func (u *UseCase) GetNotificationSettings(ctx context.Context, userID string) (*entity.NotificationSettings, error) {
+	u.logger.Debugf("[user: %s] GetNotificationSettings started]", userID)
+	return u.service.GetNotificationSettings(ctx, userID)
+}
+
+func (u *UseCase) UpdateNotificationSettings(ctx context.Context, settings entity.NotificationSettings) error {
+	u.logger.Debugf("[user: %s] UpdateNotificationSettings started]", settings.UserID)
+	return u.service.UpdateNotificationSettings(ctx, &settings)
+}
+
+// ConsumeNotification сообщение, по которому отправляются уведомления (event_reminder, agenda_digest,
+// invitation_sent, rsvp_changed): уведомления ставятся в очередь в транзакции inbox; false - дубль
+func (u *UseCase) ConsumeNotification(ctx context.Context, msg entity.InboxMessage) (bool, error) {
+	u.logger.Debugf("[message: %s] consumer notification %s, time: %v", msg.ID, msg.EventType, msg.ReceivedAt)
+	return u.service.HandleInboxMessage(ctx, &msg, func(ctx context.Context) error {
+		return u.service.EnqueueNotifications(ctx, &msg)
+	})
+}
+
+func (u *UseCase) RunNotifier(ctx context.Context) {
+	u.logger.Debug("notifier started")
+	u.service.RunNotifier(ctx)
+}
+
+func (u *UseCase) ListAttendees(ctx context.Context, eventID uuid.UUID) ([]entity.Attendee, error) {
+	u.logger.Debugf("[event: %s] ListAttendees started]", eventID)
+	return u.service.ListAttendees(ctx, eventID)
+}
+
+func (u *UseCase) InviteAttendees(ctx context.Context, eventID uuid.UUID, req entity.InviteRequest) ([]entity.Attendee, error) {
+	u.logger.Debugf("[event: %s] InviteAttendees started]", eventID)
+	return u.service.InviteAttendees(ctx, eventID, &req)
+}
+
+func (u *UseCase) RespondAttendee(ctx context.Context, eventID uuid.UUID, userID string, rsvp entity.RSVPRequest) (*entity.Attendee, error) {
+	u.logger.Debugf("[event: %s, user: %s] RespondAttendee started]", eventID, userID)
+	return u.service.RespondAttendee(ctx, eventID, userID, &rsvp)
+}
+
+func (u *UseCase) CreateCalendar(ctx context.Context, in entity.CalendarCreate) (*entity.Calendar, error) {
+	u.logger.Debugf("[user: %s] CreateCalendar started]", in.UserID)
+	return u.service.CreateCalendar(ctx, &in)
+}
+
+func (u *UseCase) GetCalendar(ctx context.Context, id uuid.UUID) (*entity.Calendar, error) {
+	u.logger.Debugf("[calendar: %s] GetCalendar started]", id)
+	return u.service.GetCalendar(ctx, id)
+}
+
+func (u *UseCase) ListCalendars(ctx context.Context, userID string) ([]entity.Calendar, error) {
+	u.logger.Debugf("[user: %s] ListCalendars started]", userID)
+	return u.service.ListCalendars(ctx, userID)
+}
+
+func (u *UseCase) UpdateCalendar(ctx context.Context, id uuid.UUID, patch entity.CalendarPatch) (*entity.Calendar, error) {
+	u.logger.Debugf("[calendar: %s] UpdateCalendar started]", id)
+	return u.service.UpdateCalendar(ctx, id, &patch)
+}
+
+func (u *UseCase) DeleteCalendar(ctx context.Context, id uuid.UUID) error {
+	u.logger.Debugf("[calendar: %s] DeleteCalendar started]", id)
+	return u.service.DeleteCalendar(ctx, id)
 }
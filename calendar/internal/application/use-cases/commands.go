@@ -0,0 +1,123 @@
+package use_cases
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"calendar/internal/application/validation"
+	"calendar/pkg/validator"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// ConsumerMessage обработка сообщения консьюмера через inbox; false - дубль
+func (u *UseCase) ConsumerMessage(ctx context.Context, msg entity.InboxMessage) (bool, error) {
+	u.logger.Debugf("[message: %s] consumer message, time: %v", msg.ID, msg.ReceivedAt)
+	return u.service.HandleInboxMessage(ctx, &msg, nil)
+}
+
+// ConsumeCommand выполнение команды (заголовок command_id) в транзакции inbox; false - дубль.
+// Ответ уходит в reply topic до коммита транзакции: если ответ не отправлен, откатывается и команда,
+// и сообщение будет обработано заново.
+func (u *UseCase) ConsumeCommand(ctx context.Context, msg entity.InboxMessage) (bool, error) {
+	u.logger.Debugf("[command %s] consumer command, time: %v", msg.CommandID, msg.ReceivedAt)
+
+	processed, err := u.service.HandleInboxMessage(ctx, &msg, func(ctx context.Context) error {
+		res, err := u.executeCommand(ctx, msg)
+		if err != nil {
+			return err
+		}
+		return u.service.PublishCommandResult(ctx, res)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if !processed {
+		// повтор уже выполненной команды: сообщаем отправителю, ошибка ответа не мешает коммиту offset
+		res := &entity.CommandResult{CommandID: msg.CommandID, Status: entity.CommandDuplicate, ProcessedAt: time.Now().UTC()}
+		if err := u.service.PublishCommandResult(ctx, res); err != nil {
+			u.logger.Errorf("[command %s] publish duplicate result failed: %v", msg.CommandID, err)
+		}
+	}
+	return processed, nil
+}
+
+// executeCommand выполняет команду через методы UseCase с той же валидацией, что и REST.
+// Ошибки команды (валидация, не найдено, уже создано) попадают в ответ, ошибка возвращается
+// только для инфраструктурных сбоев - тогда сообщение будет повторено.
+func (u *UseCase) executeCommand(ctx context.Context, msg entity.InboxMessage) (*entity.CommandResult, error) {
+	res := &entity.CommandResult{CommandID: msg.CommandID}
+
+	var cmd entity.EventCommand
+	if err := json.Unmarshal(msg.Payload, &cmd); err != nil {
+		u.logger.Warnf("[command %s] invalid command body: %v", msg.CommandID, err)
+		return commandFailed(res, fmt.Errorf("invalid command body: %w", err), nil), nil
+	}
+	res.Type = cmd.Type
+	u.logger.Infof("[command %s] %s started", msg.CommandID, cmd.Type)
+
+	var err error
+	switch cmd.Type {
+	case entity.CommandCreateEvent, entity.CommandUpdateEvent:
+		if cmd.Event == nil {
+			return commandFailed(res, errors.New("event is required"), nil), nil
+		}
+		res.EventID = cmd.Event.ID.String()
+
+		// Валидация структуры и логическая валидация дат - как в REST
+		if err = validator.Validate.Struct(cmd.Event); err != nil {
+			u.logger.Warnf("[command %s] validation error: %v", msg.CommandID, err)
+			return commandFailed(res, errors.New("validation failed"), validator.Messages(err)), nil
+		}
+		if err = validation.EventDates(cmd.Event); err != nil {
+			u.logger.Warnf("[command %s] date validation error: %v", msg.CommandID, err)
+			return commandFailed(res, err, nil), nil
+		}
+		if err = validation.Recurrence(cmd.Event); err != nil {
+			u.logger.Warnf("[command %s] rrule validation error: %v", msg.CommandID, err)
+			return commandFailed(res, err, nil), nil
+		}
+
+		if cmd.Type == entity.CommandCreateEvent {
+			err = u.CreateEvent(ctx, *cmd.Event, nil)
+		} else {
+			err = u.UpdateEvent(ctx, *cmd.Event)
+		}
+	case entity.CommandDeleteEvent:
+		res.EventID = cmd.ID
+		if _, err = uuid.FromString(cmd.ID); err != nil {
+			return commandFailed(res, errors.New("invalid id"), nil), nil
+		}
+		err = u.DeleteEvent(ctx, cmd.ID)
+	default:
+		return commandFailed(res, fmt.Errorf("unknown command type %q", cmd.Type), nil), nil
+	}
+
+	var errResp appers.ErrorResp
+	switch {
+	case err == nil:
+		res.Status = entity.CommandOK
+		res.ProcessedAt = time.Now().UTC()
+		return res, nil
+	case errors.As(err, &errResp) && errResp.StatusCode < http.StatusInternalServerError:
+		u.logger.Warnf("[command %s] %s rejected: %v", msg.CommandID, cmd.Type, err)
+		return commandFailed(res, err, nil), nil
+	default:
+		u.logger.Errorf("[command %s] %s failed: %v", msg.CommandID, cmd.Type, err)
+		return nil, err
+	}
+}
+
+func commandFailed(res *entity.CommandResult, err error, details []string) *entity.CommandResult {
+	res.Status = entity.CommandError
+	res.Error = err.Error()
+	res.Details = details
+	res.ProcessedAt = time.Now().UTC()
+	return res
+}
@@ -0,0 +1,49 @@
+package validation
+
+import (
+	"calendar/internal/application/entity"
+	"fmt"
+	"time"
+)
+
+// EventDates выполняет логическую валидацию дат события (REST, импорт, CalDAV, команды Kafka)
+func EventDates(event *entity.Event) error {
+	dateEvent, err := time.Parse(time.RFC3339, event.DateEvent)
+	if err != nil {
+		return fmt.Errorf("неверный формат dateEvent: %w", err)
+	}
+
+	endDateEvent, err := time.Parse(time.RFC3339, event.EndDateEvent)
+	if err != nil {
+		return fmt.Errorf("неверный формат durationEvent: %w", err)
+	}
+
+	// Проверяем, что дата окончания после даты начала
+	if !endDateEvent.After(dateEvent) {
+		return fmt.Errorf("дата окончания события должна быть после даты начала")
+	}
+
+	// Если указано время уведомления, проверяем что оно до начала события
+	if event.TimeForNotification != "" {
+		notificationTime, err := time.Parse(time.RFC3339, event.TimeForNotification)
+		if err != nil {
+			return fmt.Errorf("неверный формат timeForNotification: %w", err)
+		}
+		if !notificationTime.Before(dateEvent) {
+			return fmt.Errorf("время уведомления должно быть до начала события")
+		}
+	}
+
+	// Напоминания: offset или at, без повторов; абсолютное время - до начала события
+	specs, err := event.ReminderSpecs()
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		if spec.At != nil && !spec.At.Before(dateEvent) {
+			return fmt.Errorf("время напоминания должно быть до начала события")
+		}
+	}
+
+	return nil
+}
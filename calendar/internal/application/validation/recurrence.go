@@ -0,0 +1,30 @@
+package validation
+
+import (
+	"calendar/internal/application/entity"
+	"calendar/internal/application/recurrence"
+	"fmt"
+
+	"github.com/teambition/rrule-go"
+)
+
+// Recurrence проверяет правило повторения RRULE (RFC 5545); пустое или не заданное правило допустимо.
+// Повторения чаще раза в день (HOURLY, MINUTELY, SECONDLY) и COUNT больше recurrence.MaxCount не допускаются:
+// такие серии разворачиваются в слишком много вхождений
+func Recurrence(event *entity.Event) error {
+	rule := event.RRuleValue()
+	if rule == "" {
+		return nil
+	}
+	opt, err := recurrence.ParseRule(rule)
+	if err != nil {
+		return fmt.Errorf("поле 'rrule' должно быть правилом RRULE по RFC 5545 (например, FREQ=WEEKLY;BYDAY=MO): %w", err)
+	}
+	if opt.Freq > rrule.DAILY {
+		return fmt.Errorf("поле 'rrule': FREQ=%s не поддерживается, допустимы DAILY, WEEKLY, MONTHLY и YEARLY", opt.Freq)
+	}
+	if opt.Count > recurrence.MaxCount {
+		return fmt.Errorf("поле 'rrule': COUNT должен быть не больше %d", recurrence.MaxCount)
+	}
+	return nil
+}
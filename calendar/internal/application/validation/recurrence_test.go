@@ -0,0 +1,33 @@
+package validation
+
+import (
+	"calendar/internal/application/entity"
+	"testing"
+)
+
+func TestRecurrence(t *testing.T) {
+	tests := []struct {
+		rule    string
+		wantErr bool
+	}{
+		{rule: ""},
+		{rule: "FREQ=DAILY;COUNT=10"},
+		{rule: "RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{rule: "FREQ=MONTHLY;BYMONTHDAY=1"},
+		{rule: "FREQ=YEARLY;COUNT=1000"},
+		{rule: "FREQ=YEARLY;COUNT=1001", wantErr: true},
+		{rule: "FREQ=HOURLY", wantErr: true},
+		{rule: "FREQ=MINUTELY;COUNT=10", wantErr: true},
+		{rule: "FREQ=SECONDLY;COUNT=100000000", wantErr: true},
+		{rule: "FREQ=SOMETIMES", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			rule := tt.rule
+			err := Recurrence(&entity.Event{RRule: &rule})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Recurrence(%q) error = %v, wantErr %v", tt.rule, err, tt.wantErr)
+			}
+		})
+	}
+}
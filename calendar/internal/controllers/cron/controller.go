@@ -3,6 +3,7 @@ package cron
 import (
 	use_cases "calendar/internal/application/use-cases"
 	"calendar/pkg/config"
+	"calendar/pkg/metrics"
 	"context"
 	"fmt"
 
@@ -51,6 +52,66 @@ func (c *Controller) RegisterDeleteOldEventsJob(usecase use_cases.UseCaser, conf
 	return nil
 }
 
+// RegisterOutboxRetentionJob регистрирует очистку отправленных сообщений outbox
+// со своим расписанием cron.outboxSchedule (по умолчанию раз в час)
+func (c *Controller) RegisterOutboxRetentionJob(usecase use_cases.UseCaser, conf config.Cron, m *metrics.Metrics) error {
+	job := NewOutboxRetentionJob(usecase, c.logger, conf.OutboxArchive, m)
+
+	spec := conf.OutboxSchedule
+	if spec == "" {
+		spec = "@every 1h"
+		c.logger.Warnf("⚠Расписание очистки outbox не указано, используется интервал по умолчанию: %s", spec)
+	}
+
+	entryID, err := c.scheduler.Add(spec, job)
+	if err != nil {
+		return fmt.Errorf("не удалось зарегистрировать задачу очистки outbox: %w", err)
+	}
+
+	c.logger.Infof("Задача очистки outbox зарегистрирована с ID: %d, расписание: %s", entryID, spec)
+	return nil
+}
+
+// RegisterReminderJob регистрирует отправку наступивших напоминаний
+// со своим расписанием cron.reminderSchedule (по умолчанию каждые 30 секунд)
+func (c *Controller) RegisterReminderJob(usecase use_cases.UseCaser, conf config.Cron, m *metrics.Metrics) error {
+	job := NewReminderJob(usecase, c.logger, m)
+
+	spec := conf.ReminderSchedule
+	if spec == "" {
+		spec = "@every 30s"
+		c.logger.Warnf("⚠Расписание напоминаний не указано, используется интервал по умолчанию: %s", spec)
+	}
+
+	entryID, err := c.scheduler.Add(spec, job)
+	if err != nil {
+		return fmt.Errorf("не удалось зарегистрировать задачу напоминаний: %w", err)
+	}
+
+	c.logger.Infof("Задача напоминаний зарегистрирована с ID: %d, расписание: %s", entryID, spec)
+	return nil
+}
+
+// RegisterAgendaDigestJob регистрирует отправку ежедневных сводок событий
+// со своим расписанием cron.digestSchedule (по умолчанию каждую минуту)
+func (c *Controller) RegisterAgendaDigestJob(usecase use_cases.UseCaser, conf config.Cron, m *metrics.Metrics) error {
+	job := NewAgendaDigestJob(usecase, c.logger, m)
+
+	spec := conf.DigestSchedule
+	if spec == "" {
+		spec = "@every 1m"
+		c.logger.Warnf("⚠Расписание сводок не указано, используется интервал по умолчанию: %s", spec)
+	}
+
+	entryID, err := c.scheduler.Add(spec, job)
+	if err != nil {
+		return fmt.Errorf("не удалось зарегистрировать задачу сводок: %w", err)
+	}
+
+	c.logger.Infof("Задача сводок зарегистрирована с ID: %d, расписание: %s", entryID, spec)
+	return nil
+}
+
 // Start запускает планировщик задач
 func (c *Controller) Start() {
 	c.logger.Info("Запуск планировщика cron задач")
@@ -2,8 +2,10 @@ package cron
 
 import (
 	"calendar/internal/application/use-cases"
+	"calendar/pkg/metrics"
 	"context"
 	"go.uber.org/zap"
+	"time"
 )
 
 // OutdatedJob - задача для удаления устаревших событий
@@ -33,3 +35,159 @@ func (j *OutdatedJob) Run(ctx context.Context) {
 	j.usecase.DeleteOldEventsByYear(ctx)
 	j.logger.Info("Задача удаления устаревших событий завершена")
 }
+
+// OutboxRetentionJob - задача очистки отправленных сообщений outbox (архив или удаление)
+// и обработанных сообщений inbox
+type OutboxRetentionJob struct {
+	usecase use_cases.UseCaser
+	logger  *zap.SugaredLogger
+	archive bool
+	m       *metrics.Metrics
+}
+
+// NewOutboxRetentionJob создает задачу очистки outbox
+func NewOutboxRetentionJob(usecase use_cases.UseCaser, logger *zap.SugaredLogger, archive bool, m *metrics.Metrics) *OutboxRetentionJob {
+	return &OutboxRetentionJob{
+		usecase: usecase,
+		logger:  logger,
+		archive: archive,
+		m:       m,
+	}
+}
+
+// Run выполняет очистку outbox
+func (j *OutboxRetentionJob) Run(ctx context.Context) {
+	j.logger.Info("Запуск задачи очистки outbox")
+
+	defer func() {
+		if r := recover(); r != nil {
+			j.logger.Errorf("Паника при выполнении задачи очистки outbox: %v", r)
+		}
+	}()
+
+	action := "deleted"
+	if j.archive {
+		action = "archived"
+	}
+
+	start := time.Now()
+	n, err := j.usecase.CleanupOutbox(ctx)
+	if j.m != nil {
+		j.m.Outbox.RetentionRowsTotal.WithLabelValues(action).Add(float64(n))
+	}
+	if err != nil {
+		j.logger.Errorf("Ошибка очистки outbox (обработано строк: %d, %s): %v", n, action, err)
+	}
+
+	// inbox чистится, даже если outbox не удалось: таблицы независимы
+	inbox, inboxErr := j.usecase.CleanupInbox(ctx)
+	if j.m != nil {
+		j.m.Outbox.RetentionRowsTotal.WithLabelValues("inbox_deleted").Add(float64(inbox))
+	}
+	if inboxErr != nil {
+		j.logger.Errorf("Ошибка очистки inbox (удалено строк: %d): %v", inbox, inboxErr)
+	}
+
+	if err != nil || inboxErr != nil {
+		if j.m != nil {
+			j.m.Outbox.RetentionRunsTotal.WithLabelValues("error").Inc()
+		}
+		return
+	}
+	if j.m != nil {
+		j.m.Outbox.RetentionRunsTotal.WithLabelValues("success").Inc()
+	}
+	j.logger.Infof("Задача очистки outbox завершена: %d строк (%s), inbox: %d строк за %s", n, action, inbox, time.Since(start))
+}
+
+// ReminderJob - задача отправки наступивших напоминаний о событиях
+type ReminderJob struct {
+	usecase use_cases.UseCaser
+	logger  *zap.SugaredLogger
+	m       *metrics.Metrics
+}
+
+// NewReminderJob создает задачу отправки напоминаний
+func NewReminderJob(usecase use_cases.UseCaser, logger *zap.SugaredLogger, m *metrics.Metrics) *ReminderJob {
+	return &ReminderJob{
+		usecase: usecase,
+		logger:  logger,
+		m:       m,
+	}
+}
+
+// Run выполняет отправку напоминаний
+func (j *ReminderJob) Run(ctx context.Context) {
+	j.logger.Debug("Запуск задачи отправки напоминаний")
+
+	defer func() {
+		if r := recover(); r != nil {
+			j.logger.Errorf("Паника при выполнении задачи отправки напоминаний: %v", r)
+		}
+	}()
+
+	fired, missed, err := j.usecase.FireReminders(ctx)
+	if j.m != nil {
+		j.m.Reminder.RemindersTotal.WithLabelValues("fired").Add(float64(fired))
+		j.m.Reminder.RemindersTotal.WithLabelValues("missed").Add(float64(missed))
+	}
+	if err != nil {
+		if j.m != nil {
+			j.m.Reminder.RunsTotal.WithLabelValues("error").Inc()
+		}
+		j.logger.Errorf("Ошибка отправки напоминаний (отправлено: %d, пропущено: %d): %v", fired, missed, err)
+		return
+	}
+	if j.m != nil {
+		j.m.Reminder.RunsTotal.WithLabelValues("success").Inc()
+	}
+	if fired+missed > 0 {
+		j.logger.Infof("Задача отправки напоминаний завершена: отправлено %d, пропущено %d", fired, missed)
+	}
+}
+
+// AgendaDigestJob - задача отправки ежедневных сводок событий
+type AgendaDigestJob struct {
+	usecase use_cases.UseCaser
+	logger  *zap.SugaredLogger
+	m       *metrics.Metrics
+}
+
+// NewAgendaDigestJob создает задачу отправки сводок
+func NewAgendaDigestJob(usecase use_cases.UseCaser, logger *zap.SugaredLogger, m *metrics.Metrics) *AgendaDigestJob {
+	return &AgendaDigestJob{
+		usecase: usecase,
+		logger:  logger,
+		m:       m,
+	}
+}
+
+// Run выполняет отправку сводок
+func (j *AgendaDigestJob) Run(ctx context.Context) {
+	j.logger.Debug("Запуск задачи отправки сводок")
+
+	defer func() {
+		if r := recover(); r != nil {
+			j.logger.Errorf("Паника при выполнении задачи отправки сводок: %v", r)
+		}
+	}()
+
+	sent, empty, err := j.usecase.SendAgendaDigests(ctx)
+	if j.m != nil {
+		j.m.Digest.DigestsTotal.WithLabelValues("sent").Add(float64(sent))
+		j.m.Digest.DigestsTotal.WithLabelValues("empty").Add(float64(empty))
+	}
+	if err != nil {
+		if j.m != nil {
+			j.m.Digest.RunsTotal.WithLabelValues("error").Inc()
+		}
+		j.logger.Errorf("Ошибка отправки сводок (отправлено: %d, без событий: %d): %v", sent, empty, err)
+		return
+	}
+	if j.m != nil {
+		j.m.Digest.RunsTotal.WithLabelValues("success").Inc()
+	}
+	if sent+empty > 0 {
+		j.logger.Infof("Задача отправки сводок завершена: отправлено %d, без событий %d", sent, empty)
+	}
+}
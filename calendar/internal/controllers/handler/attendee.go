@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"calendar/pkg/validator"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
+)
+
+// ListAttendees godoc
+// @Summary     Участники события
+// @Description Возвращает участников события или серии с ролью, ответом (partstat) и комментарием
+// @Produce     json
+// @Param       id   path     string  true  "ID события"
+// @Success     200  {array}  entity.Attendee
+// @Failure     400
+// @Failure     404
+// @Failure     500
+// @tags        Attendee
+// @Router      /v1/event/{id}/attendees [get]
+func (h *HandlerImpl) ListAttendees(c *fiber.Ctx) error {
+	eventID, err := uuid.FromString(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id",
+		})
+	}
+
+	attendees, err := h.usecase.ListAttendees(c.Context(), eventID)
+	switch {
+	case errors.Is(err, appers.ErrEventNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(attendees)
+}
+
+// InviteAttendees godoc
+// @Summary     Приглашение участников
+// @Description Приглашает участников в событие или серию (invitation_sent в outbox по каждому новому участнику).
+// @Description Уже приглашённому меняется роль, его ответ сохраняется. Переопределение вхождения - 404
+// @Accept      json
+// @Produce     json
+// @Param       id    path     string                true  "ID события"
+// @Param       body  body     entity.InviteRequest  true  "Участники"
+// @Success     200   {array}  entity.Attendee
+// @Failure     400
+// @Failure     404
+// @Failure     422
+// @Failure     500
+// @tags        Attendee
+// @Router      /v1/event/{id}/attendees [post]
+func (h *HandlerImpl) InviteAttendees(c *fiber.Ctx) error {
+	eventID, err := uuid.FromString(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id",
+		})
+	}
+
+	var req entity.InviteRequest
+	if err = c.BodyParser(&req); err != nil {
+		h.logger.Errorf("error parsing body: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err = validator.Validate.Struct(&req); err != nil {
+		h.logger.Warnf("validation error: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(formatValidationErrors(err))
+	}
+
+	attendees, err := h.usecase.InviteAttendees(c.Context(), eventID, req)
+	switch {
+	case errors.Is(err, appers.ErrEventNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case errors.Is(err, appers.ErrAttendeeIsOrganizer):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"description": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(attendees)
+}
+
+// RespondAttendee godoc
+// @Summary     Ответ на приглашение
+// @Description Сохраняет ответ участника (accepted, declined, tentative, needs-action) и комментарий.
+// @Description Изменённый ответ пишет rsvp_changed в outbox, организатору уходит уведомление
+// @Accept      json
+// @Produce     json
+// @Param       id      path     string              true  "ID события"
+// @Param       userID  path     string              true  "ID участника"
+// @Param       body    body     entity.RSVPRequest  true  "Ответ"
+// @Success     200     {object} entity.Attendee
+// @Failure     400
+// @Failure     404
+// @Failure     500
+// @tags        Attendee
+// @Router      /v1/event/{id}/attendees/{userID} [put]
+func (h *HandlerImpl) RespondAttendee(c *fiber.Ctx) error {
+	eventID, err := uuid.FromString(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id",
+		})
+	}
+
+	var rsvp entity.RSVPRequest
+	if err = c.BodyParser(&rsvp); err != nil {
+		h.logger.Errorf("error parsing body: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err = validator.Validate.Struct(&rsvp); err != nil {
+		h.logger.Warnf("validation error: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(formatValidationErrors(err))
+	}
+
+	attendee, err := h.usecase.RespondAttendee(c.Context(), eventID, c.Params("userID"), rsvp)
+	switch {
+	case errors.Is(err, appers.ErrEventNotFound), errors.Is(err, appers.ErrAttendeeNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(attendee)
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"calendar/internal/appers"
+	"calendar/pkg/config"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	RoleAdmin = "admin"
+
+	localsRole   = "role"
+	bearerPrefix = "Bearer "
+	basicPrefix  = "Basic "
+	davRealm     = `Basic realm="calendar"`
+)
+
+// roleToken токен и роль, которую он даёт
+type roleToken struct {
+	token []byte
+	role  string
+}
+
+func parseRoleTokens(conf config.Auth) []roleToken {
+	var tokens []roleToken
+	for _, t := range strings.Split(conf.AdminTokens, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tokens = append(tokens, roleToken{token: []byte(t), role: RoleAdmin})
+		}
+	}
+	return tokens
+}
+
+// RequireRole пропускает запрос, только если Bearer-токен из заголовка Authorization даёт роль role.
+// Роль кладётся в c.Locals("role").
+func RequireRole(conf config.Auth, role string, logger *zap.SugaredLogger) fiber.Handler {
+	tokens := parseRoleTokens(conf)
+	if len(tokens) == 0 {
+		logger.Warnf("auth: no tokens configured for role %s, endpoints are closed", role)
+	}
+
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if !strings.HasPrefix(header, bearerPrefix) {
+			return appers.SanitizeError(c, appers.ErrUnauthorized)
+		}
+		got := []byte(strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix)))
+
+		for _, t := range tokens {
+			if subtle.ConstantTimeCompare(got, t.token) != 1 {
+				continue
+			}
+			if t.role != role {
+				logger.Warnf("auth: access denied to %s %s for role %s", c.Method(), c.Path(), t.role)
+				return appers.SanitizeError(c, appers.ErrForbidden)
+			}
+			c.Locals(localsRole, t.role)
+			return c.Next()
+		}
+
+		logger.Warnf("auth: unknown token for %s %s", c.Method(), c.Path())
+		return appers.SanitizeError(c, appers.ErrUnauthorized)
+	}
+}
+
+// parseDavUsers пароли пользователей CalDAV из пар user:password
+func parseDavUsers(conf config.Auth) map[string][]byte {
+	users := make(map[string][]byte)
+	for _, pair := range strings.Split(conf.DavUsers, ",") {
+		user, password, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && user != "" && password != "" {
+			users[user] = []byte(password)
+		}
+	}
+	return users
+}
+
+// RequireDavUser пропускает запрос CalDAV, только если HTTP Basic-авторизация выполнена
+// пользователем из пути (:user) с паролем из auth.davUsers. Клиентам отдаётся WWW-Authenticate,
+// чтобы они запросили учётные данные.
+func RequireDavUser(conf config.Auth, logger *zap.SugaredLogger) fiber.Handler {
+	users := parseDavUsers(conf)
+	if len(users) == 0 {
+		logger.Warn("auth: no CalDAV users configured, CalDAV is closed")
+	}
+
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if !strings.HasPrefix(header, basicPrefix) {
+			c.Set(fiber.HeaderWWWAuthenticate, davRealm)
+			return appers.SanitizeError(c, appers.ErrUnauthorized)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(strings.TrimPrefix(header, basicPrefix)))
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, davRealm)
+			return appers.SanitizeError(c, appers.ErrUnauthorized)
+		}
+		user, password, _ := strings.Cut(string(decoded), ":")
+
+		want, ok := users[user]
+		if !ok || subtle.ConstantTimeCompare([]byte(password), want) != 1 {
+			logger.Warnf("auth: invalid CalDAV credentials for %s %s", c.Method(), c.Path())
+			c.Set(fiber.HeaderWWWAuthenticate, davRealm)
+			return appers.SanitizeError(c, appers.ErrUnauthorized)
+		}
+		// пользователь работает только со своим календарём
+		if pathUser, err := davUserID(c); err != nil || user != pathUser {
+			logger.Warnf("auth: CalDAV user %s denied access to %s %s", user, c.Method(), c.Path())
+			return appers.SanitizeError(c, appers.ErrForbidden)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,604 @@
+package handler
+
+import (
+	"bytes"
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"calendar/internal/application/recurrence"
+	use_cases "calendar/internal/application/use-cases"
+	"calendar/internal/application/validation"
+	"calendar/pkg/ical"
+	"calendar/pkg/validator"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
+	"go.uber.org/zap"
+)
+
+// CalDAV (RFC 4791) поверх тех же use case'ов, что и REST API.
+// Структура URL:
+//
+//	/calendar/dav/{userID}/                 - principal и calendar-home пользователя
+//	/calendar/dav/{userID}/events/          - коллекция-календарь пользователя
+//	/calendar/dav/{userID}/events/{id}.ics  - событие (серия вместе с переопределениями вхождений)
+const (
+	DavBasePath      = "/calendar/dav"
+	davCollection    = "events"
+	davDisplayName   = "Календарь"
+	davSyncPrefix    = "data:,"
+	davComponentType = mimeCalendar + "; charset=utf-8; component=vevent"
+	davMethods       = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT"
+	davCapabilities  = "1, 3, calendar-access"
+)
+
+const (
+	MethodPropfind = "PROPFIND"
+	MethodReport   = "REPORT"
+)
+
+type DavHandler interface {
+	Options(c *fiber.Ctx) error
+	PropfindHome(c *fiber.Ctx) error
+	PropfindCollection(c *fiber.Ctx) error
+	PropfindResource(c *fiber.Ctx) error
+	Report(c *fiber.Ctx) error
+	GetResource(c *fiber.Ctx) error
+	PutResource(c *fiber.Ctx) error
+	DeleteResource(c *fiber.Ctx) error
+}
+
+type DavHandlerImpl struct {
+	usecase use_cases.UseCaser
+	logger  *zap.SugaredLogger
+}
+
+func NewDavHandler(usecase use_cases.UseCaser, logger *zap.SugaredLogger) *DavHandlerImpl {
+	return &DavHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+	}
+}
+
+// davResource событие-ресурс коллекции: серия (или одиночное событие) и переопределения её вхождений
+type davResource struct {
+	rows []*entity.EventResponse
+}
+
+func (r davResource) master() *entity.EventResponse {
+	return r.rows[0]
+}
+
+func (r davResource) lastModified() time.Time {
+	last := r.master().UpdatedAt
+	for _, row := range r.rows[1:] {
+		if row.UpdatedAt.After(last) {
+			last = row.UpdatedAt
+		}
+	}
+	return last
+}
+
+func (r davResource) etag() string {
+	return `"` + strconv.FormatInt(r.lastModified().UnixNano(), 36) + `"`
+}
+
+func (r davResource) ics() (string, error) {
+	return ical.Marshal(resourceToCalendar(r.rows, r.lastModified()))
+}
+
+func homeHref(userID string) string {
+	return DavBasePath + "/" + userID + "/"
+}
+
+func collectionHref(userID string) string {
+	return homeHref(userID) + davCollection + "/"
+}
+
+func resourceHref(userID string, id uuid.UUID) string {
+	return collectionHref(userID) + id.String() + icsSuffix
+}
+
+// resourceID ID события по имени ресурса: UUID из имени или UUIDv5, как для UID при импорте
+func resourceID(name string) uuid.UUID {
+	return uidToEventID(strings.TrimSuffix(name, icsSuffix))
+}
+
+func syncToken(state entity.CollectionState) string {
+	return fmt.Sprintf("%s%d-%d", davSyncPrefix, state.Count, state.UpdatedAt.UnixNano())
+}
+
+func davUserID(c *fiber.Ctx) (string, error) {
+	userID := strings.TrimSpace(c.Params("user"))
+	if userID == "" || len(userID) > maxUserIDLength {
+		return "", fmt.Errorf("invalid user")
+	}
+	return userID, nil
+}
+
+func (h *DavHandlerImpl) Options(c *fiber.Ctx) error {
+	c.Set("DAV", davCapabilities)
+	c.Set(fiber.HeaderAllow, davMethods)
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *DavHandlerImpl) principalProps(userID string) davProps {
+	home := hrefElement(homeHref(userID))
+	return davProps{
+		propResourceType:         "<d:collection/><d:principal/>",
+		propDisplayName:          xmlEscape(userID),
+		propCurrentUserPrincipal: home,
+		propPrincipalURL:         home,
+		propCalendarHomeSet:      home,
+	}
+}
+
+func (h *DavHandlerImpl) collectionProps(userID string, state entity.CollectionState) davProps {
+	token := syncToken(state)
+	return davProps{
+		propResourceType:         "<d:collection/><c:calendar/>",
+		propDisplayName:          xmlEscape(davDisplayName),
+		propCurrentUserPrincipal: hrefElement(homeHref(userID)),
+		propOwner:                hrefElement(homeHref(userID)),
+		propSupportedComponents:  `<c:comp name="VEVENT"/>`,
+		propGetCTag:              xmlEscape(token),
+		propSyncToken:            xmlEscape(token),
+		propSupportedReportSet: "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><d:sync-collection/></d:report></d:supported-report>",
+		propCurrentUserPrivilege: "<d:privilege><d:read/></d:privilege><d:privilege><d:write/></d:privilege>" +
+			"<d:privilege><d:write-content/></d:privilege><d:privilege><d:bind/></d:privilege><d:privilege><d:unbind/></d:privilege>",
+	}
+}
+
+func (h *DavHandlerImpl) resourceProps(res davResource, withData bool) (davProps, error) {
+	props := davProps{
+		propResourceType:    "",
+		propGetETag:         xmlEscape(res.etag()),
+		propGetContentType:  davComponentType,
+		propGetLastModified: res.lastModified().UTC().Format(http.TimeFormat),
+	}
+	if withData {
+		data, err := res.ics()
+		if err != nil {
+			return nil, err
+		}
+		props[propCalendarData] = xmlEscape(data)
+	}
+	return props, nil
+}
+
+func wantsProp(requested []xml.Name, name xml.Name) bool {
+	for _, n := range requested {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// loadResources группирует строки событий пользователя в ресурсы коллекции
+func (h *DavHandlerImpl) loadResources(c *fiber.Ctx, userID string) ([]davResource, error) {
+	rows, err := h.usecase.GetUserEvents(c.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[uuid.UUID]int)
+	resources := make([]davResource, 0, len(rows))
+	for _, row := range rows {
+		if row.SeriesID == nil {
+			index[row.ID] = len(resources)
+			resources = append(resources, davResource{rows: []*entity.EventResponse{row}})
+		}
+	}
+	for _, row := range rows {
+		if row.SeriesID == nil {
+			continue
+		}
+		if i, ok := index[*row.SeriesID]; ok {
+			resources[i].rows = append(resources[i].rows, row)
+		}
+	}
+	return resources, nil
+}
+
+// loadResource загружает ресурс по имени и проверяет владельца
+func (h *DavHandlerImpl) loadResource(c *fiber.Ctx, userID string) (davResource, error) {
+	id := resourceID(c.Params("name"))
+	rows, err := h.usecase.GetEventResource(c.Context(), id.String())
+	if err != nil {
+		return davResource{}, err
+	}
+	if rows[0].UserID != userID || rows[0].SeriesID != nil {
+		return davResource{}, appers.ErrEventNotFound
+	}
+	return davResource{rows: rows}, nil
+}
+
+func (h *DavHandlerImpl) PropfindHome(c *fiber.Ctx) error {
+	userID, err := davUserID(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	requested, namesOnly, err := parsePropfind(c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	responses := []davResponse{{href: homeHref(userID), props: h.principalProps(userID)}}
+	if c.Get("Depth") != "0" {
+		state, err := h.usecase.GetUserCollectionState(c.Context(), userID)
+		if err != nil {
+			return appers.SanitizeError(c, err)
+		}
+		responses = append(responses, davResponse{href: collectionHref(userID), props: h.collectionProps(userID, state)})
+	}
+	return sendMultistatus(c, responses, requested, namesOnly, "")
+}
+
+func (h *DavHandlerImpl) PropfindCollection(c *fiber.Ctx) error {
+	userID, err := davUserID(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	requested, namesOnly, err := parsePropfind(c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	state, err := h.usecase.GetUserCollectionState(c.Context(), userID)
+	if err != nil {
+		return appers.SanitizeError(c, err)
+	}
+	responses := []davResponse{{href: collectionHref(userID), props: h.collectionProps(userID, state)}}
+
+	if c.Get("Depth") != "0" {
+		resources, err := h.loadResources(c, userID)
+		if err != nil {
+			return appers.SanitizeError(c, err)
+		}
+		withData := wantsProp(requested, propCalendarData)
+		for _, res := range resources {
+			props, err := h.resourceProps(res, withData)
+			if err != nil {
+				return appers.SanitizeError(c, err)
+			}
+			responses = append(responses, davResponse{href: resourceHref(userID, res.master().ID), props: props})
+		}
+	}
+	return sendMultistatus(c, responses, requested, namesOnly, "")
+}
+
+func (h *DavHandlerImpl) PropfindResource(c *fiber.Ctx) error {
+	userID, err := davUserID(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	requested, namesOnly, err := parsePropfind(c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	res, err := h.loadResource(c, userID)
+	if err != nil {
+		return sendDavNotFoundOr(c, err)
+	}
+	props, err := h.resourceProps(res, wantsProp(requested, propCalendarData))
+	if err != nil {
+		return appers.SanitizeError(c, err)
+	}
+	return sendMultistatus(c, []davResponse{{href: resourceHref(userID, res.master().ID), props: props}}, requested, namesOnly, "")
+}
+
+// Report обрабатывает calendar-query, calendar-multiget и sync-collection
+func (h *DavHandlerImpl) Report(c *fiber.Ctx) error {
+	userID, err := davUserID(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	var req reportRequest
+	if err = xml.Unmarshal(c.Body(), &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(fmt.Sprintf("invalid report body: %v", err))
+	}
+	requested := req.Prop.names()
+	withData := wantsProp(requested, propCalendarData)
+
+	switch {
+	case req.XMLName.Space == nsCalDAV && req.XMLName.Local == "calendar-query":
+		return h.calendarQuery(c, userID, &req, requested, withData)
+	case req.XMLName.Space == nsCalDAV && req.XMLName.Local == "calendar-multiget":
+		return h.calendarMultiget(c, userID, &req, requested, withData)
+	case req.XMLName.Space == nsDAV && req.XMLName.Local == "sync-collection":
+		return h.syncCollection(c, userID, &req, requested, withData)
+	default:
+		return sendDavError(c, fiber.StatusForbidden, xml.Name{Space: nsDAV, Local: "supported-report"})
+	}
+}
+
+func (h *DavHandlerImpl) calendarQuery(c *fiber.Ctx, userID string, req *reportRequest, requested []xml.Name, withData bool) error {
+	var start, end time.Time
+	if tr := req.Filter.eventTimeRange(); tr != nil {
+		var err error
+		if tr.Start != "" {
+			if start, err = (&ical.Property{Value: tr.Start}).DateTime(); err != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+			}
+		}
+		if tr.End != "" {
+			if end, err = (&ical.Property{Value: tr.End}).DateTime(); err != nil {
+				return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+			}
+		}
+	}
+
+	resources, err := h.loadResources(c, userID)
+	if err != nil {
+		return appers.SanitizeError(c, err)
+	}
+
+	responses := make([]davResponse, 0, len(resources))
+	for _, res := range resources {
+		if !start.IsZero() || !end.IsZero() {
+			ok, err := resourceOverlaps(res, start, end)
+			if err != nil {
+				h.logger.Errorf("[event: %s] time-range check failed: %v", res.master().ID, err)
+				continue
+			}
+			if !ok {
+				continue
+			}
+		}
+		props, err := h.resourceProps(res, withData)
+		if err != nil {
+			return appers.SanitizeError(c, err)
+		}
+		responses = append(responses, davResponse{href: resourceHref(userID, res.master().ID), props: props})
+	}
+	return sendMultistatus(c, responses, requested, false, "")
+}
+
+// resourceOverlaps проверяет пересечение ресурса с time-range (открытые границы допустимы)
+func resourceOverlaps(res davResource, start, end time.Time) (bool, error) {
+	if end.IsZero() {
+		end = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	master := res.master()
+	if !master.IsSeries() {
+		return master.DateEvent.Before(end) && master.EndDateEvent.After(start), nil
+	}
+	return recurrence.Overlaps(master, res.rows[1:], start, end)
+}
+
+func (h *DavHandlerImpl) calendarMultiget(c *fiber.Ctx, userID string, req *reportRequest, requested []xml.Name, withData bool) error {
+	responses := make([]davResponse, 0, len(req.Hrefs))
+	for _, href := range req.Hrefs {
+		name := href[strings.LastIndex(href, "/")+1:]
+		if !strings.HasPrefix(href, collectionHref(userID)) || name == "" {
+			responses = append(responses, davResponse{href: href, status: fiber.StatusNotFound})
+			continue
+		}
+
+		rows, err := h.usecase.GetEventResource(c.Context(), resourceID(name).String())
+		if err != nil || rows[0].UserID != userID || rows[0].SeriesID != nil {
+			responses = append(responses, davResponse{href: href, status: fiber.StatusNotFound})
+			continue
+		}
+		props, err := h.resourceProps(davResource{rows: rows}, withData)
+		if err != nil {
+			return appers.SanitizeError(c, err)
+		}
+		responses = append(responses, davResponse{href: href, props: props})
+	}
+	return sendMultistatus(c, responses, requested, false, "")
+}
+
+// syncCollection (RFC 6578). История изменений не хранится: пустой токен - полная синхронизация,
+// совпадающий токен - изменений нет, устаревший токен - valid-sync-token, клиент начинает заново.
+func (h *DavHandlerImpl) syncCollection(c *fiber.Ctx, userID string, req *reportRequest, requested []xml.Name, withData bool) error {
+	state, err := h.usecase.GetUserCollectionState(c.Context(), userID)
+	if err != nil {
+		return appers.SanitizeError(c, err)
+	}
+	token := syncToken(state)
+
+	switch req.SyncToken {
+	case token:
+		return sendMultistatus(c, nil, requested, false, token)
+	case "":
+	default:
+		return sendDavError(c, fiber.StatusForbidden, xml.Name{Space: nsDAV, Local: "valid-sync-token"})
+	}
+
+	resources, err := h.loadResources(c, userID)
+	if err != nil {
+		return appers.SanitizeError(c, err)
+	}
+	responses := make([]davResponse, 0, len(resources))
+	for _, res := range resources {
+		props, err := h.resourceProps(res, withData)
+		if err != nil {
+			return appers.SanitizeError(c, err)
+		}
+		responses = append(responses, davResponse{href: resourceHref(userID, res.master().ID), props: props})
+	}
+	return sendMultistatus(c, responses, requested, false, token)
+}
+
+func (h *DavHandlerImpl) GetResource(c *fiber.Ctx) error {
+	userID, err := davUserID(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	res, err := h.loadResource(c, userID)
+	if err != nil {
+		return sendDavNotFoundOr(c, err)
+	}
+
+	data, err := res.ics()
+	if err != nil {
+		return appers.SanitizeError(c, err)
+	}
+	c.Set(fiber.HeaderETag, res.etag())
+	c.Set(fiber.HeaderLastModified, res.lastModified().UTC().Format(http.TimeFormat))
+	c.Set(fiber.HeaderContentType, mimeCalendar+"; charset=utf-8")
+	return c.Status(fiber.StatusOK).SendString(data)
+}
+
+// PutResource создаёт или заменяет событие. Запись идёт через те же use case'ы, что и REST API:
+// создание - одна транзакция с event_created (и event_updated, если в теле есть вхождения с RECURRENCE-ID),
+// замена существующего события - одна транзакция ReplaceEvent с одним event_updated.
+func (h *DavHandlerImpl) PutResource(c *fiber.Ctx) error {
+	userID, err := davUserID(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	id := resourceID(c.Params("name"))
+
+	existing, err := h.loadResource(c, userID)
+	exists := err == nil
+	if err != nil && !errors.Is(err, appers.ErrEventNotFound) {
+		return appers.SanitizeError(c, err)
+	}
+	if _, err = h.usecase.GetEventResource(c.Context(), id.String()); !exists && err == nil {
+		// событие с таким ID есть у другого пользователя или это переопределение вхождения
+		return c.SendStatus(fiber.StatusForbidden)
+	}
+
+	if match := c.Get(fiber.HeaderIfMatch); match != "" && (!exists || (match != "*" && match != existing.etag())) {
+		return c.SendStatus(fiber.StatusPreconditionFailed)
+	}
+	if c.Get(fiber.HeaderIfNoneMatch) == "*" && exists {
+		return c.SendStatus(fiber.StatusPreconditionFailed)
+	}
+
+	event, occurrences, err := parseDavResource(c.Body(), id, userID)
+	if err != nil {
+		h.logger.Warnf("[event: %s] caldav put: %v", id, err)
+		return sendDavError(c, fiber.StatusForbidden, xml.Name{Space: nsCalDAV, Local: "valid-calendar-data"})
+	}
+
+	if exists {
+		// замена целиком: описание, уведомление и переопределения, которых нет в теле, удаляются
+		if err = h.usecase.ReplaceEvent(c.Context(), *event, occurrences); err != nil {
+			h.logger.Errorf("[event: %s] caldav put failed: %v", id, err)
+			return appers.SanitizeError(c, err)
+		}
+	} else {
+		if err = h.usecase.CreateEvent(c.Context(), *event, occurrences); err != nil {
+			h.logger.Errorf("[event: %s] caldav put failed: %v", id, err)
+			return appers.SanitizeError(c, err)
+		}
+	}
+
+	if res, err := h.loadResource(c, userID); err == nil {
+		c.Set(fiber.HeaderETag, res.etag())
+	}
+	if exists {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+func (h *DavHandlerImpl) DeleteResource(c *fiber.Ctx) error {
+	userID, err := davUserID(c)
+	if err != nil {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	res, err := h.loadResource(c, userID)
+	if err != nil {
+		return sendDavNotFoundOr(c, err)
+	}
+	if match := c.Get(fiber.HeaderIfMatch); match != "" && match != "*" && match != res.etag() {
+		return c.SendStatus(fiber.StatusPreconditionFailed)
+	}
+
+	if err = h.usecase.DeleteEvent(c.Context(), res.master().ID.String()); err != nil {
+		return sendDavNotFoundOr(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func sendDavNotFoundOr(c *fiber.Ctx, err error) error {
+	if errors.Is(err, appers.ErrEventNotFound) {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	return appers.SanitizeError(c, err)
+}
+
+// parseDavResource разбирает тело PUT: ровно одна серия/событие и переопределения её вхождений с тем же UID
+func parseDavResource(body []byte, id uuid.UUID, userID string) (*entity.Event, []*entity.EventOccurrence, error) {
+	cal, err := ical.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if cal.Name != ical.ComponentCalendar {
+		return nil, nil, fmt.Errorf("expected %s, got %s", ical.ComponentCalendar, cal.Name)
+	}
+
+	var (
+		event       *entity.Event
+		occurrences []*entity.EventOccurrence
+		uid         string
+	)
+	now := time.Now().UTC()
+	for _, v := range cal.ChildrenByName(ical.ComponentEvent) {
+		item, err := vEventToEvent(v, userID, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		if uid != "" && item.uid != uid {
+			return nil, nil, fmt.Errorf("resource contains different UIDs: %s, %s", uid, item.uid)
+		}
+		uid = item.uid
+
+		if item.occurrence != nil {
+			item.occurrence.SeriesID = id
+			if err = validator.Validate.Struct(item.occurrence); err != nil {
+				return nil, nil, err
+			}
+			if err = validateOccurrenceDates(item.occurrence); err != nil {
+				return nil, nil, err
+			}
+			occurrences = append(occurrences, item.occurrence)
+			continue
+		}
+		if event != nil {
+			return nil, nil, fmt.Errorf("resource contains more than one master VEVENT")
+		}
+
+		item.event.ID = id
+		// PUT заменяет ресурс целиком: отсутствующие RRULE/RDATE/EXDATE сбрасывают сохранённые
+		if item.event.RRule == nil {
+			item.event.RRule = new(string)
+		}
+		if item.event.RDate == nil {
+			item.event.RDate = []string{}
+		}
+		if item.event.ExDate == nil {
+			item.event.ExDate = []string{}
+		}
+		if err = validator.Validate.Struct(&item.event); err != nil {
+			return nil, nil, err
+		}
+		if err = validation.EventDates(&item.event); err != nil {
+			return nil, nil, err
+		}
+		if err = validation.Recurrence(&item.event); err != nil {
+			return nil, nil, err
+		}
+		event = &item.event
+	}
+
+	if event == nil {
+		return nil, nil, fmt.Errorf("resource has no master VEVENT")
+	}
+	return event, occurrences, nil
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+
+	mimeXML = "application/xml; charset=utf-8"
+)
+
+var davPrefixes = map[string]string{
+	nsDAV:    "d",
+	nsCalDAV: "c",
+	nsCS:     "cs",
+}
+
+// Свойства WebDAV/CalDAV, с которыми работает сервер
+var (
+	propResourceType         = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName          = xml.Name{Space: nsDAV, Local: "displayname"}
+	propGetETag              = xml.Name{Space: nsDAV, Local: "getetag"}
+	propGetContentType       = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propGetLastModified      = xml.Name{Space: nsDAV, Local: "getlastmodified"}
+	propCurrentUserPrincipal = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propPrincipalURL         = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	propOwner                = xml.Name{Space: nsDAV, Local: "owner"}
+	propSyncToken            = xml.Name{Space: nsDAV, Local: "sync-token"}
+	propSupportedReportSet   = xml.Name{Space: nsDAV, Local: "supported-report-set"}
+	propCurrentUserPrivilege = xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}
+	propCalendarHomeSet      = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propCalendarData         = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propSupportedComponents  = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propGetCTag              = xml.Name{Space: nsCS, Local: "getctag"}
+)
+
+// davProps значения свойств ресурса в виде готового XML-содержимого элемента
+type davProps map[xml.Name]string
+
+// davResponse элемент <d:response> ответа multistatus
+type davResponse struct {
+	href   string
+	props  davProps
+	status int // для ресурса целиком (например, 404 в calendar-multiget)
+}
+
+// xmlAny произвольный элемент, нужен только его XMLName
+type xmlAny struct {
+	XMLName xml.Name
+}
+
+type davPropNames struct {
+	Names []xmlAny `xml:",any"`
+}
+
+func (p *davPropNames) names() []xml.Name {
+	if p == nil {
+		return nil
+	}
+	res := make([]xml.Name, 0, len(p.Names))
+	for _, n := range p.Names {
+		res = append(res, n.XMLName)
+	}
+	return res
+}
+
+type propfindRequest struct {
+	XMLName  xml.Name      `xml:"DAV: propfind"`
+	AllProp  *struct{}     `xml:"DAV: allprop"`
+	PropName *struct{}     `xml:"DAV: propname"`
+	Prop     *davPropNames `xml:"DAV: prop"`
+}
+
+// reportRequest общая форма calendar-query, calendar-multiget и sync-collection
+type reportRequest struct {
+	XMLName   xml.Name
+	Prop      *davPropNames `xml:"DAV: prop"`
+	Hrefs     []string      `xml:"DAV: href"`
+	Filter    *calFilter    `xml:"urn:ietf:params:xml:ns:caldav filter"`
+	SyncToken string        `xml:"DAV: sync-token"`
+}
+
+type calFilter struct {
+	CompFilter compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type compFilter struct {
+	Name        string       `xml:"name,attr"`
+	TimeRange   *timeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	CompFilters []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type timeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+// eventTimeRange ищет time-range фильтра VCALENDAR/VEVENT
+func (f *calFilter) eventTimeRange() *timeRange {
+	if f == nil {
+		return nil
+	}
+	for _, cf := range f.CompFilter.CompFilters {
+		if strings.EqualFold(cf.Name, "VEVENT") {
+			return cf.TimeRange
+		}
+	}
+	return nil
+}
+
+// parsePropfind разбирает тело PROPFIND; пустое тело означает allprop.
+// Возвращает nil, если запрошены все свойства.
+func parsePropfind(body []byte) ([]xml.Name, bool, error) {
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil, false, nil
+	}
+	var req propfindRequest
+	if err := xml.Unmarshal(body, &req); err != nil {
+		return nil, false, fmt.Errorf("invalid propfind body: %w", err)
+	}
+	if req.PropName != nil {
+		return nil, true, nil
+	}
+	return req.Prop.names(), false, nil
+}
+
+func xmlEscape(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+func hrefElement(href string) string {
+	return "<d:href>" + xmlEscape(href) + "</d:href>"
+}
+
+// writeElement пишет элемент с префиксом известного пространства имён или с собственным xmlns
+func writeElement(sb *strings.Builder, name xml.Name, inner string) {
+	prefix, ok := davPrefixes[name.Space]
+	open := prefix + ":" + name.Local
+	attr := ""
+	if !ok {
+		open = "x:" + name.Local
+		attr = ` xmlns:x="` + xmlEscape(name.Space) + `"`
+	}
+	if inner == "" {
+		sb.WriteString("<" + open + attr + "/>")
+		return
+	}
+	sb.WriteString("<" + open + attr + ">" + inner + "</" + open + ">")
+}
+
+func writePropstat(sb *strings.Builder, names []xml.Name, props davProps, status int, namesOnly bool) {
+	if len(names) == 0 {
+		return
+	}
+	sb.WriteString("<d:propstat><d:prop>")
+	for _, n := range names {
+		inner := ""
+		if !namesOnly {
+			inner = props[n]
+		}
+		writeElement(sb, n, inner)
+	}
+	sb.WriteString("</d:prop>")
+	sb.WriteString(fmt.Sprintf("<d:status>HTTP/1.1 %d %s</d:status>", status, http.StatusText(status)))
+	sb.WriteString("</d:propstat>")
+}
+
+// sendMultistatus отдаёт 207 Multi-Status.
+// requested == nil - все свойства (кроме calendar-data), namesOnly - ответ на propname.
+func sendMultistatus(c *fiber.Ctx, responses []davResponse, requested []xml.Name, namesOnly bool, syncToken string) error {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="` + nsCalDAV + `" xmlns:cs="` + nsCS + `">`)
+
+	for _, r := range responses {
+		sb.WriteString("<d:response>")
+		sb.WriteString(hrefElement(r.href))
+		if r.status != 0 {
+			sb.WriteString(fmt.Sprintf("<d:status>HTTP/1.1 %d %s</d:status>", r.status, http.StatusText(r.status)))
+			sb.WriteString("</d:response>")
+			continue
+		}
+
+		var found, missing []xml.Name
+		if requested == nil {
+			for n := range r.props {
+				if n != propCalendarData {
+					found = append(found, n)
+				}
+			}
+			sort.Slice(found, func(i, j int) bool {
+				return found[i].Space+found[i].Local < found[j].Space+found[j].Local
+			})
+		} else {
+			for _, n := range requested {
+				if _, ok := r.props[n]; ok {
+					found = append(found, n)
+				} else {
+					missing = append(missing, n)
+				}
+			}
+		}
+		writePropstat(&sb, found, r.props, http.StatusOK, namesOnly)
+		writePropstat(&sb, missing, nil, http.StatusNotFound, true)
+		sb.WriteString("</d:response>")
+	}
+
+	if syncToken != "" {
+		sb.WriteString("<d:sync-token>" + xmlEscape(syncToken) + "</d:sync-token>")
+	}
+	sb.WriteString("</d:multistatus>")
+
+	c.Set(fiber.HeaderContentType, mimeXML)
+	return c.Status(fiber.StatusMultiStatus).SendString(sb.String())
+}
+
+// sendDavError отдаёт ошибку с элементом предусловия (RFC 4918, 16)
+func sendDavError(c *fiber.Ctx, status int, condition xml.Name) error {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<d:error xmlns:d="DAV:" xmlns:c="` + nsCalDAV + `">`)
+	writeElement(&sb, condition, "")
+	sb.WriteString("</d:error>")
+
+	c.Set(fiber.HeaderContentType, mimeXML)
+	return c.Status(status).SendString(sb.String())
+}
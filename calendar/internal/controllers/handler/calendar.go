@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"calendar/pkg/validator"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
+)
+
+// parseCalendarIDs разбирает query-параметр calendarID: ID через запятую и/или повтором параметра
+func parseCalendarIDs(c *fiber.Ctx) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, raw := range c.Context().QueryArgs().PeekMulti("calendarID") {
+		for _, s := range strings.Split(string(raw), ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			id, err := uuid.FromString(s)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// CreateCalendar godoc
+// @Summary     Создание календаря
+// @Description Создаёт календарь пользователя. isDefault: true делает его основным вместо прежнего
+// @Accept      json
+// @Produce     json
+// @Param       body  body     entity.CalendarCreate  true  "Календарь"
+// @Success     201   {object} entity.Calendar
+// @Failure     400
+// @Failure     500
+// @tags        Calendar
+// @Router      /v1/calendar [post]
+func (h *HandlerImpl) CreateCalendar(c *fiber.Ctx) error {
+	var in entity.CalendarCreate
+	if err := c.BodyParser(&in); err != nil {
+		h.logger.Errorf("error parsing body: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err := validator.Validate.Struct(&in); err != nil {
+		h.logger.Warnf("validation error: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(formatValidationErrors(err))
+	}
+
+	cal, err := h.usecase.CreateCalendar(c.Context(), in)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(cal)
+}
+
+// ListCalendars godoc
+// @Summary     Календари пользователя
+// @Description Возвращает календари пользователя, основной - первым
+// @Produce     json
+// @Param       userID  query    string  true  "ID пользователя"
+// @Success     200     {array}  entity.Calendar
+// @Failure     400
+// @Failure     500
+// @tags        Calendar
+// @Router      /v1/calendar [get]
+func (h *HandlerImpl) ListCalendars(c *fiber.Ctx) error {
+	userID := c.Query("userID")
+	if userID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID is required",
+		})
+	}
+
+	calendars, err := h.usecase.ListCalendars(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(calendars)
+}
+
+// GetCalendar godoc
+// @Summary     Календарь
+// @Description Возвращает календарь по идентификатору
+// @Produce     json
+// @Param       id   path     string  true  "ID календаря"
+// @Success     200  {object} entity.Calendar
+// @Failure     400
+// @Failure     404
+// @Failure     500
+// @tags        Calendar
+// @Router      /v1/calendar/{id} [get]
+func (h *HandlerImpl) GetCalendar(c *fiber.Ctx) error {
+	id, err := uuid.FromString(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id",
+		})
+	}
+
+	cal, err := h.usecase.GetCalendar(c.Context(), id)
+	switch {
+	case errors.Is(err, appers.ErrCalendarNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(cal)
+}
+
+// UpdateCalendar godoc
+// @Summary     Изменение календаря
+// @Description Меняет переданные поля календаря; пустые color и timeZone сбрасывают значение.
+// @Description isDefault: true делает календарь основным вместо прежнего
+// @Accept      json
+// @Produce     json
+// @Param       id    path     string                true  "ID календаря"
+// @Param       body  body     entity.CalendarPatch  true  "Изменения"
+// @Success     200   {object} entity.Calendar
+// @Failure     400
+// @Failure     404
+// @Failure     500
+// @tags        Calendar
+// @Router      /v1/calendar/{id} [patch]
+func (h *HandlerImpl) UpdateCalendar(c *fiber.Ctx) error {
+	id, err := uuid.FromString(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id",
+		})
+	}
+
+	var patch entity.CalendarPatch
+	if err = c.BodyParser(&patch); err != nil {
+		h.logger.Errorf("error parsing body: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	if err = validator.Validate.Struct(&patch); err != nil {
+		h.logger.Warnf("validation error: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(formatValidationErrors(err))
+	}
+
+	cal, err := h.usecase.UpdateCalendar(c.Context(), id, patch)
+	switch {
+	case errors.Is(err, appers.ErrCalendarNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(cal)
+}
+
+// DeleteCalendar godoc
+// @Summary     Удаление календаря
+// @Description Удаляет календарь без событий. Основной календарь и календарь с событиями удалить нельзя - 409
+// @Produce     json
+// @Param       id   path     string  true  "ID календаря"
+// @Success     200
+// @Failure     400
+// @Failure     404
+// @Failure     409
+// @Failure     500
+// @tags        Calendar
+// @Router      /v1/calendar/{id} [delete]
+func (h *HandlerImpl) DeleteCalendar(c *fiber.Ctx) error {
+	id, err := uuid.FromString(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id",
+		})
+	}
+
+	err = h.usecase.DeleteCalendar(c.Context(), id)
+	switch {
+	case errors.Is(err, appers.ErrCalendarNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case errors.Is(err, appers.ErrDefaultCalendar), errors.Is(err, appers.ErrCalendarNotEmpty):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"description": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"description": "ok"})
+}
@@ -5,6 +5,7 @@ import (
 	"calendar/internal/application/common"
 	"calendar/internal/application/entity"
 	use_cases "calendar/internal/application/use-cases"
+	"calendar/internal/application/validation"
 	"calendar/pkg/validator"
 	"context"
 	"errors"
@@ -13,8 +14,8 @@ import (
 	"strings"
 	"time"
 
-	playgroundvalidator "github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
 	"go.uber.org/zap"
 )
 
@@ -23,6 +24,19 @@ type Handler interface {
 	GetEventsByPeriod(c *fiber.Ctx) error
 	UpdateEvent(c *fiber.Ctx) error
 	DeleteEvent(c *fiber.Ctx) error
+	UpsertOccurrence(c *fiber.Ctx) error
+	CancelOccurrence(c *fiber.Ctx) error
+	ImportEvents(c *fiber.Ctx) error
+	GetNotificationSettings(c *fiber.Ctx) error
+	UpdateNotificationSettings(c *fiber.Ctx) error
+	ListAttendees(c *fiber.Ctx) error
+	InviteAttendees(c *fiber.Ctx) error
+	RespondAttendee(c *fiber.Ctx) error
+	CreateCalendar(c *fiber.Ctx) error
+	ListCalendars(c *fiber.Ctx) error
+	GetCalendar(c *fiber.Ctx) error
+	UpdateCalendar(c *fiber.Ctx) error
+	DeleteCalendar(c *fiber.Ctx) error
 	HealthCheck(c *fiber.Ctx) error
 }
 type HandlerImpl struct {
@@ -39,63 +53,32 @@ func NewEventHandler(usecase use_cases.UseCaser, logger *zap.SugaredLogger) *Han
 
 // formatValidationErrors форматирует ошибки валидации в понятный формат для клиента
 func formatValidationErrors(err error) fiber.Map {
-	var errors []string
-	if validationErrors, ok := err.(playgroundvalidator.ValidationErrors); ok {
-		for _, e := range validationErrors {
-			field := e.Field()
-			tag := e.Tag()
-			var message string
-			switch tag {
-			case "required":
-				message = fmt.Sprintf("поле '%s' обязательно для заполнения", field)
-			case "min":
-				message = fmt.Sprintf("поле '%s' должно содержать минимум %s символов", field, e.Param())
-			case "max":
-				message = fmt.Sprintf("поле '%s' должно содержать максимум %s символов", field, e.Param())
-			case "rfc3339", "rfc3339_optional":
-				message = fmt.Sprintf("поле '%s' должно быть в формате RFC3339 (например, 2026-01-20T15:00:00Z)", field)
-			default:
-				message = fmt.Sprintf("поле '%s' не прошло валидацию: %s", field, tag)
-			}
-			errors = append(errors, message)
-		}
-	} else {
-		errors = append(errors, err.Error())
-	}
 	return fiber.Map{
 		"error":   "validation failed",
-		"details": errors,
+		"details": validator.Messages(err),
 	}
 }
 
-// validateEventDates выполняет логическую валидацию дат события
-func validateEventDates(event *entity.Event) error {
-	dateEvent, err := time.Parse(time.RFC3339, event.DateEvent)
+// validateOccurrenceDates выполняет логическую валидацию дат переопределения вхождения
+func validateOccurrenceDates(occurrence *entity.EventOccurrence) error {
+	if occurrence.DateEvent == "" || occurrence.EndDateEvent == "" {
+		return nil
+	}
+
+	dateEvent, err := time.Parse(time.RFC3339, occurrence.DateEvent)
 	if err != nil {
 		return fmt.Errorf("неверный формат dateEvent: %w", err)
 	}
 
-	endDateEvent, err := time.Parse(time.RFC3339, event.EndDateEvent)
+	endDateEvent, err := time.Parse(time.RFC3339, occurrence.EndDateEvent)
 	if err != nil {
 		return fmt.Errorf("неверный формат durationEvent: %w", err)
 	}
 
-	// Проверяем, что дата окончания после даты начала
 	if !endDateEvent.After(dateEvent) {
 		return fmt.Errorf("дата окончания события должна быть после даты начала")
 	}
 
-	// Если указано время уведомления, проверяем что оно до начала события
-	if event.TimeForNotification != "" {
-		notificationTime, err := time.Parse(time.RFC3339, event.TimeForNotification)
-		if err != nil {
-			return fmt.Errorf("неверный формат timeForNotification: %w", err)
-		}
-		if !notificationTime.Before(dateEvent) {
-			return fmt.Errorf("время уведомления должно быть до начала события")
-		}
-	}
-
 	return nil
 }
 
@@ -147,13 +130,15 @@ func (h *HandlerImpl) HealthCheck(c *fiber.Ctx) error {
 
 // CreateEvent godoc
 // @Summary     Создание события
-// @Description Создает новое событие и записывает его в БД
+// @Description Создает новое событие и записывает его в БД. Без calendarID событие попадает в основной календарь владельца
 // @Accept      json
 // @Produce     json
 // @Param       body  body     entity.Event  true  "Данные события"
 // @Success     200
 // @Failure     400
+// @Failure     404
 // @Failure     409
+// @Failure     422
 // @Failure     500
 // @tags        Event
 // @Router      /v1/event [post]
@@ -174,17 +159,27 @@ func (h *HandlerImpl) CreateEvent(c *fiber.Ctx) error {
 	}
 
 	// Логическая валидация дат
-	if err = validateEventDates(&event); err != nil {
+	if err = validation.EventDates(&event); err != nil {
 		h.logger.Warnf("date validation error: %v", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
+	if err = validation.Recurrence(&event); err != nil {
+		h.logger.Warnf("rrule validation error: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-	err = h.usecase.CreateEvent(c.Context(), event)
+	err = h.usecase.CreateEvent(c.Context(), event, nil)
 	switch {
 	case errors.Is(err, appers.ErrEventAlreadyExists):
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"description": err.Error()})
+	case errors.Is(err, appers.ErrCalendarNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case errors.Is(err, appers.ErrCalendarOwner):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"description": err.Error()})
 	case err != nil:
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
 	}
@@ -194,16 +189,22 @@ func (h *HandlerImpl) CreateEvent(c *fiber.Ctx) error {
 
 // GetEventsByPeriod godoc
 // @Summary     Получение событий за период
-// @Description Возвращает список событий за период, заданный query-параметрами start и end
+// @Description Возвращает список событий за период, заданный query-параметрами start и end. Повторяющиеся события разворачиваются во вхождения периода.
+// @Description С userID - только события пользователя и события, в которых он участник; с calendarID - только события этих календарей
+// @Description Формат ответа выбирается по заголовку Accept (application/json или text/calendar); /v1/event.ics всегда отдаёт iCalendar
 // @Produce     json
+// @Produce     text/calendar
 // @Param       start  query    string true "Дата/время начала периода (например, 2026-01-01T00:00:00Z)"
 // @Param       end    query    string true "Дата/время конца периода (например, 2026-01-31T23:59:59Z)"
-// @Success     200    {array}  entity.Event
+// @Param       userID     query    string false "ID пользователя: его события и события, где он участник"
+// @Param       calendarID query    []string false "ID календарей через запятую или повтором параметра" collectionFormat(multi)
+// @Success     200    {array}  entity.EventResponse
 // @Failure     400
 // @Failure     409
 // @Failure     500
 // @tags        Event
 // @Router      /v1/event [get]
+// @Router      /v1/event.ics [get]
 func (h *HandlerImpl) GetEventsByPeriod(c *fiber.Ctx) error {
 	startStr := c.Query("start")
 	endStr := c.Query("end")
@@ -248,10 +249,21 @@ func (h *HandlerImpl) GetEventsByPeriod(c *fiber.Ctx) error {
 	start = start.UTC()
 	end = end.UTC()
 
-	events, err := h.usecase.GetEvent(c.Context(), start, end)
+	calendarIDs, err := parseCalendarIDs(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid calendarID",
+		})
+	}
+
+	filter := entity.EventFilter{UserID: c.Query("userID"), CalendarIDs: calendarIDs}
+	events, err := h.usecase.GetEvent(c.Context(), filter, start, end)
 	if err != nil {
 		return appers.SanitizeError(c, err)
 	}
+	if wantsICS(c) {
+		return sendICS(c, events)
+	}
 	return c.Status(fiber.StatusOK).JSON(events)
 }
 
@@ -264,6 +276,7 @@ func (h *HandlerImpl) GetEventsByPeriod(c *fiber.Ctx) error {
 // @Success     200
 // @Failure     400
 // @Failure     404
+// @Failure     422
 // @Failure     500
 // @tags        Event
 // @Router      /v1/event [patch]
@@ -284,17 +297,26 @@ func (h *HandlerImpl) UpdateEvent(c *fiber.Ctx) error {
 	}
 
 	// Логическая валидация дат
-	if err = validateEventDates(&event); err != nil {
+	if err = validation.EventDates(&event); err != nil {
 		h.logger.Warnf("date validation error: %v", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
+	if err = validation.Recurrence(&event); err != nil {
+		h.logger.Warnf("rrule validation error: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
 	err = h.usecase.UpdateEvent(c.Context(), event)
 	switch {
-	case errors.Is(err, appers.ErrEventNotFound):
+	case errors.Is(err, appers.ErrEventNotFound), errors.Is(err, appers.ErrCalendarNotFound):
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case errors.Is(err, appers.ErrCalendarOwner), errors.Is(err, appers.ErrOverrideSeriesFields),
+		errors.Is(err, appers.ErrOverrideCalendar):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"description": err.Error()})
 	case err != nil:
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
 	}
@@ -324,3 +346,92 @@ func (h *HandlerImpl) DeleteEvent(c *fiber.Ctx) error {
 	}
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"description": "ok"})
 }
+
+// UpsertOccurrence godoc
+// @Summary     Переопределение вхождения серии
+// @Description Изменяет одно вхождение повторяющегося события. Изменение хранится как переопределение, серия не меняется
+// @Accept      json
+// @Produce     json
+// @Param       id    path     string                  true  "ID серии"
+// @Param       body  body     entity.EventOccurrence  true  "Данные вхождения"
+// @Success     200
+// @Failure     400
+// @Failure     404
+// @Failure     500
+// @tags        Event
+// @Router      /v1/event/{id}/occurrence [put]
+func (h *HandlerImpl) UpsertOccurrence(c *fiber.Ctx) error {
+	seriesID, err := uuid.FromString(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id",
+		})
+	}
+
+	var occurrence entity.EventOccurrence
+	if err = c.BodyParser(&occurrence); err != nil {
+		h.logger.Errorf("error parsing body: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	occurrence.SeriesID = seriesID
+
+	if err = validator.Validate.Struct(&occurrence); err != nil {
+		h.logger.Warnf("validation error: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(formatValidationErrors(err))
+	}
+
+	if err = validateOccurrenceDates(&occurrence); err != nil {
+		h.logger.Warnf("date validation error: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	_, err = h.usecase.UpsertOccurrence(c.Context(), occurrence)
+	switch {
+	case errors.Is(err, appers.ErrEventNotFound), errors.Is(err, appers.ErrOccurrenceNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"description": "ok"})
+}
+
+// CancelOccurrence godoc
+// @Summary     Отмена вхождения серии
+// @Description Отменяет одно вхождение повторяющегося события, остальные вхождения серии не меняются
+// @Produce     json
+// @Param       id            path     string  true  "ID серии"
+// @Param       recurrenceID  query    string  true  "Исходное время начала вхождения (RFC3339)"
+// @Success     200
+// @Failure     400
+// @Failure     404
+// @Failure     500
+// @tags        Event
+// @Router      /v1/event/{id}/occurrence [delete]
+func (h *HandlerImpl) CancelOccurrence(c *fiber.Ctx) error {
+	seriesID, err := uuid.FromString(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id",
+		})
+	}
+
+	recurrenceID := strings.Trim(c.Query("recurrenceID"), `"`)
+	if _, err = time.Parse(time.RFC3339, recurrenceID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid recurrenceID format, expected RFC3339 (e.g., 2026-01-20T11:00:00Z)",
+		})
+	}
+
+	err = h.usecase.CancelOccurrence(c.Context(), seriesID, recurrenceID)
+	switch {
+	case errors.Is(err, appers.ErrEventNotFound), errors.Is(err, appers.ErrOccurrenceNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"description": "ok"})
+}
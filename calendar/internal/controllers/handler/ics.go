@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"calendar/internal/application/common"
+	"calendar/internal/application/entity"
+	"calendar/internal/application/recurrence"
+	"calendar/pkg/ical"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	mimeCalendar  = "text/calendar"
+	icsSuffix     = ".ics"
+	icsFileName   = "calendar.ics"
+	icsProdID     = "-//calendar//Calendar Service " + common.Version + "//RU"
+	alarmFallback = "Напоминание"
+)
+
+// wantsICS определяет формат ответа: суффикс .ics в пути или text/calendar в Accept
+func wantsICS(c *fiber.Ctx) bool {
+	if strings.HasSuffix(c.Path(), icsSuffix) {
+		return true
+	}
+	return c.Accepts(fiber.MIMEApplicationJSON, mimeCalendar) == mimeCalendar
+}
+
+// sendICS отдаёт события документом VCALENDAR
+func sendICS(c *fiber.Ctx, events []*entity.EventResponse) error {
+	body, err := ical.Marshal(eventsToCalendar(events, time.Now()))
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, mimeCalendar+"; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+icsFileName+`"`)
+	return c.Status(fiber.StatusOK).SendString(body)
+}
+
+// eventsToCalendar собирает VCALENDAR событий периода. Серии в выгрузке уже развёрнуты, а вхождение
+// с RECURRENCE-ID без VEVENT серии клиенты отбрасывают, поэтому вхождения отдаются самостоятельными
+// событиями с UID вхождения (см. entity.OccurrenceID)
+func eventsToCalendar(events []*entity.EventResponse, stamp time.Time) *ical.Component {
+	cal := ical.NewCalendar(icsProdID)
+	for _, e := range events {
+		if e.SeriesID != nil && e.RecurrenceID != nil {
+			e = detachOccurrence(e)
+		}
+		cal.AddChild(eventToVEvent(e, stamp))
+	}
+	return cal
+}
+
+// detachOccurrence копия вхождения серии как одиночного события: без ссылки на серию и правила повторения
+func detachOccurrence(e *entity.EventResponse) *entity.EventResponse {
+	occ := *e
+	occ.ID = entity.OccurrenceID(*e.SeriesID, *e.RecurrenceID)
+	occ.SeriesID = nil
+	occ.RecurrenceID = nil
+	occ.RRule = ""
+	occ.RDate = nil
+	occ.ExDate = nil
+	return &occ
+}
+
+// resourceToCalendar собирает VCALENDAR одного события: серия и переопределения её вхождений.
+// Отменённые вхождения отдаются как EXDATE серии.
+func resourceToCalendar(rows []*entity.EventResponse, stamp time.Time) *ical.Component {
+	cal := ical.NewCalendar(icsProdID)
+	if len(rows) == 0 {
+		return cal
+	}
+
+	master := *rows[0]
+	master.ExDate = append([]time.Time(nil), rows[0].ExDate...)
+	overrides := make([]*entity.EventResponse, 0, len(rows)-1)
+	for _, o := range rows[1:] {
+		if o.Cancelled && o.RecurrenceID != nil {
+			master.ExDate = append(master.ExDate, *o.RecurrenceID)
+			continue
+		}
+		overrides = append(overrides, o)
+	}
+
+	cal.AddChild(eventToVEvent(&master, stamp))
+	for _, o := range overrides {
+		cal.AddChild(eventToVEvent(o, stamp))
+	}
+	return cal
+}
+
+// eventToVEvent маппинг события на VEVENT.
+// UID = ID события; переопределения вхождений рядом с серией (ресурс CalDAV) получают UID серии и RECURRENCE-ID.
+func eventToVEvent(e *entity.EventResponse, stamp time.Time) *ical.Component {
+	vevent := ical.NewComponent(ical.ComponentEvent)
+	// время серии отдаётся в её часовом поясе (DTSTART;TZID=...), иначе клиент развернёт RRULE по UTC
+	loc, err := recurrence.Location(e.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	uid := e.ID
+	if e.SeriesID != nil {
+		uid = *e.SeriesID
+	}
+	vevent.AddText("UID", uid.String())
+	vevent.AddDateTime("DTSTAMP", stamp)
+	if !e.CreationDate.IsZero() {
+		vevent.AddDateTime("CREATED", e.CreationDate)
+	}
+	vevent.AddDateTimeIn("DTSTART", e.DateEvent, loc)
+	vevent.AddDateTimeIn("DTEND", e.EndDateEvent, loc)
+	if e.RecurrenceID != nil {
+		vevent.AddDateTimeIn("RECURRENCE-ID", *e.RecurrenceID, loc)
+	}
+	vevent.AddText("SUMMARY", e.Title)
+	if e.DescriptionEvent != "" {
+		vevent.AddText("DESCRIPTION", e.DescriptionEvent)
+	}
+	if e.Cancelled {
+		vevent.Add("STATUS", "CANCELLED")
+	}
+	// правило повторения отдаём только для самой серии, не для её вхождений
+	if e.RecurrenceID == nil && e.IsSeries() {
+		if e.RRule != "" {
+			vevent.Add("RRULE", strings.TrimPrefix(e.RRule, "RRULE:"))
+		}
+		for _, d := range e.RDate {
+			vevent.AddDateTimeIn("RDATE", d, loc)
+		}
+		for _, d := range e.ExDate {
+			vevent.AddDateTimeIn("EXDATE", d, loc)
+		}
+	}
+
+	if !e.TimeForNotification.IsZero() {
+		vevent.AddChild(notificationToVAlarm(e, e.TimeForNotification.Sub(e.DateEvent)))
+	}
+	// напоминания с offset; timeForNotification среди напоминаний - с абсолютным временем
+	for _, r := range e.Reminders {
+		if r.Offset == "" {
+			continue
+		}
+		if offset, err := time.ParseDuration(r.Offset); err == nil {
+			vevent.AddChild(notificationToVAlarm(e, -offset))
+		}
+	}
+
+	return vevent
+}
+
+// notificationToVAlarm строит VALARM с триггером относительно начала события
+func notificationToVAlarm(e *entity.EventResponse, trigger time.Duration) *ical.Component {
+	valarm := ical.NewComponent(ical.ComponentAlarm)
+	valarm.Add("ACTION", "DISPLAY")
+
+	description := e.Title
+	if description == "" {
+		description = alarmFallback
+	}
+	valarm.AddText("DESCRIPTION", description)
+	valarm.Add("TRIGGER", ical.FormatDuration(trigger))
+
+	return valarm
+}
+
+// importedEvent результат маппинга VEVENT: событие или переопределение вхождения серии
+type importedEvent struct {
+	uid          string
+	recurrenceID string
+	event        entity.Event
+	occurrence   *entity.EventOccurrence
+}
+
+// uidToEventID использует UID как ID события, если это UUID, иначе выводит детерминированный UUIDv5,
+// чтобы повторный импорт того же файла распознавался как дубликат
+func uidToEventID(uid string) uuid.UUID {
+	if id, err := uuid.FromString(uid); err == nil {
+		return id
+	}
+	return uuid.NewV5(uuid.NamespaceURL, "ics:"+uid)
+}
+
+// vEventToEvent маппинг VEVENT на entity.Event (или entity.EventOccurrence для RECURRENCE-ID)
+func vEventToEvent(v *ical.Component, userID string, now time.Time) (importedEvent, error) {
+	res := importedEvent{uid: v.Text("UID")}
+	if res.uid == "" {
+		return res, fmt.Errorf("отсутствует UID")
+	}
+
+	dtstart := v.Prop("DTSTART")
+	if dtstart == nil {
+		return res, fmt.Errorf("отсутствует DTSTART")
+	}
+	start, err := dtstart.DateTime()
+	if err != nil {
+		return res, err
+	}
+
+	var end time.Time
+	switch {
+	case v.Prop("DTEND") != nil:
+		if end, err = v.Prop("DTEND").DateTime(); err != nil {
+			return res, err
+		}
+	case v.Prop("DURATION") != nil:
+		d, err := ical.ParseDuration(v.Prop("DURATION").Value)
+		if err != nil {
+			return res, err
+		}
+		end = start.Add(d)
+	case dtstart.IsDate():
+		// событие на весь день без DTEND длится один день (RFC 5545, 3.6.1)
+		end = start.AddDate(0, 0, 1)
+	default:
+		end = start
+	}
+
+	var notification string
+	if alarm, err := alarmTime(v, start, end); err != nil {
+		return res, err
+	} else if !alarm.IsZero() {
+		notification = alarm.Format(time.RFC3339)
+	}
+
+	id := uidToEventID(res.uid)
+
+	if rid := v.Prop("RECURRENCE-ID"); rid != nil {
+		recurrenceID, err := rid.DateTime()
+		if err != nil {
+			return res, err
+		}
+		res.recurrenceID = recurrenceID.Format(time.RFC3339)
+		res.occurrence = &entity.EventOccurrence{
+			SeriesID:            id,
+			RecurrenceID:        res.recurrenceID,
+			Title:               v.Text("SUMMARY"),
+			DateEvent:           start.Format(time.RFC3339),
+			EndDateEvent:        end.Format(time.RFC3339),
+			DescriptionEvent:    v.Text("DESCRIPTION"),
+			TimeForNotification: notification,
+			Cancelled:           strings.EqualFold(v.Text("STATUS"), "CANCELLED"),
+		}
+		return res, nil
+	}
+
+	created := now
+	for _, name := range []string{"CREATED", "DTSTAMP"} {
+		if p := v.Prop(name); p != nil {
+			if t, err := p.DateTime(); err == nil {
+				created = t
+				break
+			}
+		}
+	}
+
+	res.event = entity.Event{
+		ID:                  id,
+		Title:               v.Text("SUMMARY"),
+		DateEvent:           start.Format(time.RFC3339),
+		CreationDate:        created.Format(time.RFC3339),
+		EndDateEvent:        end.Format(time.RFC3339),
+		DescriptionEvent:    v.Text("DESCRIPTION"),
+		UserID:              userID,
+		TimeForNotification: notification,
+		RqTm:                now.Format(time.RFC3339),
+		TimeZone:            dtstart.TimeZone(),
+	}
+
+	if p := v.Prop("RRULE"); p != nil {
+		rule := p.Value
+		res.event.RRule = &rule
+	}
+	for name, dst := range map[string]*[]string{"RDATE": &res.event.RDate, "EXDATE": &res.event.ExDate} {
+		for _, p := range v.PropsByName(name) {
+			times, err := p.DateTimes()
+			if err != nil {
+				return res, err
+			}
+			for _, t := range times {
+				*dst = append(*dst, t.Format(time.RFC3339))
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// alarmTime вычисляет время уведомления по первому VALARM с поддерживаемым TRIGGER
+func alarmTime(v *ical.Component, start, end time.Time) (time.Time, error) {
+	for _, alarm := range v.ChildrenByName(ical.ComponentAlarm) {
+		trigger := alarm.Prop("TRIGGER")
+		if trigger == nil {
+			continue
+		}
+		if strings.EqualFold(trigger.Param("VALUE"), "DATE-TIME") {
+			return trigger.DateTime()
+		}
+		d, err := ical.ParseDuration(trigger.Value)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if strings.EqualFold(trigger.Param("RELATED"), "END") {
+			return end.Add(d), nil
+		}
+		return start.Add(d), nil
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"calendar/internal/application/entity"
+	"calendar/pkg/ical"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+var (
+	testSeriesID = uuid.Must(uuid.FromString("7c9e6679-7425-40de-944b-e07fc1f90ae7"))
+	testStamp    = time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+)
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func testSeries() *entity.EventResponse {
+	return &entity.EventResponse{
+		ID:                  testSeriesID,
+		Title:               "Стендап; команда, бэкенд",
+		DateEvent:           utc("2026-01-19T09:00:00Z"),
+		CreationDate:        utc("2026-01-15T12:00:00Z"),
+		EndDateEvent:        utc("2026-01-19T09:15:00Z"),
+		DescriptionEvent:    "Повестка:\n1. вчера\n2. сегодня",
+		UserID:              "user123",
+		TimeForNotification: utc("2026-01-19T08:50:00Z"),
+		RRule:               "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+		ExDate:              []time.Time{utc("2026-01-21T09:00:00Z")},
+	}
+}
+
+// testOccurrence развёрнутое вхождение серии: ID серии, как в recurrence.Expand
+func testOccurrence(start string) *entity.EventResponse {
+	occ := *testSeries()
+	seriesID := testSeriesID
+	recurrenceID := utc(start)
+	occ.DateEvent = recurrenceID
+	occ.EndDateEvent = recurrenceID.Add(15 * time.Minute)
+	occ.SeriesID = &seriesID
+	occ.RecurrenceID = &recurrenceID
+	return &occ
+}
+
+func roundTrip(t *testing.T, cal *ical.Component) []*ical.Component {
+	t.Helper()
+	body, err := ical.Marshal(cal)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	back, err := ical.Unmarshal(body)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	return back.ChildrenByName(ical.ComponentEvent)
+}
+
+func TestEventsToCalendarDetachesOccurrences(t *testing.T) {
+	single := &entity.EventResponse{
+		ID:           uuid.Must(uuid.FromString("550e8400-e29b-41d4-a716-446655440000")),
+		Title:        "Встреча",
+		DateEvent:    utc("2026-01-20T15:00:00Z"),
+		EndDateEvent: utc("2026-01-20T16:00:00Z"),
+	}
+	first := testOccurrence("2026-01-19T09:00:00Z")
+	second := testOccurrence("2026-01-23T09:00:00Z")
+
+	vevents := roundTrip(t, eventsToCalendar([]*entity.EventResponse{first, single, second}, testStamp))
+	if len(vevents) != 3 {
+		t.Fatalf("got %d VEVENT, want 3", len(vevents))
+	}
+
+	uids := make(map[string]struct{})
+	for _, v := range vevents {
+		uids[v.Text("UID")] = struct{}{}
+		for _, name := range []string{"RECURRENCE-ID", "RRULE", "RDATE", "EXDATE"} {
+			if v.Prop(name) != nil {
+				t.Errorf("VEVENT %s has %s, exported occurrences must be standalone", v.Text("UID"), name)
+			}
+		}
+	}
+	if len(uids) != 3 {
+		t.Errorf("UIDs are not unique: %v", uids)
+	}
+
+	if got, want := vevents[0].Text("UID"), entity.OccurrenceID(testSeriesID, *first.RecurrenceID).String(); got != want {
+		t.Errorf("occurrence UID = %s, want occurrence ID %s", got, want)
+	}
+	if got := vevents[1].Text("UID"); got != single.ID.String() {
+		t.Errorf("single event UID = %s, want %s", got, single.ID)
+	}
+	if got := vevents[2].Prop("DTSTART").Value; got != "20260123T090000Z" {
+		t.Errorf("occurrence DTSTART = %s", got)
+	}
+	// исходные данные выдачи не меняются
+	if first.SeriesID == nil || first.RRule == "" {
+		t.Error("eventsToCalendar modified the occurrence in place")
+	}
+}
+
+func TestResourceToCalendar(t *testing.T) {
+	seriesID := testSeriesID
+	moved := &entity.EventResponse{
+		ID:           entity.OccurrenceID(testSeriesID, utc("2026-01-23T09:00:00Z")),
+		Title:        "Стендап (перенесён)",
+		DateEvent:    utc("2026-01-23T11:00:00Z"),
+		EndDateEvent: utc("2026-01-23T11:15:00Z"),
+		SeriesID:     &seriesID,
+		RecurrenceID: ptr(utc("2026-01-23T09:00:00Z")),
+	}
+	cancelled := &entity.EventResponse{
+		ID:           entity.OccurrenceID(testSeriesID, utc("2026-01-26T09:00:00Z")),
+		Title:        "Стендап",
+		DateEvent:    utc("2026-01-26T09:00:00Z"),
+		EndDateEvent: utc("2026-01-26T09:15:00Z"),
+		SeriesID:     &seriesID,
+		RecurrenceID: ptr(utc("2026-01-26T09:00:00Z")),
+		Cancelled:    true,
+	}
+
+	master := testSeries()
+	vevents := roundTrip(t, resourceToCalendar([]*entity.EventResponse{master, moved, cancelled}, testStamp))
+	if len(vevents) != 2 {
+		t.Fatalf("got %d VEVENT, want master and one override", len(vevents))
+	}
+
+	m, o := vevents[0], vevents[1]
+	if m.Text("UID") != testSeriesID.String() || o.Text("UID") != testSeriesID.String() {
+		t.Errorf("UIDs = %s, %s, want series UID for both", m.Text("UID"), o.Text("UID"))
+	}
+	if m.Prop("RECURRENCE-ID") != nil {
+		t.Error("master has RECURRENCE-ID")
+	}
+	if got := m.Prop("RRULE"); got == nil || got.Value != master.RRule {
+		t.Errorf("master RRULE = %v, want %s", got, master.RRule)
+	}
+	var exdates []string
+	for _, p := range m.PropsByName("EXDATE") {
+		exdates = append(exdates, p.Value)
+	}
+	if len(exdates) != 2 || exdates[0] != "20260121T090000Z" || exdates[1] != "20260126T090000Z" {
+		t.Errorf("master EXDATE = %v, want series exdate and cancelled occurrence", exdates)
+	}
+	if len(master.ExDate) != 1 {
+		t.Errorf("resourceToCalendar modified master ExDate: %v", master.ExDate)
+	}
+
+	if got := o.Prop("RECURRENCE-ID"); got == nil || got.Value != "20260123T090000Z" {
+		t.Errorf("override RECURRENCE-ID = %v", got)
+	}
+	if o.Prop("RRULE") != nil {
+		t.Error("override has RRULE")
+	}
+}
+
+func TestVEventRoundTrip(t *testing.T) {
+	master := testSeries()
+	vevents := roundTrip(t, resourceToCalendar([]*entity.EventResponse{master}, testStamp))
+	if len(vevents) != 1 {
+		t.Fatalf("got %d VEVENT, want 1", len(vevents))
+	}
+
+	got, err := vEventToEvent(vevents[0], "user123", testStamp)
+	if err != nil {
+		t.Fatalf("vEventToEvent() error = %v", err)
+	}
+	if got.occurrence != nil {
+		t.Fatal("master imported as occurrence")
+	}
+	e := got.event
+	checks := []struct {
+		field, got, want string
+	}{
+		{"id", e.ID.String(), master.ID.String()},
+		{"title", e.Title, master.Title},
+		{"description", e.DescriptionEvent, master.DescriptionEvent},
+		{"dateEvent", e.DateEvent, "2026-01-19T09:00:00Z"},
+		{"durationEvent", e.EndDateEvent, "2026-01-19T09:15:00Z"},
+		{"creationDate", e.CreationDate, "2026-01-15T12:00:00Z"},
+		{"timeForNotification", e.TimeForNotification, "2026-01-19T08:50:00Z"},
+		{"rrule", e.RRuleValue(), master.RRule},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %q, want %q", c.field, c.got, c.want)
+		}
+	}
+	if len(e.ExDate) != 1 || e.ExDate[0] != "2026-01-21T09:00:00Z" {
+		t.Errorf("exdate = %v", e.ExDate)
+	}
+}
+
+func TestVEventToEventOccurrence(t *testing.T) {
+	body := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:" + testSeriesID.String() + "\r\n" +
+		"RECURRENCE-ID;TZID=Europe/Moscow:20260123T120000\r\n" +
+		"DTSTART:20260123T100000Z\r\n" +
+		"DURATION:PT30M\r\n" +
+		"SUMMARY:Перенос\r\n" +
+		"STATUS:CANCELLED\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	cal, err := ical.Unmarshal(body)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	got, err := vEventToEvent(cal.ChildrenByName(ical.ComponentEvent)[0], "user123", testStamp)
+	if err != nil {
+		t.Fatalf("vEventToEvent() error = %v", err)
+	}
+	o := got.occurrence
+	if o == nil {
+		t.Fatal("RECURRENCE-ID VEVENT not imported as occurrence")
+	}
+	if o.SeriesID != testSeriesID || o.RecurrenceID != "2026-01-23T09:00:00Z" {
+		t.Errorf("occurrence = %s / %s", o.SeriesID, o.RecurrenceID)
+	}
+	if o.EndDateEvent != "2026-01-23T10:30:00Z" || !o.Cancelled {
+		t.Errorf("occurrence end = %s, cancelled = %v", o.EndDateEvent, o.Cancelled)
+	}
+}
+
+func TestUIDToEventID(t *testing.T) {
+	if got := uidToEventID(testSeriesID.String()); got != testSeriesID {
+		t.Errorf("UUID UID = %s, want %s", got, testSeriesID)
+	}
+	a, b := uidToEventID("event-1@example.com"), uidToEventID("event-1@example.com")
+	if a != b || a == uuid.Nil {
+		t.Errorf("non-UUID UID is not mapped deterministically: %s, %s", a, b)
+	}
+	if uidToEventID("event-2@example.com") == a {
+		t.Error("different UIDs map to the same ID")
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestVEventTimeZoneRoundTrip(t *testing.T) {
+	master := testSeries()
+	master.TimeZone = "Europe/Moscow"
+	vevents := roundTrip(t, resourceToCalendar([]*entity.EventResponse{master}, testStamp))
+	if len(vevents) != 1 {
+		t.Fatalf("got %d VEVENT, want 1", len(vevents))
+	}
+
+	v := vevents[0]
+	for _, name := range []string{"DTSTART", "DTEND", "EXDATE"} {
+		if p := v.Prop(name); p == nil || p.Param("TZID") != "Europe/Moscow" {
+			t.Errorf("%s = %+v, want TZID=Europe/Moscow", name, p)
+		}
+	}
+	if got := v.Prop("DTSTART").Value; got != "20260119T120000" {
+		t.Errorf("DTSTART = %s, want local time 20260119T120000", got)
+	}
+
+	got, err := vEventToEvent(v, "user123", testStamp)
+	if err != nil {
+		t.Fatalf("vEventToEvent() error = %v", err)
+	}
+	e := got.event
+	if e.TimeZone != "Europe/Moscow" || e.DateEvent != "2026-01-19T09:00:00Z" {
+		t.Errorf("imported timeZone = %q, dateEvent = %s", e.TimeZone, e.DateEvent)
+	}
+	if len(e.ExDate) != 1 || e.ExDate[0] != "2026-01-21T09:00:00Z" {
+		t.Errorf("exdate = %v", e.ExDate)
+	}
+}
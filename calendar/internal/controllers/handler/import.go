@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"bytes"
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"calendar/internal/application/validation"
+	"calendar/pkg/ical"
+	"calendar/pkg/validator"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	importFormField = "file"
+	maxUserIDLength = 100
+)
+
+// ImportEvents godoc
+// @Summary     Импорт событий из iCalendar (.ics)
+// @Description Разбирает VEVENT из .ics (multipart-поле file или тело text/calendar) и создаёт события через outbox (event_created).
+// @Description Для каждого UID возвращает статус: created, duplicate или rejected с ошибками валидации
+// @Accept      multipart/form-data
+// @Accept      text/calendar
+// @Produce     json
+// @Param       userID  query     string  true   "Владелец импортируемых событий"
+// @Param       file    formData  file    false  "Файл .ics"
+// @Success     200     {object}  entity.ImportResponse
+// @Failure     400
+// @Failure     415
+// @tags        Event
+// @Router      /v1/event/import [post]
+func (h *HandlerImpl) ImportEvents(c *fiber.Ctx) error {
+	userID := strings.TrimSpace(c.Query("userID"))
+	if userID == "" {
+		userID = strings.TrimSpace(c.FormValue("userID"))
+	}
+	if userID == "" || len(userID) > maxUserIDLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userID is required",
+		})
+	}
+
+	data, err := readICS(c)
+	if err != nil {
+		h.logger.Warnf("import: %v", err)
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	cal, err := ical.Decode(bytes.NewReader(data))
+	if err == nil && cal.Name != ical.ComponentCalendar {
+		err = fmt.Errorf("ical: expected %s, got %s", ical.ComponentCalendar, cal.Name)
+	}
+	if err != nil {
+		h.logger.Warnf("import: invalid calendar: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	vevents := cal.ChildrenByName(ical.ComponentEvent)
+	// переопределения вхождений применяем после создания серий
+	sort.SliceStable(vevents, func(i, j int) bool {
+		return vevents[i].Prop("RECURRENCE-ID") == nil && vevents[j].Prop("RECURRENCE-ID") != nil
+	})
+
+	now := time.Now().UTC()
+	resp := entity.ImportResponse{Items: make([]entity.ImportResult, 0, len(vevents))}
+	for _, v := range vevents {
+		resp.Add(h.importOne(c.Context(), v, userID, now))
+	}
+
+	h.logger.Infof("import for user %s: created=%d duplicates=%d rejected=%d",
+		userID, resp.Created, resp.Duplicates, resp.Rejected)
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (h *HandlerImpl) importOne(ctx context.Context, v *ical.Component, userID string, now time.Time) entity.ImportResult {
+	item, err := vEventToEvent(v, userID, now)
+	res := entity.ImportResult{UID: item.uid, RecurrenceID: item.recurrenceID, Status: entity.ImportRejected}
+	if err != nil {
+		res.Errors = []string{err.Error()}
+		return res
+	}
+
+	if item.occurrence != nil {
+		res.EventID = item.occurrence.SeriesID
+		if err = validator.Validate.Struct(item.occurrence); err != nil {
+			res.Errors = validator.Messages(err)
+			return res
+		}
+		if err = validateOccurrenceDates(item.occurrence); err != nil {
+			res.Errors = []string{err.Error()}
+			return res
+		}
+		// переопределение меняет серию: импортировать можно только в свою
+		series, err := h.usecase.GetEventResource(ctx, item.occurrence.SeriesID.String())
+		if err != nil {
+			res.Errors = []string{err.Error()}
+			return res
+		}
+		if series[0].UserID != userID {
+			h.logger.Warnf("[event: %s] import occurrence: series belongs to %s, not %s", item.occurrence.SeriesID, series[0].UserID, userID)
+			res.Errors = []string{appers.ErrForbidden.Error()}
+			return res
+		}
+		changed, err := h.usecase.UpsertOccurrence(ctx, *item.occurrence)
+		switch {
+		case err != nil:
+			res.Errors = []string{err.Error()}
+		case !changed:
+			res.Status = entity.ImportDuplicate
+		default:
+			res.Status = entity.ImportCreated
+		}
+		return res
+	}
+
+	res.EventID = item.event.ID
+	if err = validator.Validate.Struct(&item.event); err != nil {
+		res.Errors = validator.Messages(err)
+		return res
+	}
+	if err = validation.EventDates(&item.event); err != nil {
+		res.Errors = []string{err.Error()}
+		return res
+	}
+	if err = validation.Recurrence(&item.event); err != nil {
+		res.Errors = []string{err.Error()}
+		return res
+	}
+
+	err = h.usecase.CreateEvent(ctx, item.event, nil)
+	switch {
+	case errors.Is(err, appers.ErrEventAlreadyExists):
+		res.Status = entity.ImportDuplicate
+	case err != nil:
+		h.logger.Errorf("[event: %s] import failed: %v", item.event.ID, err)
+		res.Errors = []string{err.Error()}
+	default:
+		res.Status = entity.ImportCreated
+	}
+	return res
+}
+
+// readICS читает .ics из multipart-поля file или из тела запроса text/calendar
+func readICS(c *fiber.Ctx) ([]byte, error) {
+	contentType := strings.ToLower(string(c.Request().Header.ContentType()))
+
+	switch {
+	case strings.HasPrefix(contentType, fiber.MIMEMultipartForm):
+		fh, err := c.FormFile(importFormField)
+		if err != nil {
+			return nil, fmt.Errorf("multipart field %q is required", importFormField)
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open uploaded file: %w", err)
+		}
+		defer f.Close()
+		return io.ReadAll(f)
+	case strings.HasPrefix(contentType, mimeCalendar):
+		return c.Body(), nil
+	default:
+		return nil, fmt.Errorf("unsupported content type %q, expected %s or %s", contentType, fiber.MIMEMultipartForm, mimeCalendar)
+	}
+}
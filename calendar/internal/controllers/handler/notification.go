@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"calendar/pkg/validator"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetNotificationSettings godoc
+// @Summary     Настройки уведомлений пользователя
+// @Description Возвращает адреса каналов уведомлений (email, webhook) и язык шаблонов пользователя
+// @Produce     json
+// @Param       userID  path     string  true  "ID пользователя"
+// @Success     200     {object} entity.NotificationSettings
+// @Failure     404
+// @Failure     500
+// @tags        Notification
+// @Router      /v1/notification-settings/{userID} [get]
+func (h *HandlerImpl) GetNotificationSettings(c *fiber.Ctx) error {
+	settings, err := h.usecase.GetNotificationSettings(c.Context(), c.Params("userID"))
+	switch {
+	case errors.Is(err, appers.ErrNotificationSettingsNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"description": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"description": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(settings)
+}
+
+// UpdateNotificationSettings godoc
+// @Summary     Изменение настроек уведомлений пользователя
+// @Description Создаёт или заменяет настройки уведомлений. Пустой email - письма пользователю не отправляются,
+// @Description пустой webhookURL - используется notification.webhook.url, пустой locale - язык по умолчанию.
+// @Description webhookURL на loopback или частный адрес отклоняется
+// @Accept      json
+// @Produce     json
+// @Param       userID  path     string                       true  "ID пользователя"
+// @Param       body    body     entity.NotificationSettings  true  "Настройки уведомлений"
+// @Success     200     {object} entity.NotificationSettings
+// @Failure     400
+// @Failure     500
+// @tags        Notification
+// @Router      /v1/notification-settings/{userID} [put]
+func (h *HandlerImpl) UpdateNotificationSettings(c *fiber.Ctx) error {
+	var settings entity.NotificationSettings
+	if err := c.BodyParser(&settings); err != nil {
+		h.logger.Errorf("error parsing body: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+	settings.UserID = c.Params("userID")
+
+	if err := validator.Validate.Struct(&settings); err != nil {
+		h.logger.Warnf("validation error: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(formatValidationErrors(err))
+	}
+
+	if err := h.usecase.UpdateNotificationSettings(c.Context(), settings); err != nil {
+		return appers.SanitizeError(c, err)
+	}
+	return h.GetNotificationSettings(c)
+}
@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	use_cases "calendar/internal/application/use-cases"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofrs/uuid"
+	"go.uber.org/zap"
+)
+
+type OutboxAdminHandler interface {
+	ListOutbox(c *fiber.Ctx) error
+	GetOutbox(c *fiber.Ctx) error
+	RequeueOutbox(c *fiber.Ctx) error
+	RequeueOutboxByFilter(c *fiber.Ctx) error
+	PurgeSentOutbox(c *fiber.Ctx) error
+}
+
+type OutboxAdminHandlerImpl struct {
+	usecase use_cases.UseCaser
+	logger  *zap.SugaredLogger
+}
+
+func NewOutboxAdminHandler(usecase use_cases.UseCaser, logger *zap.SugaredLogger) *OutboxAdminHandlerImpl {
+	return &OutboxAdminHandlerImpl{
+		usecase: usecase,
+		logger:  logger,
+	}
+}
+
+// parseOutboxFilter разбирает query-параметры фильтра outbox
+func parseOutboxFilter(c *fiber.Ctx) (entity.OutboxFilter, error) {
+	var f entity.OutboxFilter
+
+	if statuses := c.Query("status"); statuses != "" {
+		for _, st := range strings.Split(statuses, ",") {
+			status := entity.OutboxStatus(strings.ToUpper(strings.TrimSpace(st)))
+			switch status {
+			case entity.OutboxNew, entity.OutboxSent, entity.OutboxFailed, entity.OutboxGaveUp:
+				f.Statuses = append(f.Statuses, status)
+			default:
+				return f, fmt.Errorf("unknown status %q", st)
+			}
+		}
+	}
+	f.EventType = entity.OutboxEventType(c.Query("eventType"))
+	if aggregateID := c.Query("aggregateID"); aggregateID != "" {
+		id, err := uuid.FromString(aggregateID)
+		if err != nil {
+			return f, fmt.Errorf("invalid aggregateID: %w", err)
+		}
+		f.AggregateID = &id
+	}
+
+	var err error
+	if f.OlderThan, err = parseAge(c.Query("olderThan")); err != nil {
+		return f, fmt.Errorf("invalid olderThan: %w", err)
+	}
+	if f.NewerThan, err = parseAge(c.Query("newerThan")); err != nil {
+		return f, fmt.Errorf("invalid newerThan: %w", err)
+	}
+	if f.AfterID, err = parseNonNegativeInt(c.Query("afterID")); err != nil {
+		return f, fmt.Errorf("invalid afterID: %w", err)
+	}
+	if f.Limit, err = parseNonNegativeInt(c.Query("limit")); err != nil {
+		return f, fmt.Errorf("invalid limit: %w", err)
+	}
+	return f, nil
+}
+
+// parseAge возраст в формате time.Duration (например, 30m, 24h); пусто - без ограничения
+func parseAge(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return d, nil
+}
+
+func parseNonNegativeInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return n, nil
+}
+
+func outboxID(c *fiber.Ctx) (int, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid outbox id %q", c.Params("id"))
+	}
+	return id, nil
+}
+
+// ListOutbox godoc
+// @Summary     Список сообщений outbox
+// @Description Возвращает сообщения outbox (без payload) по фильтру, по возрастанию id. Для следующей страницы передайте afterID = nextAfterID
+// @Produce     json
+// @Param       status       query    string  false  "Статусы через запятую: NEW, SENT, FAILED, GAVE_UP"
+// @Param       eventType    query    string  false  "Тип сообщения (event_created, event_updated, ...)"
+// @Param       aggregateID  query    string  false  "ID события"
+// @Param       olderThan    query    string  false  "Создано не позже, чем столько назад (например, 1h)"
+// @Param       newerThan    query    string  false  "Создано не раньше, чем столько назад (например, 24h)"
+// @Param       afterID      query    int     false  "Курсор: id последнего сообщения предыдущей страницы"
+// @Param       limit        query    int     false  "Размер страницы (по умолчанию 50, максимум 500)"
+// @Success     200  {object}  entity.OutboxPage
+// @Failure     400
+// @Failure     401
+// @Failure     403
+// @Failure     500
+// @Security    BearerAuth
+// @tags        Outbox admin
+// @Router      /v1/admin/outbox [get]
+func (h *OutboxAdminHandlerImpl) ListOutbox(c *fiber.Ctx) error {
+	f, err := parseOutboxFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	page, err := h.usecase.ListOutbox(c.Context(), f)
+	if err != nil {
+		h.logger.Errorf("list outbox failed: %v", err)
+		return appers.SanitizeError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
+// GetOutbox godoc
+// @Summary     Сообщение outbox
+// @Description Возвращает сообщение outbox вместе с payload и причиной последней ошибки
+// @Produce     json
+// @Param       id   path      int  true  "ID сообщения outbox"
+// @Success     200  {object}  entity.OutboxEvent
+// @Failure     400
+// @Failure     401
+// @Failure     403
+// @Failure     404
+// @Failure     500
+// @Security    BearerAuth
+// @tags        Outbox admin
+// @Router      /v1/admin/outbox/{id} [get]
+func (h *OutboxAdminHandlerImpl) GetOutbox(c *fiber.Ctx) error {
+	id, err := outboxID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	e, err := h.usecase.GetOutbox(c.Context(), id)
+	if err != nil {
+		return appers.SanitizeError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(e)
+}
+
+// RequeueOutbox godoc
+// @Summary     Повторная отправка сообщения outbox
+// @Description Возвращает сообщение в статусе FAILED или GAVE_UP в очередь relay: статус NEW, attempts = 0, next_attempt_at = now()
+// @Produce     json
+// @Param       id   path  int  true  "ID сообщения outbox"
+// @Success     200
+// @Failure     400
+// @Failure     401
+// @Failure     403
+// @Failure     404
+// @Failure     409
+// @Failure     500
+// @Security    BearerAuth
+// @tags        Outbox admin
+// @Router      /v1/admin/outbox/{id}/requeue [post]
+func (h *OutboxAdminHandlerImpl) RequeueOutbox(c *fiber.Ctx) error {
+	id, err := outboxID(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err = h.usecase.RequeueOutbox(c.Context(), id); err != nil {
+		return appers.SanitizeError(c, err)
+	}
+	h.logger.Infof("[ID %d] outbox requeued by admin", id)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"description": "ok"})
+}
+
+// RequeueOutboxByFilter godoc
+// @Summary     Массовая повторная отправка outbox
+// @Description Возвращает в очередь relay все сообщения FAILED/GAVE_UP, подходящие под фильтр
+// @Produce     json
+// @Param       status       query    string  false  "FAILED и/или GAVE_UP через запятую (по умолчанию оба)"
+// @Param       eventType    query    string  false  "Тип сообщения"
+// @Param       aggregateID  query    string  false  "ID события"
+// @Param       olderThan    query    string  false  "Создано не позже, чем столько назад (например, 1h)"
+// @Param       newerThan    query    string  false  "Создано не раньше, чем столько назад (например, 24h)"
+// @Success     200
+// @Failure     400
+// @Failure     401
+// @Failure     403
+// @Failure     409
+// @Failure     500
+// @Security    BearerAuth
+// @tags        Outbox admin
+// @Router      /v1/admin/outbox/requeue [post]
+func (h *OutboxAdminHandlerImpl) RequeueOutboxByFilter(c *fiber.Ctx) error {
+	f, err := parseOutboxFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	n, err := h.usecase.RequeueOutboxByFilter(c.Context(), f)
+	if err != nil {
+		return appers.SanitizeError(c, err)
+	}
+	h.logger.Infof("[filter: %+v] %d outbox rows requeued by admin", f, n)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"requeued": n})
+}
+
+// PurgeSentOutbox godoc
+// @Summary     Удаление отправленных сообщений outbox
+// @Description Удаляет сообщения в статусе SENT, созданные раньше olderThan (по умолчанию все отправленные)
+// @Produce     json
+// @Param       olderThan  query  string  false  "Возраст сообщения (например, 168h)"
+// @Success     200
+// @Failure     400
+// @Failure     401
+// @Failure     403
+// @Failure     500
+// @Security    BearerAuth
+// @tags        Outbox admin
+// @Router      /v1/admin/outbox/sent [delete]
+func (h *OutboxAdminHandlerImpl) PurgeSentOutbox(c *fiber.Ctx) error {
+	olderThan, err := parseAge(c.Query("olderThan"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("invalid olderThan: %v", err)})
+	}
+
+	n, err := h.usecase.PurgeSentOutbox(c.Context(), olderThan)
+	if err != nil {
+		return appers.SanitizeError(c, err)
+	}
+	h.logger.Infof("[olderThan: %s] %d sent outbox rows purged by admin", olderThan, n)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"purged": n})
+}
@@ -12,17 +12,21 @@ import (
 
 type Router struct {
 	handler Handler
+	dav     DavHandler
+	admin   OutboxAdminHandler
 	app     *fiber.App
 	conf    *config.Config
 	logger  *zap.SugaredLogger
 }
 
-func NewRouter(handler Handler, app *fiber.App, conf *config.Config, logger *zap.SugaredLogger) *Router {
+func NewRouter(handler Handler, dav DavHandler, admin OutboxAdminHandler, app *fiber.App, conf *config.Config, logger *zap.SugaredLogger) *Router {
 	return &Router{
 		logger:  logger,
 		app:     app,
 		conf:    conf,
 		handler: handler,
+		dav:     dav,
+		admin:   admin,
 	}
 }
 
@@ -49,7 +53,42 @@ func (r *Router) RegisterRouter() {
 
 		v1.Post("/event", r.handler.CreateEvent)
 		v1.Get("/event", r.handler.GetEventsByPeriod)
+		v1.Get("/event.ics", r.handler.GetEventsByPeriod)
+		v1.Post("/event/import", r.handler.ImportEvents)
 		v1.Patch("/event", r.handler.UpdateEvent)
 		v1.Delete("/event/:id", r.handler.DeleteEvent)
+		v1.Put("/event/:id/occurrence", r.handler.UpsertOccurrence)
+		v1.Delete("/event/:id/occurrence", r.handler.CancelOccurrence)
+		v1.Get("/event/:id/attendees", r.handler.ListAttendees)
+		v1.Post("/event/:id/attendees", r.handler.InviteAttendees)
+		v1.Put("/event/:id/attendees/:userID", r.handler.RespondAttendee)
+		v1.Post("/calendar", r.handler.CreateCalendar)
+		v1.Get("/calendar", r.handler.ListCalendars)
+		v1.Get("/calendar/:id", r.handler.GetCalendar)
+		v1.Patch("/calendar/:id", r.handler.UpdateCalendar)
+		v1.Delete("/calendar/:id", r.handler.DeleteCalendar)
+		v1.Get("/notification-settings/:userID", r.handler.GetNotificationSettings)
+		v1.Put("/notification-settings/:userID", r.handler.UpdateNotificationSettings)
+
+		admin := v1.Group("/admin", RequireRole(r.conf.Auth, RoleAdmin, r.logger))
+
+		admin.Get("/outbox", r.admin.ListOutbox)
+		admin.Post("/outbox/requeue", r.admin.RequeueOutboxByFilter)
+		admin.Delete("/outbox/sent", r.admin.PurgeSentOutbox)
+		admin.Get("/outbox/:id", r.admin.GetOutbox)
+		admin.Post("/outbox/:id/requeue", r.admin.RequeueOutbox)
+
+		dav := router.Group("/dav")
+		// авторизация на каждом маршруте: middleware группы не видит параметр :user
+		davAuth := RequireDavUser(r.conf.Auth, r.logger)
+
+		dav.Options("/*", r.dav.Options)
+		dav.Add(MethodPropfind, "/:user", davAuth, r.dav.PropfindHome)
+		dav.Add(MethodPropfind, "/:user/"+davCollection, davAuth, r.dav.PropfindCollection)
+		dav.Add(MethodPropfind, "/:user/"+davCollection+"/:name", davAuth, r.dav.PropfindResource)
+		dav.Add(MethodReport, "/:user/"+davCollection, davAuth, r.dav.Report)
+		dav.Get("/:user/"+davCollection+"/:name", davAuth, r.dav.GetResource)
+		dav.Put("/:user/"+davCollection+"/:name", davAuth, r.dav.PutResource)
+		dav.Delete("/:user/"+davCollection+"/:name", davAuth, r.dav.DeleteResource)
 	})
 }
@@ -0,0 +1,208 @@
+package listener
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/common"
+	"calendar/internal/application/entity"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.uber.org/zap"
+)
+
+const (
+	errorClassRetryable = "retryable"
+	errorClassPermanent = "permanent"
+
+	dlqTopicSuffix = "-dlq"
+
+	// заголовки сообщения в топике задержки
+	headerRetryAttempt           = "retry_attempt"
+	headerRetryNotBefore         = "retry_not_before"
+	headerRetryError             = "retry_error"
+	headerRetryOriginalTopic     = "retry_original_topic"
+	headerRetryOriginalPartition = "retry_original_partition"
+	headerRetryOriginalOffset    = "retry_original_offset"
+
+	maxErrorHeaderLength = 1000
+)
+
+// retryTopic топик задержки: сообщение из него обрабатывается не раньше, чем через delay после публикации
+type retryTopic struct {
+	topic string
+	delay time.Duration
+}
+
+// parseRetryTopics разбирает "1m,10m,1h" в топики <topic>-retry-1m, <topic>-retry-10m, <topic>-retry-1h
+func parseRetryTopics(topic, delays string, logger *zap.SugaredLogger) []retryTopic {
+	var res []retryTopic
+	for _, s := range strings.Split(delays, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			logger.Warnf("некорректная задержка retry-топика %q пропущена", s)
+			continue
+		}
+		res = append(res, retryTopic{topic: topic + "-retry-" + s, delay: d})
+	}
+	return res
+}
+
+// classifyError постоянные ошибки - некорректное сообщение и отказы 4xx; остальное (БД, Kafka, таймауты) повторяем
+func classifyError(err error) string {
+	var errResp appers.ErrorResp
+	if errors.As(err, &errResp) && errResp.StatusCode < http.StatusInternalServerError {
+		return errorClassPermanent
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+		return errorClassPermanent
+	}
+	return errorClassRetryable
+}
+
+// handleError передаёт неудачное сообщение дальше: retryable - в следующий топик задержки, постоянные
+// и исчерпавшие задержки - в DLQ. Без топиков задержки retryable ошибки повторяются на месте.
+// false - контекст сессии отменён до того, как сообщение передано.
+func (k *KafkaBrokerConsumer) handleError(ctx context.Context, msg *sarama.ConsumerMessage, m entity.InboxMessage, procErr error) bool {
+	class := classifyError(procErr)
+	attempt := retryAttempt(msg)
+	k.logger.Errorf("[message: %s] обработка не удалась (%s, попытка %d): %v", m.ID, class, attempt+1, procErr)
+
+	if class == errorClassRetryable && len(k.retries) == 0 {
+		k.observeError(msg.Topic, class, "in_place")
+		return k.retryInPlace(ctx, m)
+	}
+
+	outcome := "dlq"
+	var out *sarama.ProducerMessage
+	if class == errorClassRetryable && attempt < len(k.retries) {
+		outcome = "retry_topic"
+		out = retryMessage(msg, k.retries[attempt], attempt+1, procErr)
+	} else {
+		out = dlqMessage(msg, k.dlqTopic, attempt+1, class, procErr)
+	}
+
+	// без успешной публикации offset отмечать нельзя: повторяем отправку
+	for try := 0; ; try++ {
+		err := k.producer.ProduceRecord(ctx, out)
+		if err == nil {
+			break
+		}
+		k.observeError(msg.Topic, class, "republish_failed")
+		k.logger.Errorf("[message: %s] не удалось отправить в %s: %v", m.ID, out.Topic, err)
+		if err := common.SleepCtx(ctx, common.NextBackoffWithJitter(min(try, maxBackoffAttempt))); err != nil {
+			return false
+		}
+	}
+
+	k.observeError(msg.Topic, class, outcome)
+	k.logger.Warnf("[message: %s] отправлено в %s", m.ID, out.Topic)
+	return true
+}
+
+func (k *KafkaBrokerConsumer) observeError(topic, class, outcome string) {
+	if k.m != nil {
+		k.m.Kafka.ConsumerErrorsTotal.WithLabelValues(topic, class, outcome).Inc()
+	}
+}
+
+// waitRetryDelay ждёт времени retry_not_before сообщения из топика задержки
+func waitRetryDelay(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	v := header(msg, headerRetryNotBefore)
+	if v == "" {
+		return nil
+	}
+	notBefore, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return nil
+	}
+	return common.SleepCtx(ctx, time.Until(notBefore))
+}
+
+// retryAttempt сколько раз сообщение уже прошло через топики задержки
+func retryAttempt(msg *sarama.ConsumerMessage) int {
+	n, _ := strconv.Atoi(header(msg, headerRetryAttempt))
+	return n
+}
+
+func header(msg *sarama.ConsumerMessage, key string) string {
+	for _, h := range msg.Headers {
+		if h != nil && strings.EqualFold(string(h.Key), key) {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// originHeaders исходные заголовки без служебных retry_*; координаты исходного сообщения сохраняются
+// с первого захода в топик задержки
+func originHeaders(msg *sarama.ConsumerMessage) (headers []sarama.RecordHeader, topic, partition, offset string) {
+	topic, partition, offset = msg.Topic, strconv.Itoa(int(msg.Partition)), strconv.FormatInt(msg.Offset, 10)
+	if t := header(msg, headerRetryOriginalTopic); t != "" {
+		topic, partition, offset = t, header(msg, headerRetryOriginalPartition), header(msg, headerRetryOriginalOffset)
+	}
+	for _, h := range msg.Headers {
+		if h == nil || strings.HasPrefix(strings.ToLower(string(h.Key)), "retry_") {
+			continue
+		}
+		headers = append(headers, *h)
+	}
+	return headers, topic, partition, offset
+}
+
+func retryMessage(msg *sarama.ConsumerMessage, rt retryTopic, attempt int, procErr error) *sarama.ProducerMessage {
+	headers, topic, partition, offset := originHeaders(msg)
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(headerRetryAttempt), Value: []byte(strconv.Itoa(attempt))},
+		sarama.RecordHeader{Key: []byte(headerRetryNotBefore), Value: []byte(time.Now().UTC().Add(rt.delay).Format(time.RFC3339Nano))},
+		sarama.RecordHeader{Key: []byte(headerRetryError), Value: []byte(truncate(procErr.Error()))},
+		sarama.RecordHeader{Key: []byte(headerRetryOriginalTopic), Value: []byte(topic)},
+		sarama.RecordHeader{Key: []byte(headerRetryOriginalPartition), Value: []byte(partition)},
+		sarama.RecordHeader{Key: []byte(headerRetryOriginalOffset), Value: []byte(offset)},
+	)
+	return &sarama.ProducerMessage{
+		Topic:     rt.topic,
+		Key:       sarama.ByteEncoder(msg.Key),
+		Value:     sarama.ByteEncoder(msg.Value),
+		Headers:   headers,
+		Timestamp: time.Now(),
+	}
+}
+
+// dlqMessage исходное сообщение с исходными заголовками и причиной; заголовки dlq_* - как у DLQ relay
+func dlqMessage(msg *sarama.ConsumerMessage, dlqTopic string, attempts int, class string, procErr error) *sarama.ProducerMessage {
+	headers, topic, partition, offset := originHeaders(msg)
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte("dlq_original_topic"), Value: []byte(topic)},
+		sarama.RecordHeader{Key: []byte("dlq_original_partition"), Value: []byte(partition)},
+		sarama.RecordHeader{Key: []byte("dlq_original_offset"), Value: []byte(offset)},
+		sarama.RecordHeader{Key: []byte("dlq_attempts"), Value: []byte(strconv.Itoa(attempts))},
+		sarama.RecordHeader{Key: []byte("dlq_error"), Value: []byte(truncate(procErr.Error()))},
+		sarama.RecordHeader{Key: []byte("dlq_error_class"), Value: []byte(class)},
+	)
+	return &sarama.ProducerMessage{
+		Topic:     dlqTopic,
+		Key:       sarama.ByteEncoder(msg.Key),
+		Value:     sarama.ByteEncoder(msg.Value),
+		Headers:   headers,
+		Timestamp: time.Now(),
+	}
+}
+
+func truncate(s string) string {
+	if len(s) <= maxErrorHeaderLength {
+		return s
+	}
+	return strings.ToValidUTF8(s[:maxErrorHeaderLength], "")
+}
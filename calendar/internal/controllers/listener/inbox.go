@@ -0,0 +1,66 @@
+package listener
+
+import (
+	"calendar/internal/appers"
+	"calendar/internal/application/entity"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+const contentTypeCloudEventJSON = "application/cloudevents+json"
+
+// inboxMessage ID сообщения для inbox: "command:<command_id>" для команд, "<ce_source>:<ce_id>"
+// (CloudEvents binary или structured mode), для остальных сообщений - "<topic>/<partition>/<offset>".
+// Для сообщений из топиков задержки топик, партиция и offset - исходные (заголовки retry_original_*).
+func inboxMessage(msg *sarama.ConsumerMessage) (entity.InboxMessage, error) {
+	m := entity.InboxMessage{
+		Topic:      msg.Topic,
+		Partition:  msg.Partition,
+		Offset:     msg.Offset,
+		Payload:    msg.Value,
+		ReceivedAt: msg.Timestamp,
+	}
+
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		if h != nil {
+			headers[strings.ToLower(string(h.Key))] = string(h.Value)
+		}
+	}
+
+	if topic := headers[headerRetryOriginalTopic]; topic != "" {
+		partition, _ := strconv.ParseInt(headers[headerRetryOriginalPartition], 10, 32)
+		offset, _ := strconv.ParseInt(headers[headerRetryOriginalOffset], 10, 64)
+		m.Topic, m.Partition, m.Offset = topic, int32(partition), offset
+	}
+
+	id, source := headers["ce_id"], headers["ce_source"]
+	m.EventType = headers["ce_type"]
+
+	if strings.HasPrefix(headers["content-type"], contentTypeCloudEventJSON) {
+		var ce struct {
+			ID     string          `json:"id"`
+			Source string          `json:"source"`
+			Type   string          `json:"type"`
+			Data   json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(msg.Value, &ce); err != nil {
+			return m, fmt.Errorf("structured CloudEvent: %v: %w", err, appers.ErrMalformedMessage)
+		}
+		id, source, m.EventType, m.Payload = ce.ID, ce.Source, ce.Type, ce.Data
+	}
+
+	if m.CommandID = headers[entity.CommandIDHeader]; m.CommandID != "" {
+		m.ID = "command:" + m.CommandID
+		m.EventType = entity.CommandEventType
+	} else if id != "" {
+		m.ID = source + ":" + id
+	} else {
+		m.ID = fmt.Sprintf("%s/%d/%d", m.Topic, m.Partition, m.Offset)
+	}
+	return m, nil
+}
@@ -1,27 +1,61 @@
 package listener
 
 import (
-	use_cases "calendar/internal/application/use-cases"
+	"calendar/internal/appers"
+	"calendar/internal/application/common"
+	"calendar/internal/application/entity"
+	"calendar/internal/transport/producer"
+	"calendar/pkg/config"
 	"calendar/pkg/metrics"
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/IBM/sarama"
 	"go.uber.org/zap"
 )
 
+const maxBackoffAttempt = 11
+
 type KafkaBrokerConsumer struct {
-	usecase use_cases.UseCaser
-	logger  *zap.SugaredLogger
-	m       *metrics.Metrics
+	handlers *Registry
+	producer producer.Producer
+	logger   *zap.SugaredLogger
+	m        *metrics.Metrics
+
+	retries []retryTopic // топики задержки по порядку попыток, общие для всех топиков
+
+	dlqTopic string
+
+	workers   int // воркеров на партицию
+	queueSize int
 }
 
-func NewKafkaBrokerConsumer(usecase use_cases.UseCaser, logger *zap.SugaredLogger, m *metrics.Metrics) *KafkaBrokerConsumer {
+func NewKafkaBrokerConsumer(handlers *Registry, producer producer.Producer, conf config.Kafka, logger *zap.SugaredLogger, m *metrics.Metrics) *KafkaBrokerConsumer {
+	dlq := conf.Retry.DLQTopic
+	if dlq == "" {
+		dlq = conf.ReaderTopic + dlqTopicSuffix
+	}
+
 	return &KafkaBrokerConsumer{
-		logger:  logger,
-		usecase: usecase,
-		m:       m,
+		logger:    logger,
+		handlers:  handlers,
+		producer:  producer,
+		m:         m,
+		retries:   parseRetryTopics(conf.ReaderTopic, conf.Retry.Delays, logger),
+		dlqTopic:  dlq,
+		workers:   conf.Consumer.Workers,
+		queueSize: conf.Consumer.QueueSize,
+	}
+}
+
+// Topics топики подписки: топики реестра обработчиков и топики задержки
+func (k *KafkaBrokerConsumer) Topics() []string {
+	topics := k.handlers.Topics()
+	for _, r := range k.retries {
+		topics = append(topics, r.topic)
 	}
+	return topics
 }
 
 func (k *KafkaBrokerConsumer) Setup(session sarama.ConsumerGroupSession) error {
@@ -40,25 +74,103 @@ func (k *KafkaBrokerConsumer) Cleanup(session sarama.ConsumerGroupSession) error
 	return nil
 }
 
+// ConsumeClaim раздаёт сообщения партиции воркерам (broker.kafka.consumer.workers) по хешу ключа: сообщения
+// с одним ключом обрабатываются по порядку. Offset отмечается только для непрерывного префикса сообщений, которые
+// обработаны, оказались дублем или переданы дальше (топик задержки или DLQ). Обработчики получают контекст сессии;
+// при ребалансе или остановке новые сообщения не раздаются, а ConsumeClaim ждёт начатые. Необработанные сообщения
+// будут доставлены заново после ребаланса.
 func (k *KafkaBrokerConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	topic := claim.Topic()
+	ctx := session.Context()
+	pool := newPartitionPool(ctx, k.workers, k.queueSize, session, k.handle)
+	defer pool.drain()
 
-	for msg := range claim.Messages() {
-		if k.m != nil {
-			k.m.Kafka.ConsumerInFlight.WithLabelValues(topic).Inc()
-		}
-		start := time.Now()
-		k.logger.Infof("Message topic:%q partition:%d offset:%d  value:%s", msg.Topic, msg.Partition, msg.Offset, msg.Value)
-
-		k.usecase.ConsumerMessage(context.Background(), msg.Value, start)
-		if k.m != nil {
-			k.m.Kafka.ConsumerMessagesTotal.WithLabelValues(topic).Inc()
-			k.m.Kafka.ConsumerProcessDuration.WithLabelValues(topic).Observe(time.Since(start).Seconds())
-			k.m.Kafka.ConsumerInFlight.WithLabelValues(topic).Dec()
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if !pool.dispatch(ctx, msg) {
+				return nil
+			}
+		case <-ctx.Done():
+			return nil
 		}
+	}
+}
 
-		session.MarkMessage(msg, "")
+// handle обрабатывает одно сообщение в воркере; сообщения топиков задержки ждут своего времени
+func (k *KafkaBrokerConsumer) handle(ctx context.Context, msg *sarama.ConsumerMessage) bool {
+	if err := waitRetryDelay(ctx, msg); err != nil {
+		return false
 	}
 
-	return nil
+	if k.m != nil {
+		k.m.Kafka.ConsumerInFlight.WithLabelValues(msg.Topic).Inc()
+	}
+	start := time.Now()
+	k.logger.Infof("Message topic:%q partition:%d offset:%d  value:%s", msg.Topic, msg.Partition, msg.Offset, msg.Value)
+
+	ok := k.process(ctx, msg)
+	if k.m != nil {
+		k.m.Kafka.ConsumerMessagesTotal.WithLabelValues(msg.Topic).Inc()
+		k.m.Kafka.ConsumerProcessDuration.WithLabelValues(msg.Topic).Observe(time.Since(start).Seconds())
+		k.m.Kafka.ConsumerInFlight.WithLabelValues(msg.Topic).Dec()
+	}
+	return ok
+}
+
+// process обрабатывает сообщение; false - контекст сессии отменён до того, как сообщение обработано
+// или передано в топик задержки/DLQ
+func (k *KafkaBrokerConsumer) process(ctx context.Context, msg *sarama.ConsumerMessage) bool {
+	m, err := inboxMessage(msg)
+	if err == nil {
+		err = k.consume(ctx, m)
+	}
+	if err == nil {
+		return true
+	}
+	if ctx.Err() != nil {
+		return false
+	}
+	return k.handleError(ctx, msg, m, err)
+}
+
+// consume передаёт сообщение обработчику исходного топика и типа сообщения
+func (k *KafkaBrokerConsumer) consume(ctx context.Context, m entity.InboxMessage) error {
+	handle, ok := k.handlers.Handler(m.Topic, m.EventType)
+	if !ok {
+		return fmt.Errorf("[topic: %s, type: %q] %w", m.Topic, m.EventType, appers.ErrNoMessageHandler)
+	}
+
+	processed, err := handle(ctx, m)
+	if k.m != nil {
+		result := "processed"
+		switch {
+		case err != nil:
+			result = "failed"
+		case !processed:
+			result = "duplicate"
+		}
+		k.m.Kafka.ConsumerInboxTotal.WithLabelValues(m.Topic, result).Inc()
+	}
+	return err
+}
+
+// retryInPlace повторяет обработку с backoff, пока не получится; false - контекст сессии отменён
+func (k *KafkaBrokerConsumer) retryInPlace(ctx context.Context, m entity.InboxMessage) bool {
+	for attempt := 0; ; attempt++ {
+		// backoff растёт до потолка NextBackoffWithJitter (30m) и дальше не сдвигается
+		if err := common.SleepCtx(ctx, common.NextBackoffWithJitter(min(attempt, maxBackoffAttempt))); err != nil {
+			return false
+		}
+		err := k.consume(ctx, m)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		k.logger.Errorf("[message: %s] обработка не удалась, попытка %d: %v", m.ID, attempt+2, err)
+	}
 }
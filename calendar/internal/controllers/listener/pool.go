@@ -0,0 +1,129 @@
+package listener
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+const (
+	defaultWorkers   = 1
+	defaultQueueSize = 16
+)
+
+// partitionPool обрабатывает сообщения одной партиции в нескольких воркерах. Воркер выбирается по хешу ключа,
+// поэтому сообщения с одним ключом обрабатываются по порядку; сообщения без ключа распределяются по offset.
+type partitionPool struct {
+	queues  []chan *sarama.ConsumerMessage
+	offsets *offsetTracker
+	wg      sync.WaitGroup
+}
+
+// newPartitionPool запускает воркеры; process возвращает false, если сообщение не обработано (сессия закончилась)
+func newPartitionPool(ctx context.Context, workers, queueSize int, session sarama.ConsumerGroupSession, process func(ctx context.Context, msg *sarama.ConsumerMessage) bool) *partitionPool {
+	if workers < 1 {
+		workers = defaultWorkers
+	}
+	if queueSize < 1 {
+		queueSize = defaultQueueSize
+	}
+
+	p := &partitionPool{
+		queues:  make([]chan *sarama.ConsumerMessage, workers),
+		offsets: newOffsetTracker(session),
+	}
+	for i := range p.queues {
+		q := make(chan *sarama.ConsumerMessage, queueSize)
+		p.queues[i] = q
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for msg := range q {
+				// после отмены сессии очередь только вычитывается: offset этих сообщений не отмечается
+				if ctx.Err() != nil || !process(ctx, msg) {
+					continue
+				}
+				p.offsets.done(msg)
+			}
+		}()
+	}
+	return p
+}
+
+// dispatch ставит сообщение в очередь воркера; false - контекст сессии отменён
+func (p *partitionPool) dispatch(ctx context.Context, msg *sarama.ConsumerMessage) bool {
+	p.offsets.add(msg)
+
+	q := p.queues[p.worker(msg)]
+	select {
+	case q <- msg:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (p *partitionPool) worker(msg *sarama.ConsumerMessage) int {
+	if len(p.queues) == 1 {
+		return 0
+	}
+	if len(msg.Key) == 0 {
+		return int(msg.Offset % int64(len(p.queues)))
+	}
+	h := fnv.New32a()
+	_, _ = h.Write(msg.Key)
+	return int(h.Sum32() % uint32(len(p.queues)))
+}
+
+// drain закрывает очереди и ждёт, пока воркеры закончат начатые сообщения
+func (p *partitionPool) drain() {
+	for _, q := range p.queues {
+		close(q)
+	}
+	p.wg.Wait()
+}
+
+// offsetTracker отмечает offset только для непрерывного префикса обработанных сообщений: если сообщение
+// с меньшим offset ещё в работе (или не обработано), следующие за ним не коммитятся
+type offsetTracker struct {
+	mu        sync.Mutex
+	session   sarama.ConsumerGroupSession
+	pending   []*sarama.ConsumerMessage // в порядке offset
+	processed map[int64]struct{}
+}
+
+func newOffsetTracker(session sarama.ConsumerGroupSession) *offsetTracker {
+	return &offsetTracker{
+		session:   session,
+		processed: make(map[int64]struct{}),
+	}
+}
+
+func (t *offsetTracker) add(msg *sarama.ConsumerMessage) {
+	t.mu.Lock()
+	t.pending = append(t.pending, msg)
+	t.mu.Unlock()
+}
+
+func (t *offsetTracker) done(msg *sarama.ConsumerMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.processed[msg.Offset] = struct{}{}
+
+	var last *sarama.ConsumerMessage
+	for len(t.pending) > 0 {
+		if _, ok := t.processed[t.pending[0].Offset]; !ok {
+			break
+		}
+		last = t.pending[0]
+		delete(t.processed, last.Offset)
+		t.pending[0] = nil
+		t.pending = t.pending[1:]
+	}
+	if last != nil {
+		t.session.MarkMessage(last, "")
+	}
+}
@@ -0,0 +1,119 @@
+package listener
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// fakeSession запоминает отмеченные offset; остальные методы сессии в тестах не вызываются
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	marked []int64
+}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+func TestOffsetTracker(t *testing.T) {
+	tests := []struct {
+		name string
+		add  []int64
+		done []int64
+		// want offset, отмеченные в сессии (nil - ничего); pending - сообщения, оставшиеся в трекере
+		want    []int64
+		pending int
+	}{
+		{
+			name: "in order",
+			add:  []int64{10, 11, 12},
+			done: []int64{10, 11, 12},
+			want: []int64{10, 11, 12},
+		},
+		{
+			name: "reverse order commits once at the end",
+			add:  []int64{10, 11, 12},
+			done: []int64{12, 11, 10},
+			want: []int64{12},
+		},
+		{
+			name: "gap holds later offsets",
+			add:  []int64{10, 11, 12, 13},
+			done: []int64{11, 13, 10, 12},
+			want: []int64{11, 13},
+		},
+		{
+			name:    "unfinished first message",
+			add:     []int64{10, 11, 12},
+			done:    []int64{11, 12},
+			want:    nil,
+			pending: 3,
+		},
+		{
+			name:    "prefix committed, rest in flight",
+			add:     []int64{10, 11, 12},
+			done:    []int64{10, 12},
+			want:    []int64{10},
+			pending: 2,
+		},
+		{
+			name: "offsets with holes from compaction",
+			add:  []int64{3, 7, 20},
+			done: []int64{7, 3, 20},
+			want: []int64{7, 20},
+		},
+		{
+			name: "single message",
+			add:  []int64{42},
+			done: []int64{42},
+			want: []int64{42},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &fakeSession{}
+			tracker := newOffsetTracker(session)
+			msgs := make(map[int64]*sarama.ConsumerMessage)
+			for _, off := range tt.add {
+				msgs[off] = &sarama.ConsumerMessage{Offset: off}
+				tracker.add(msgs[off])
+			}
+			for _, off := range tt.done {
+				tracker.done(msgs[off])
+			}
+
+			if !reflect.DeepEqual(session.marked, tt.want) {
+				t.Errorf("marked = %v, want %v", session.marked, tt.want)
+			}
+			if len(tracker.pending) != tt.pending {
+				t.Errorf("pending = %d, want %d", len(tracker.pending), tt.pending)
+			}
+		})
+	}
+}
+
+func TestPartitionPoolWorker(t *testing.T) {
+	p := &partitionPool{queues: make([]chan *sarama.ConsumerMessage, 4)}
+
+	for i := 0; i < 10; i++ {
+		key := []byte(fmt.Sprintf("aggregate-%d", i))
+		first := p.worker(&sarama.ConsumerMessage{Key: key, Offset: 1})
+		if got := p.worker(&sarama.ConsumerMessage{Key: key, Offset: 100}); got != first {
+			t.Errorf("key %s: worker %d, then %d; want the same worker for one key", key, first, got)
+		}
+	}
+
+	for off := int64(0); off < 8; off++ {
+		if got, want := p.worker(&sarama.ConsumerMessage{Offset: off}), int(off%4); got != want {
+			t.Errorf("no key, offset %d: worker %d, want %d", off, got, want)
+		}
+	}
+
+	single := &partitionPool{queues: make([]chan *sarama.ConsumerMessage, 1)}
+	if got := single.worker(&sarama.ConsumerMessage{Key: []byte("k"), Offset: 5}); got != 0 {
+		t.Errorf("single worker: got %d", got)
+	}
+}
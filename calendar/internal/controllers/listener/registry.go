@@ -0,0 +1,48 @@
+package listener
+
+import (
+	"calendar/internal/application/entity"
+	"context"
+	"sort"
+)
+
+// Handler обработчик сообщения консьюмера; false - дубль (уже обработано)
+type Handler func(ctx context.Context, msg entity.InboxMessage) (bool, error)
+
+// Registry обработчики сообщений по топику и типу (ce_type или entity.CommandEventType для команд).
+// Пустой тип - обработчик по умолчанию для топика.
+type Registry struct {
+	handlers map[string]map[string]Handler
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]map[string]Handler)}
+}
+
+// Register привязывает обработчик к топику и типу сообщения; повторная регистрация заменяет обработчик
+func (r *Registry) Register(topic, eventType string, h Handler) {
+	if r.handlers[topic] == nil {
+		r.handlers[topic] = make(map[string]Handler)
+	}
+	r.handlers[topic][eventType] = h
+}
+
+// Topics топики, на которые есть обработчики
+func (r *Registry) Topics() []string {
+	topics := make([]string, 0, len(r.handlers))
+	for t := range r.handlers {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Handler обработчик для типа сообщения, иначе обработчик по умолчанию топика
+func (r *Registry) Handler(topic, eventType string) (Handler, bool) {
+	byType := r.handlers[topic]
+	if h, ok := byType[eventType]; ok {
+		return h, true
+	}
+	h, ok := byType[""]
+	return h, ok
+}
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"calendar/internal/application/entity"
+	"calendar/internal/transport/sink"
+	"context"
+	"errors"
+	"net/textproto"
+)
+
+// Sender канал доставки уведомлений
+type Sender interface {
+	Channel() entity.ReminderChannel
+	// Send доставляет уведомление получателю n.Recipient
+	Send(ctx context.Context, n *entity.Notification) error
+}
+
+// Senders подключённые каналы; канала нет - уведомления по нему не доставляются (SKIPPED)
+type Senders map[entity.ReminderChannel]Sender
+
+func NewSenders(senders ...Sender) Senders {
+	res := make(Senders, len(senders))
+	for _, s := range senders {
+		res[s.Channel()] = s
+	}
+	return res
+}
+
+// IsPermanent ошибка, которую бесполезно повторять: ответ SMTP 5xx, 4xx webhook (кроме 408 и 429)
+// или недопустимый адрес webhook
+func IsPermanent(err error) bool {
+	if errors.Is(err, ErrWebhookHostNotAllowed) {
+		return true
+	}
+	var perr *textproto.Error
+	if errors.As(err, &perr) {
+		return perr.Code >= 500
+	}
+	var herr *sink.HTTPStatusError
+	if errors.As(err, &herr) {
+		return sink.IsPermanent(err)
+	}
+	return false
+}
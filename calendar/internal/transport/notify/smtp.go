@@ -0,0 +1,187 @@
+package notify
+
+import (
+	"bytes"
+	"calendar/internal/application/entity"
+	"calendar/pkg/config"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+const defaultSMTPTimeout = 30 * time.Second
+
+// SMTPSender канал email: письмо text/plain (и text/html, если у шаблона есть HTML) через SMTP-сервер.
+// STARTTLS включается, если сервер его объявляет; PLAIN-авторизация net/smtp без TLS разрешена только к localhost.
+type SMTPSender struct {
+	addr    string
+	host    string
+	from    *mail.Address
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+func NewSMTPSender(conf config.SMTP) (*SMTPSender, error) {
+	host, _, err := net.SplitHostPort(conf.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("notification.smtp.addr: %w", err)
+	}
+	from, err := mail.ParseAddress(conf.From)
+	if err != nil {
+		return nil, fmt.Errorf("notification.smtp.from: %w", err)
+	}
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultSMTPTimeout
+	}
+
+	s := &SMTPSender{addr: conf.Addr, host: host, from: from, timeout: timeout}
+	if conf.Username != "" {
+		s.auth = smtp.PlainAuth("", conf.Username, conf.Password, host)
+	}
+	return s, nil
+}
+
+func (s *SMTPSender) Channel() entity.ReminderChannel { return entity.ReminderEmail }
+
+func (s *SMTPSender) Send(ctx context.Context, n *entity.Notification) error {
+	to, err := mail.ParseAddress(n.Recipient)
+	if err != nil {
+		// адрес не исправится сам: ответ 5xx не повторяется
+		return &textproto.Error{Code: 553, Msg: fmt.Sprintf("invalid recipient %q: %v", n.Recipient, err)}
+	}
+	msg, err := s.buildMessage(to, n)
+	if err != nil {
+		return fmt.Errorf("build message: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("smtp dial: %w", err)
+	}
+	// net/smtp не принимает контекст: весь диалог ограничен дедлайном соединения
+	deadline, _ := ctx.Deadline()
+	if err = conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("smtp deadline: %w", err)
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("smtp greeting: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if s.auth != nil {
+		if err = c.Auth(s.auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err = c.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	if err = c.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp rcpt to: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err = w.Write(msg); err != nil {
+		return fmt.Errorf("smtp write: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("smtp data end: %w", err)
+	}
+	return c.Quit()
+}
+
+// buildMessage письмо RFC 5322: тема в encoded-word, части в quoted-printable
+func (s *SMTPSender) buildMessage(to *mail.Address, n *entity.Notification) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", s.from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", n.Content.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", s.messageID(n))
+	header("MIME-Version", "1.0")
+
+	if n.Content.HTML == "" {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, n.Content.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", n.Content.Text},
+		{"text/html; charset=utf-8", n.Content.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// messageID уникален для попытки; ID уведомления помогает найти письмо в логах почтового сервера
+func (s *SMTPSender) messageID(n *entity.Notification) string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	domain := s.host
+	if _, d, ok := strings.Cut(s.from.Address, "@"); ok {
+		domain = d
+	}
+	return fmt.Sprintf("<notification-%d.%s@%s>", n.ID, hex.EncodeToString(b), domain)
+}
+
+// writeQuotedPrintable тело части с переводами строк CRLF
+func writeQuotedPrintable(w io.Writer, s string) error {
+	s = strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
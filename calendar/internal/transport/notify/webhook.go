@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bytes"
+	"calendar/internal/application/entity"
+	"calendar/internal/transport/sink"
+	"calendar/pkg/config"
+	"calendar/pkg/httpclient"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// IdempotencyHeader ID уведомления: одинаков во всех попытках, получатель дедуплицирует по нему
+	IdempotencyHeader = "Idempotency-Key"
+
+	defaultWebhookTimeout = 10 * time.Second
+	maxErrorBodyLength    = 512
+)
+
+// ErrWebhookHostNotAllowed URL пользователя указывает на loopback, частную или служебную сеть
+var ErrWebhookHostNotAllowed = errors.New("webhook host is not allowed")
+
+// webhookBody тело запроса webhook: отрисованный текст и исходное сообщение в data
+type webhookBody struct {
+	ID      int64                  `json:"id"`
+	Type    entity.OutboxEventType `json:"type"`
+	UserID  string                 `json:"userID"`
+	Locale  string                 `json:"locale"`
+	Subject string                 `json:"subject"`
+	Text    string                 `json:"text"`
+	Data    json.RawMessage        `json:"data"`
+}
+
+// WebhookSender канал webhook: POST JSON на URL получателя. Повторы делает воркер уведомлений,
+// поэтому client - обычный httpclient.Client без RetryClient.
+// Заголовки и подпись из конфигурации получает только notification.webhook.url: URL пользователя задаёт
+// сам пользователь, учётные данные оператора ему не отправляются.
+type WebhookSender struct {
+	client  httpclient.HTTPClient
+	url     string
+	headers http.Header
+	timeout time.Duration
+	secret  []byte
+}
+
+func NewWebhookSender(client httpclient.HTTPClient, conf config.NotificationWebhook) *WebhookSender {
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	return &WebhookSender{
+		client:  client,
+		url:     conf.URL,
+		headers: sink.ParseHeaders(conf.Headers),
+		timeout: timeout,
+		secret:  []byte(conf.Secret),
+	}
+}
+
+func (w *WebhookSender) Channel() entity.ReminderChannel { return entity.ReminderWebhook }
+
+func (w *WebhookSender) Send(ctx context.Context, n *entity.Notification) error {
+	body, err := json.Marshal(webhookBody{
+		ID:      n.ID,
+		Type:    n.EventType,
+		UserID:  n.UserID,
+		Locale:  n.Content.Locale,
+		Subject: n.Content.Subject,
+		Text:    n.Content.Text,
+		Data:    n.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("marshal webhook body: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	operator := w.url != "" && n.Recipient == w.url
+	if !operator {
+		// адрес мог смениться после сохранения настроек: проверяем при каждой отправке
+		if err := CheckWebhookURL(ctx, n.Recipient); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.Recipient, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	if operator {
+		for name, values := range w.headers {
+			req.Header[name] = values
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyHeader, "notification-"+strconv.FormatInt(n.ID, 10))
+	if operator && len(w.secret) > 0 {
+		req.Header.Set(sink.SignatureHeader, "sha256="+sink.Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		return &sink.HTTPStatusError{StatusCode: resp.StatusCode, Body: string(b)}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// CheckWebhookURL проверяет webhook пользователя: http(s) и хост, все адреса которого публичные
+// (не loopback, не частные, не link-local и не unspecified)
+func CheckWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWebhookHostNotAllowed, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q", ErrWebhookHostNotAllowed, u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: empty host", ErrWebhookHostNotAllowed)
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return fmt.Errorf("resolve webhook host %s: %w", host, err)
+		}
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrWebhookHostNotAllowed, host, ip)
+		}
+	}
+	return nil
+}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
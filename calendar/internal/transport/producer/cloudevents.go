@@ -0,0 +1,72 @@
+package producer
+
+import (
+	"calendar/internal/application/entity"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// CloudEvents 1.0, Kafka protocol binding
+// https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/bindings/kafka-protocol-binding.md
+const (
+	CloudEventsBinary     = "binary"
+	CloudEventsStructured = "structured"
+
+	cloudEventsSpecVersion   = "1.0"
+	defaultCloudEventsSource = "/calendar"
+
+	contentTypeHeader         = "content-type"
+	contentTypeJSON           = "application/json"
+	contentTypeCloudEventJSON = "application/cloudevents+json; charset=UTF-8"
+)
+
+// structuredCloudEvent конверт structured mode: атрибуты и data в теле сообщения
+type structuredCloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject"`
+	Time            string          `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// cloudEvent собирает заголовки и тело сообщения outbox.
+// ce_id - ID строки outbox: он не меняется между повторными отправками, консьюмеры дедуплицируют по нему.
+func (p *KafkaProducerConfig) cloudEvent(e entity.OutboxEvent) ([]sarama.RecordHeader, []byte, error) {
+	id := strconv.Itoa(e.ID)
+	eventTime := e.CreatedAt.UTC().Format(time.RFC3339Nano)
+
+	if p.ce.Mode == CloudEventsStructured {
+		value, err := json.Marshal(structuredCloudEvent{
+			SpecVersion:     cloudEventsSpecVersion,
+			ID:              id,
+			Source:          p.ce.Source,
+			Type:            string(e.EventType),
+			Subject:         e.AggregateID.String(),
+			Time:            eventTime,
+			DataContentType: contentTypeJSON,
+			Data:            e.Payload,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return []sarama.RecordHeader{
+			{Key: []byte(contentTypeHeader), Value: []byte(contentTypeCloudEventJSON)},
+		}, value, nil
+	}
+
+	return []sarama.RecordHeader{
+		{Key: []byte("ce_specversion"), Value: []byte(cloudEventsSpecVersion)},
+		{Key: []byte("ce_id"), Value: []byte(id)},
+		{Key: []byte("ce_source"), Value: []byte(p.ce.Source)},
+		{Key: []byte("ce_type"), Value: []byte(e.EventType)},
+		{Key: []byte("ce_subject"), Value: []byte(e.AggregateID.String())},
+		{Key: []byte("ce_time"), Value: []byte(eventTime)},
+		{Key: []byte(contentTypeHeader), Value: []byte(contentTypeJSON)},
+	}, e.Payload, nil
+}
@@ -2,13 +2,17 @@ package producer
 
 import (
 	"calendar/internal/application/common"
+	"calendar/internal/application/entity"
 	"calendar/pkg/broker"
+	"calendar/pkg/config"
 	"calendar/pkg/metrics"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -16,7 +20,10 @@ import (
 )
 
 type Producer interface {
-	ProduceMessage(ctx context.Context, ID int, message []byte) error
+	ProduceBatch(ctx context.Context, events []entity.OutboxEvent) map[int]error
+	ProduceDeadLetter(ctx context.Context, topic string, e entity.OutboxEvent) error
+	ProduceReply(ctx context.Context, res *entity.CommandResult) error
+	ProduceRecord(ctx context.Context, msg *sarama.ProducerMessage) error
 	HealthCheck(ctx context.Context) error
 }
 
@@ -24,19 +31,44 @@ type KafkaProducerConfig struct {
 	broker      *broker.KafkaBroker
 	logger      *zap.SugaredLogger
 	maxAttempts int
+	ce          config.CloudEvents
 	m           *metrics.Metrics
+	mode        string     // broker.ProducerMode*
+	txMu        sync.Mutex // транзакции Kafka последовательны
 }
 
-func NewProducer(broker *broker.KafkaBroker, logger *zap.SugaredLogger, maxAttempts int, m *metrics.Metrics) *KafkaProducerConfig {
+func NewProducer(broker *broker.KafkaBroker, logger *zap.SugaredLogger, maxAttempts int, ce config.CloudEvents, m *metrics.Metrics) *KafkaProducerConfig {
 	if maxAttempts < 1 {
 		maxAttempts = 1
 	}
+	switch ce.Mode {
+	case CloudEventsBinary, CloudEventsStructured:
+	case "":
+		ce.Mode = CloudEventsBinary
+	default:
+		logger.Warnf("unknown cloudEvents mode %q, using %s", ce.Mode, CloudEventsBinary)
+		ce.Mode = CloudEventsBinary
+	}
+	if ce.Source == "" {
+		ce.Source = defaultCloudEventsSource
+	}
+
+	var mode string
+	if broker != nil {
+		mode = broker.ProducerMode
+	}
+	if mode != "" {
+		// повторы делает идемпотентный продюсер sarama; ручной повтор - новая последовательность, т.е. возможный дубль
+		maxAttempts = 1
+	}
 
 	return &KafkaProducerConfig{
 		broker:      broker,
 		logger:      logger,
 		maxAttempts: maxAttempts,
+		ce:          ce,
 		m:           m,
+		mode:        mode,
 	}
 }
 
@@ -50,24 +82,58 @@ func (p *KafkaProducerConfig) HealthCheck(ctx context.Context) error {
 	return p.broker.HealthCheck(ctx)
 }
 
-func (p *KafkaProducerConfig) ProduceMessage(ctx context.Context, id int, message []byte) error {
+// ProduceBatch отправляет батч outbox одним SendMessages. Ключ - AggregateID: все сообщения об одном событии
+// попадают в одну партицию (HashPartitioner) и читаются консьюмерами в порядке отправки.
+// Повторяются только сообщения с retryable ошибкой (до maxAttempts), порядок агрегата не нарушается:
+// в батче не больше одной строки на агрегат.
+// Возвращает ошибки по ID outbox; сообщений без ошибки в результате нет.
+func (p *KafkaProducerConfig) ProduceBatch(ctx context.Context, events []entity.OutboxEvent) map[int]error {
 	topic := p.broker.ProducerTopic
-	var lastErr error
+	failed := make(map[int]error)
+
+	pending := make([]*sarama.ProducerMessage, 0, len(events))
+	for _, e := range events {
+		headers, value, err := p.cloudEvent(e)
+		if err != nil {
+			if p.m != nil {
+				p.m.Kafka.ProducerOperationsTotal.WithLabelValues(topic, "permanent").Inc()
+			}
+			failed[e.ID] = fmt.Errorf("build cloudevent: %w", err)
+			continue
+		}
+		p.observeResend(topic, e)
+		pending = append(pending, &sarama.ProducerMessage{
+			Topic:    topic,
+			Key:      sarama.StringEncoder(e.AggregateID.String()),
+			Value:    sarama.ByteEncoder(value),
+			Headers:  headers,
+			Metadata: e.ID,
+		})
+	}
 
-	for attempt := 1; attempt <= p.maxAttempts; attempt++ {
+	var lastErrs map[int]error
+	for attempt := 1; len(pending) > 0; attempt++ {
 		if err := ctx.Err(); err != nil {
-			return err
+			for _, msg := range pending {
+				failed[msg.Metadata.(int)] = err
+			}
+			return failed
+		}
+		for _, msg := range pending {
+			if prev, ok := lastErrs[msg.Metadata.(int)]; ok {
+				p.observeRetry(topic, "producer", ClassifyRetry(prev))
+			}
 		}
 
-		msg := &sarama.ProducerMessage{
-			Topic:     topic,
-			Key:       sarama.StringEncoder(strconv.FormatInt(int64(id), 10)),
-			Value:     sarama.ByteEncoder(message),
-			Timestamp: time.Now(),
+		now := time.Now()
+		for _, msg := range pending {
+			msg.Timestamp = now
 		}
 
 		t0 := time.Now()
-		part, off, err := p.broker.SyncProducer.SendMessage(msg)
+		err := p.inTxn(func() error {
+			return p.broker.SyncProducer.SendMessages(pending)
+		})
 		rt := time.Since(t0)
 
 		//Metric: attempt latency: ok/error
@@ -79,49 +145,204 @@ func (p *KafkaProducerConfig) ProduceMessage(ctx context.Context, id int, messag
 			p.m.Kafka.ProducerAttemptLatencySeconds.WithLabelValues(topic, res).Observe(rt.Seconds())
 		}
 
-		if err == nil {
-			// Metric success
-			if p.m != nil {
-				p.m.Kafka.ProducerOperationsTotal.WithLabelValues(topic, "success").Inc()
-				p.m.Kafka.ProducerSuccessAttempts.WithLabelValues(topic).Observe(float64(attempt))
+		// ошибки по сообщениям; любая другая ошибка относится ко всему батчу
+		errs := make(map[int]error, len(pending))
+		if err != nil {
+			var perrs sarama.ProducerErrors
+			if errors.As(err, &perrs) {
+				for _, pe := range perrs {
+					errs[pe.Msg.Metadata.(int)] = pe.Err
+				}
+				if p.mode == broker.ProducerModeTransactional {
+					// транзакция прервана целиком: остальные сообщения батча тоже не видны консьюмерам.
+					// %v, а не %w: постоянная ошибка соседа не должна переводить их в GAVE_UP
+					for _, msg := range pending {
+						if _, ok := errs[msg.Metadata.(int)]; !ok {
+							errs[msg.Metadata.(int)] = fmt.Errorf("kafka transaction aborted: %v", err)
+						}
+					}
+				}
+			} else {
+				for _, msg := range pending {
+					errs[msg.Metadata.(int)] = err
+				}
 			}
-			p.logger.Infof("[transactionID %s] sent topic=%s partition=%d offset=%d attempt=%d rt=%s",
-				id, p.broker.ProducerTopic, part, off, attempt, rt)
-			return nil
 		}
 
-		lastErr = err
+		retry := pending[:0]
+		for _, msg := range pending {
+			id := msg.Metadata.(int)
+			msgErr, ok := errs[id]
+			if !ok {
+				if p.m != nil {
+					p.m.Kafka.ProducerOperationsTotal.WithLabelValues(topic, "success").Inc()
+					p.m.Kafka.ProducerSuccessAttempts.WithLabelValues(topic).Observe(float64(attempt))
+				}
+				p.logger.Infof("[ID %d] sent topic=%s partition=%d offset=%d attempt=%d rt=%s",
+					id, topic, msg.Partition, msg.Offset, attempt, rt)
+				continue
+			}
 
-		if kerr, ok := err.(sarama.KError); ok {
-			if isPermanent(kerr) {
+			if IsPermanent(msgErr) {
 				if p.m != nil {
 					p.m.Kafka.ProducerOperationsTotal.WithLabelValues(topic, "permanent").Inc()
 				}
-				p.logger.Errorf("[transactionID %s] permanent kafka error attempt=%d rt=%s kafka_error=%s code=%d", id, attempt, rt, kerr.Error(), int16(kerr))
-				return fmt.Errorf("permanent kafka error: %w", kerr)
+				p.logger.Errorf("[ID %d] permanent kafka error attempt=%d rt=%s err=%v", id, attempt, rt, msgErr)
+				failed[id] = fmt.Errorf("permanent kafka error: %w", msgErr)
+				continue
+			}
+
+			if attempt == p.maxAttempts {
+				p.logger.Errorf("[ID %d] produce_failed after %d attempts: %v", id, p.maxAttempts, msgErr)
+				failed[id] = fmt.Errorf("produce failed after %d attempts: %w", p.maxAttempts, msgErr)
+				continue
 			}
 
-			p.logger.Warnf("[transactionID %s] retryable kafka error attempt=%d rt=%s kafka_error=%s code=%d",
-				id, attempt, rt, kerr.Error(), int16(kerr))
-		} else {
-			p.logger.Warnf("[transactionID %s] retryable non-kafka error attempt=%d rt=%s err=%v",
-				id, attempt, rt, err)
+			p.logger.Warnf("[ID %d] retryable kafka error attempt=%d rt=%s err=%v", id, attempt, rt, msgErr)
+			retry = append(retry, msg)
 		}
+		pending = retry
+		lastErrs = errs
 
-		if attempt == p.maxAttempts {
+		if len(pending) == 0 {
 			break
 		}
-
 		if err := common.SleepCtx(ctx, common.NextBackoffWithJitter(attempt-1)); err != nil {
 			// отмена/таймаут контекста считаем как canceled
 			if p.m != nil {
-				p.m.Kafka.ProducerOperationsTotal.WithLabelValues(topic, "canceled").Inc()
+				p.m.Kafka.ProducerOperationsTotal.WithLabelValues(topic, "canceled").Add(float64(len(pending)))
+			}
+			for _, msg := range pending {
+				failed[msg.Metadata.(int)] = err
 			}
-			return err
+			return failed
+		}
+	}
+
+	return failed
+}
+
+// ProduceDeadLetter одна попытка отправить сообщение в dead letter топик: тот же CloudEvent,
+// что и в основной топик, плюс заголовки dlq_* с диагностикой
+func (p *KafkaProducerConfig) ProduceDeadLetter(ctx context.Context, topic string, e entity.OutboxEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	headers, value, err := p.cloudEvent(e)
+	if err != nil {
+		return fmt.Errorf("build cloudevent: %w", err)
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte("dlq_original_topic"), Value: []byte(p.broker.ProducerTopic)},
+		sarama.RecordHeader{Key: []byte("dlq_attempts"), Value: []byte(strconv.Itoa(e.Attempts + 1))},
+		sarama.RecordHeader{Key: []byte("dlq_error"), Value: []byte(e.LastError)},
+		sarama.RecordHeader{Key: []byte("dlq_error_class"), Value: []byte(e.ErrorClass)},
+	)
+
+	msg := &sarama.ProducerMessage{
+		Topic:     topic,
+		Key:       sarama.StringEncoder(e.AggregateID.String()),
+		Value:     sarama.ByteEncoder(value),
+		Headers:   headers,
+		Timestamp: time.Now(),
+	}
+
+	var part int32
+	var off int64
+	err = p.inTxn(func() (err error) {
+		part, off, err = p.broker.SyncProducer.SendMessage(msg)
+		return err
+	})
+	if err != nil {
+		if p.m != nil {
+			p.m.Kafka.ProducerOperationsTotal.WithLabelValues(topic, "failed").Inc()
 		}
+		return fmt.Errorf("produce dead letter: %w", err)
+	}
+	if p.m != nil {
+		p.m.Kafka.ProducerOperationsTotal.WithLabelValues(topic, "success").Inc()
+	}
+	p.logger.Warnf("[ID %d] dead letter sent topic=%s partition=%d offset=%d class=%s", e.ID, topic, part, off, e.ErrorClass)
+	return nil
+}
+
+// ProduceReply одна попытка отправить ответ на команду в broker.kafka.replyTopic; ключ и заголовок
+// command_id - ID команды. Без replyTopic ответ не отправляется.
+func (p *KafkaProducerConfig) ProduceReply(ctx context.Context, res *entity.CommandResult) error {
+	topic := p.broker.ReplyTopic
+	if topic == "" {
+		p.logger.Debugf("[command %s] reply topic is not configured, result: %s", res.CommandID, res.Status)
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(res)
+	if err != nil {
+		return fmt.Errorf("marshal command result: %w", err)
+	}
+
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(res.CommandID),
+		Value: sarama.ByteEncoder(value),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte(entity.CommandIDHeader), Value: []byte(res.CommandID)},
+			{Key: []byte(contentTypeHeader), Value: []byte(contentTypeJSON)},
+		},
+		Timestamp: time.Now(),
 	}
-	p.logger.Errorf("[transactionID %s] produce_failed after %d attempts: %v", id, p.maxAttempts, lastErr)
-	return fmt.Errorf("produce failed after %d attempts: %w", p.maxAttempts, lastErr)
+
+	var part int32
+	var off int64
+	err = p.inTxn(func() (err error) {
+		part, off, err = p.broker.SyncProducer.SendMessage(msg)
+		return err
+	})
+	if err != nil {
+		if p.m != nil {
+			p.m.Kafka.ProducerOperationsTotal.WithLabelValues(topic, "failed").Inc()
+		}
+		return fmt.Errorf("produce command result: %w", err)
+	}
+	if p.m != nil {
+		p.m.Kafka.ProducerOperationsTotal.WithLabelValues(topic, "success").Inc()
+	}
+	p.logger.Infof("[command %s] result %s sent topic=%s partition=%d offset=%d", res.CommandID, res.Status, topic, part, off)
+	return nil
+}
+
+// ProduceRecord одна попытка отправить готовое сообщение (retry-топики и DLQ консьюмера)
+func (p *KafkaProducerConfig) ProduceRecord(ctx context.Context, msg *sarama.ProducerMessage) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var part int32
+	var off int64
+	err := p.inTxn(func() (err error) {
+		part, off, err = p.broker.SyncProducer.SendMessage(msg)
+		return err
+	})
+	if err != nil {
+		if p.m != nil {
+			p.m.Kafka.ProducerOperationsTotal.WithLabelValues(msg.Topic, "failed").Inc()
+		}
+		return fmt.Errorf("produce to %s: %w", msg.Topic, err)
+	}
+	if p.m != nil {
+		p.m.Kafka.ProducerOperationsTotal.WithLabelValues(msg.Topic, "success").Inc()
+	}
+	p.logger.Debugf("record sent topic=%s partition=%d offset=%d", msg.Topic, part, off)
+	return nil
+}
+
+// IsPermanent ошибка, которую бесполезно повторять (см. isPermanent)
+func IsPermanent(err error) bool {
+	var kerr sarama.KError
+	return errors.As(err, &kerr) && isPermanent(kerr)
 }
 
 func isPermanent(k sarama.KError) bool {
@@ -139,7 +360,8 @@ func isPermanent(k sarama.KError) bool {
 }
 
 func ClassifyRetry(err error) string {
-	if k, ok := err.(sarama.KError); ok {
+	var k sarama.KError
+	if errors.As(err, &k) {
 		switch k {
 		case sarama.ErrLeaderNotAvailable:
 			return "leader_not_available"
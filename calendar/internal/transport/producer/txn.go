@@ -0,0 +1,75 @@
+package producer
+
+import (
+	"calendar/internal/application/entity"
+	"calendar/pkg/broker"
+	"fmt"
+)
+
+// ErrorClassMarkSent сообщение отправлено, но не удалось отметить его SENT: повторная отправка - дубль
+const ErrorClassMarkSent = "mark_sent_failed"
+
+// inTxn выполняет отправку в транзакции Kafka (режим transactional): консьюмеры с read_committed
+// видят сообщения только после CommitTxn, при ошибке транзакция прерывается целиком.
+// Транзакции одного продюсера выполняются последовательно.
+func (p *KafkaProducerConfig) inTxn(send func() error) error {
+	if p.mode != broker.ProducerModeTransactional {
+		return send()
+	}
+
+	p.txMu.Lock()
+	defer p.txMu.Unlock()
+
+	sp := p.broker.SyncProducer
+	if err := sp.BeginTxn(); err != nil {
+		return fmt.Errorf("begin kafka txn: %w", err)
+	}
+	if err := send(); err != nil {
+		if aerr := sp.AbortTxn(); aerr != nil {
+			p.logger.Errorf("abort kafka txn failed: %v", aerr)
+		}
+		return err
+	}
+	if err := sp.CommitTxn(); err != nil {
+		if aerr := sp.AbortTxn(); aerr != nil {
+			p.logger.Errorf("abort kafka txn failed: %v", aerr)
+		}
+		return fmt.Errorf("commit kafka txn: %w", err)
+	}
+	return nil
+}
+
+// isAmbiguous ошибка, после которой неизвестно, записал ли брокер сообщение
+func isAmbiguous(errorClass string) bool {
+	switch errorClass {
+	case "broker_timeout", "net_timeout", "client_deadline":
+		return true
+	default:
+		return false
+	}
+}
+
+// observeRetry учитывает повторную отправку сообщения. Повтор - любая отправка после неудачной попытки;
+// дубль - повтор сообщения, которое брокер мог уже записать и не отбросит: после неоднозначной ошибки
+// (кроме transactional: прерванная транзакция не видна консьюмерам) или после ошибки отметки SENT.
+func (p *KafkaProducerConfig) observeRetry(topic, source, errorClass string) {
+	if p.m == nil {
+		return
+	}
+	p.m.Kafka.ProducerRetriesTotal.WithLabelValues(topic, source).Inc()
+
+	switch {
+	case errorClass == ErrorClassMarkSent:
+		p.m.Kafka.ProducerDuplicatesTotal.WithLabelValues(topic, "mark_sent_failed").Inc()
+	case isAmbiguous(errorClass) && p.mode != broker.ProducerModeTransactional:
+		p.m.Kafka.ProducerDuplicatesTotal.WithLabelValues(topic, "ambiguous_error").Inc()
+	}
+}
+
+// observeResend учитывает повторную отправку строки outbox relay-ем (attempts > 0 или requeue после ошибки)
+func (p *KafkaProducerConfig) observeResend(topic string, e entity.OutboxEvent) {
+	if e.Attempts == 0 && e.ErrorClass == "" {
+		return
+	}
+	p.observeRetry(topic, "relay", e.ErrorClass)
+}
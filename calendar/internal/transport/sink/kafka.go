@@ -0,0 +1,24 @@
+package sink
+
+import (
+	"calendar/internal/application/entity"
+	"calendar/internal/transport/producer"
+	"context"
+)
+
+const KafkaSinkName = "kafka"
+
+// KafkaSink отправка батча в writerTopic через producer.Producer
+type KafkaSink struct {
+	producer producer.Producer
+}
+
+func NewKafkaSink(p producer.Producer) *KafkaSink {
+	return &KafkaSink{producer: p}
+}
+
+func (k *KafkaSink) Name() string { return KafkaSinkName }
+
+func (k *KafkaSink) Send(ctx context.Context, events []entity.OutboxEvent) map[int]error {
+	return k.producer.ProduceBatch(ctx, events)
+}
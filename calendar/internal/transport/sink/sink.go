@@ -0,0 +1,155 @@
+package sink
+
+import (
+	"calendar/internal/application/entity"
+	"calendar/internal/transport/producer"
+	"calendar/pkg/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Sink получатель сообщений outbox
+type Sink interface {
+	Name() string
+	// Send отправляет батч; возвращает ошибки по ID outbox, отправленных сообщений в результате нет
+	Send(ctx context.Context, events []entity.OutboxEvent) map[int]error
+}
+
+// Delivered доставки по сообщениям: outbox_id -> имена sink-ов
+type Delivered map[int]map[string]struct{}
+
+type route struct {
+	sink  Sink
+	types map[entity.OutboxEventType]struct{} // nil - все типы
+}
+
+// Router раздаёт сообщения outbox sink-ам по типу сообщения
+type Router struct {
+	routes []route
+	logger *zap.SugaredLogger
+	m      *metrics.Metrics
+}
+
+func NewRouter(logger *zap.SugaredLogger, m *metrics.Metrics) *Router {
+	return &Router{logger: logger, m: m}
+}
+
+// Add подключает sink для типов eventTypes (через запятую, пусто - все типы)
+func (r *Router) Add(s Sink, eventTypes string) {
+	rt := route{sink: s}
+	for _, t := range strings.Split(eventTypes, ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if rt.types == nil {
+			rt.types = make(map[entity.OutboxEventType]struct{})
+		}
+		rt.types[entity.OutboxEventType(t)] = struct{}{}
+	}
+	r.routes = append(r.routes, rt)
+	r.logger.Infow("relay sink registered", "sink", s.Name(), "eventTypes", eventTypes)
+}
+
+// Names имена подключённых sink-ов
+func (r *Router) Names() []string {
+	names := make([]string, 0, len(r.routes))
+	for _, rt := range r.routes {
+		names = append(names, rt.sink.Name())
+	}
+	return names
+}
+
+func (rt route) match(t entity.OutboxEventType) bool {
+	if rt.types == nil {
+		return true
+	}
+	_, ok := rt.types[t]
+	return ok
+}
+
+// Deliver отправляет сообщения во все подходящие по типу sink-и параллельно, пропуская уже доставленные.
+// Возвращает успешные доставки (sink -> ID outbox) и первую ошибку по каждому сообщению;
+// сообщение без ошибки доставлено во все свои sink-и (или не подходит ни одному).
+func (r *Router) Deliver(ctx context.Context, events []entity.OutboxEvent, delivered Delivered) (map[string][]int, map[int]error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	succeeded := make(map[string][]int)
+	failed := make(map[int]error)
+
+	for _, rt := range r.routes {
+		name := rt.sink.Name()
+		batch := make([]entity.OutboxEvent, 0, len(events))
+		for _, e := range events {
+			if !rt.match(e.EventType) {
+				continue
+			}
+			if _, ok := delivered[e.ID][name]; ok {
+				continue
+			}
+			batch = append(batch, e)
+		}
+		if len(batch) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(s Sink, batch []entity.OutboxEvent) {
+			defer wg.Done()
+			errs := s.Send(ctx, batch)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, e := range batch {
+				if err, ok := errs[e.ID]; ok {
+					if _, seen := failed[e.ID]; !seen {
+						failed[e.ID] = fmt.Errorf("sink %s: %w", name, err)
+					}
+					continue
+				}
+				succeeded[name] = append(succeeded[name], e.ID)
+			}
+			if r.m != nil {
+				r.m.Outbox.SinkDeliveriesTotal.WithLabelValues(name, "success").Add(float64(len(batch) - len(errs)))
+				r.m.Outbox.SinkDeliveriesTotal.WithLabelValues(name, "failed").Add(float64(len(errs)))
+			}
+		}(rt.sink, batch)
+	}
+	wg.Wait()
+
+	return succeeded, failed
+}
+
+// HTTPStatusError ответ webhook с кодом не 2xx
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("webhook status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsPermanent ошибка, которую бесполезно повторять: постоянная ошибка Kafka или 4xx webhook (кроме 408 и 429)
+func IsPermanent(err error) bool {
+	var herr *HTTPStatusError
+	if errors.As(err, &herr) {
+		return herr.StatusCode >= 400 && herr.StatusCode < 500 &&
+			herr.StatusCode != http.StatusRequestTimeout && herr.StatusCode != http.StatusTooManyRequests
+	}
+	return producer.IsPermanent(err)
+}
+
+// ClassifyError класс ошибки для error_class: http_<код> для webhook, иначе producer.ClassifyRetry
+func ClassifyError(err error) string {
+	var herr *HTTPStatusError
+	if errors.As(err, &herr) {
+		return fmt.Sprintf("http_%d", herr.StatusCode)
+	}
+	return producer.ClassifyRetry(err)
+}
@@ -0,0 +1,132 @@
+package sink
+
+import (
+	"bytes"
+	"calendar/internal/application/entity"
+	"calendar/pkg/config"
+	"calendar/pkg/httpclient"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	WebhookSinkName = "webhook"
+
+	// SignatureHeader подпись тела: sha256=<hex HMAC-SHA256(secret, body)>
+	SignatureHeader = "X-Calendar-Signature"
+
+	defaultWebhookTimeout = 10 * time.Second
+	maxErrorBodyLength    = 512
+)
+
+// WebhookSink POST каждого сообщения на URL в формате CloudEvents HTTP binary mode:
+// атрибуты в заголовках ce-*, тело - payload. ce-id - ID outbox, получатель дедуплицирует по нему.
+type WebhookSink struct {
+	client  httpclient.HTTPClient
+	url     string
+	headers http.Header
+	timeout time.Duration
+	secret  []byte
+	source  string
+	logger  *zap.SugaredLogger
+}
+
+// NewWebhookSink client - обычно httpclient.RetryClient: 5xx, 429 и сетевые ошибки он повторяет сам
+func NewWebhookSink(client httpclient.HTTPClient, conf config.WebhookSink, source string, logger *zap.SugaredLogger) *WebhookSink {
+	timeout := conf.Timeout
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	return &WebhookSink{
+		client:  client,
+		url:     conf.URL,
+		headers: ParseHeaders(conf.Headers),
+		timeout: timeout,
+		secret:  []byte(conf.Secret),
+		source:  source,
+		logger:  logger,
+	}
+}
+
+func (w *WebhookSink) Name() string { return WebhookSinkName }
+
+// Send отправляет сообщения по одному: в батче не больше одного сообщения на агрегат, порядок не важен
+func (w *WebhookSink) Send(ctx context.Context, events []entity.OutboxEvent) map[int]error {
+	failed := make(map[int]error)
+	for _, e := range events {
+		if err := w.send(ctx, e); err != nil {
+			w.logger.Errorf("[ID %d] webhook send failed, err: %v", e.ID, err)
+			failed[e.ID] = err
+			continue
+		}
+		w.logger.Infof("[ID %d] sent to webhook", e.ID)
+	}
+	return failed
+}
+
+func (w *WebhookSink) send(ctx context.Context, e entity.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(e.Payload))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	for name, values := range w.headers {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("ce-specversion", "1.0")
+	req.Header.Set("ce-id", strconv.Itoa(e.ID))
+	req.Header.Set("ce-source", w.source)
+	req.Header.Set("ce-type", string(e.EventType))
+	req.Header.Set("ce-subject", e.AggregateID.String())
+	req.Header.Set("ce-time", e.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.secret, e.Payload))
+	}
+
+	resp, err := w.client.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLength))
+		return &HTTPStatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Sign HMAC-SHA256 тела в hex; получатель проверяет заголовок X-Calendar-Signature тем же секретом
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseHeaders разбирает "Name: value;Name2: value2"
+func ParseHeaders(s string) http.Header {
+	h := make(http.Header)
+	for _, kv := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(kv, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
+		}
+		h.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return h
+}
@@ -4,6 +4,7 @@ import (
 	"calendar/pkg/config"
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -13,20 +14,36 @@ import (
 )
 
 const (
-	_consumerGroup = "consumer-group"
+	_defaultConsumerGroup = "consumer-group"
+
+	// ProducerModeIdempotent идемпотентный продюсер: брокер отбрасывает дубли повторов sarama
+	ProducerModeIdempotent = "idempotent"
+	// ProducerModeTransactional идемпотентный продюсер с транзакциями Kafka
+	ProducerModeTransactional = "transactional"
+
+	_defaultTransactionalIDPrefix = "calendar-relay-"
 )
 
 type KafkaBroker struct {
 	ConsumerTopic string
 	ProducerTopic string
+	ReplyTopic    string
 	ConsumerGroup sarama.ConsumerGroup
 	SyncProducer  sarama.SyncProducer
+	ProducerMode  string
 	Brokers       []string
 	conf          config.Kafka
 	logger        *zap.SugaredLogger
 }
 
 func NewKafkaBroker(conf config.Kafka, logger *zap.SugaredLogger) (*KafkaBroker, error) {
+	switch conf.ProducerMode {
+	case "", ProducerModeIdempotent, ProducerModeTransactional:
+	default:
+		logger.Warnf("Неизвестный режим продюсера %q, используется at-least-once\n", conf.ProducerMode)
+		conf.ProducerMode = ""
+	}
+
 	logger.Debugf("Создание consumer group для brokers: %s\n", conf.Brokers)
 	consumerGroup, err := newConsumerGroup(conf)
 	if err != nil {
@@ -41,14 +58,16 @@ func NewKafkaBroker(conf config.Kafka, logger *zap.SugaredLogger) (*KafkaBroker,
 		logger.Errorf("Ошибка создания producer: %v\n", err)
 		return nil, fmt.Errorf("%w", err)
 	}
-	logger.Infof("Producer создан успешно\n")
+	logger.Infof("Producer создан успешно, режим: %q\n", conf.ProducerMode)
 
 	brokers := strings.Split(conf.Brokers, ",")
 	broker := &KafkaBroker{
 		ConsumerTopic: conf.ReaderTopic,
 		ProducerTopic: conf.WriterTopic,
+		ReplyTopic:    conf.ReplyTopic,
 		ConsumerGroup: consumerGroup,
 		SyncProducer:  syncProducer,
+		ProducerMode:  conf.ProducerMode,
 		Brokers:       brokers,
 		conf:          conf,
 		logger:        logger,
@@ -150,10 +169,23 @@ func (z *zapSarama) Println(v ...interface{})               { z.l.Debug(v...) }
 func newConsumerGroup(conf config.Kafka) (sarama.ConsumerGroup, error) {
 	kafkaConfig := sarama.NewConfig()
 	applySASLConfig(kafkaConfig, conf, false) // используем Reader credentials
+	if conf.ProducerMode == ProducerModeTransactional {
+		// не читаем сообщения прерванных транзакций
+		kafkaConfig.Consumer.IsolationLevel = sarama.ReadCommitted
+	}
+
+	if err := applyConsumerGroup(kafkaConfig, conf.Consumer); err != nil {
+		return nil, err
+	}
+
+	groupID := conf.Consumer.GroupID
+	if groupID == "" {
+		groupID = _defaultConsumerGroup
+	}
 
 	brokers := strings.Split(conf.Brokers, ",")
 
-	consumer, err := sarama.NewConsumerGroup(brokers, _consumerGroup, kafkaConfig)
+	consumer, err := sarama.NewConsumerGroup(brokers, groupID, kafkaConfig)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при создании Kafka Consumer Group: %w", err)
 	}
@@ -161,6 +193,44 @@ func newConsumerGroup(conf config.Kafka) (sarama.ConsumerGroup, error) {
 	return consumer, nil
 }
 
+// applyConsumerGroup начальный offset, стратегия ребаланса и таймауты сессии; пустые значения - умолчания sarama
+func applyConsumerGroup(cfg *sarama.Config, conf config.Consumer) error {
+	switch strings.ToLower(conf.InitialOffset) {
+	case "", "newest":
+		cfg.Consumer.Offsets.Initial = sarama.OffsetNewest
+	case "oldest":
+		cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+	default:
+		return fmt.Errorf("неизвестный initialOffset %q: ожидается newest или oldest", conf.InitialOffset)
+	}
+
+	switch strings.ToLower(conf.RebalanceStrategy) {
+	case "", sarama.RangeBalanceStrategyName:
+		cfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRange()}
+	case sarama.RoundRobinBalanceStrategyName:
+		cfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
+	case sarama.StickyBalanceStrategyName:
+		cfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
+	default:
+		return fmt.Errorf("неизвестная rebalanceStrategy %q: ожидается range, roundrobin или sticky", conf.RebalanceStrategy)
+	}
+
+	if conf.SessionTimeout > 0 {
+		cfg.Consumer.Group.Session.Timeout = conf.SessionTimeout
+	}
+	if conf.HeartbeatInterval > 0 {
+		cfg.Consumer.Group.Heartbeat.Interval = conf.HeartbeatInterval
+	}
+	if conf.RebalanceTimeout > 0 {
+		cfg.Consumer.Group.Rebalance.Timeout = conf.RebalanceTimeout
+	}
+	if cfg.Consumer.Group.Heartbeat.Interval >= cfg.Consumer.Group.Session.Timeout {
+		return fmt.Errorf("heartbeatInterval %s должен быть меньше sessionTimeout %s",
+			cfg.Consumer.Group.Heartbeat.Interval, cfg.Consumer.Group.Session.Timeout)
+	}
+	return nil
+}
+
 func newSyncProducer(conf config.Kafka) (sarama.SyncProducer, error) {
 	kafkaConfig := sarama.NewConfig()
 
@@ -181,6 +251,10 @@ func newSyncProducer(conf config.Kafka) (sarama.SyncProducer, error) {
 	kafkaConfig.Producer.Timeout = 10 * time.Second
 	kafkaConfig.Producer.Partitioner = sarama.NewHashPartitioner
 
+	if err := applyProducerMode(kafkaConfig, conf); err != nil {
+		return nil, err
+	}
+
 	applySASLConfig(kafkaConfig, conf, true) // используем Writer credentials
 
 	brokers := strings.Split(conf.Brokers, ",")
@@ -192,3 +266,32 @@ func newSyncProducer(conf config.Kafka) (sarama.SyncProducer, error) {
 
 	return producer, nil
 }
+
+// applyProducerMode включает идемпотентность (и транзакции) продюсера. Повторы в этих режимах делает sarama
+// с тем же producer ID и sequence number, поэтому брокер отбрасывает дубли; ручные повторы выключаются в producer.
+func applyProducerMode(cfg *sarama.Config, conf config.Kafka) error {
+	if conf.ProducerMode == "" {
+		return nil
+	}
+
+	retries := conf.MaxAttempts
+	if retries < 1 {
+		retries = 1
+	}
+	cfg.Producer.Idempotent = true
+	cfg.Producer.Retry.Max = retries
+	cfg.Net.MaxOpenRequests = 1
+
+	if conf.ProducerMode == ProducerModeTransactional {
+		id := conf.TransactionalID
+		if id == "" {
+			host, err := os.Hostname()
+			if err != nil {
+				return fmt.Errorf("transactional id: %w", err)
+			}
+			id = _defaultTransactionalIDPrefix + host
+		}
+		cfg.Producer.Transaction.ID = id
+	}
+	return nil
+}
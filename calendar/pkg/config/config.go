@@ -14,7 +14,10 @@ type Config struct {
 	Cron         Cron        `mapstructure:"cron"`
 	Realay       RelayConfig `mapstructure:"relay"`
 	HTTPClient   HTTPClient  `mapstructure:"httpClient"`
+	Auth         Auth        `mapstructure:"auth"`
 	LoggingLevel string      `mapstructure:"logging-level"`
+
+	Notification Notification `mapstructure:"notification"`
 }
 
 type Server struct {
@@ -25,6 +28,12 @@ type Server struct {
 	BodyLimit     int    `mapstructure:"body_limit"`
 }
 
+// Auth статические Bearer-токены служебных ролей и учётные данные CalDAV
+type Auth struct {
+	AdminTokens string `mapstructure:"adminTokens"` // токены роли admin через запятую; пусто - admin API закрыт
+	DavUsers    string `mapstructure:"davUsers"`    // пары user:password через запятую; пусто - CalDAV закрыт
+}
+
 type Postgres struct {
 	ConnString     string `mapstructure:"conn_string"`
 	MaxConnections int32  `mapstructure:"max_connections"`
@@ -42,7 +51,45 @@ type Kafka struct {
 	WriterTopic  string `mapstructure:"writerTopic"`
 	WriterUsr    string `mapstructure:"writerUsr"`
 	WriterUsrPwd string `mapstructure:"writerUsrPwd"`
+	ReplyTopic   string `mapstructure:"replyTopic"` // ответы на команды из readerTopic; пусто - без ответов
 	MaxAttempts  int    `mapstructure:"maxAttempts"`
+
+	// Режим продюсера: пусто - at-least-once (повторы вручную в ProduceBatch), idempotent - идемпотентный
+	// продюсер sarama (повторы внутри sarama без дублей), transactional - idempotent + транзакция Kafka на батч relay
+	ProducerMode    string `mapstructure:"producerMode"`
+	TransactionalID string `mapstructure:"transactionalID"` // для transactional, по умолчанию calendar-relay-<hostname>
+
+	CloudEvents CloudEvents   `mapstructure:"cloudEvents"`
+	Retry       ConsumerRetry `mapstructure:"retry"`
+	Consumer    Consumer      `mapstructure:"consumer"`
+}
+
+// Consumer настройки consumer group и параллельной обработки партиции: сообщения с одним ключом
+// обрабатывает один воркер по порядку
+type Consumer struct {
+	GroupID           string        `mapstructure:"groupID"`           // по умолчанию consumer-group
+	Topics            string        `mapstructure:"topics"`            // дополнительные топики через запятую, кроме readerTopic
+	InitialOffset     string        `mapstructure:"initialOffset"`     // newest (по умолчанию) или oldest - для группы без offset
+	RebalanceStrategy string        `mapstructure:"rebalanceStrategy"` // range (по умолчанию), roundrobin или sticky
+	SessionTimeout    time.Duration `mapstructure:"sessionTimeout"`    // по умолчанию 10s
+	HeartbeatInterval time.Duration `mapstructure:"heartbeatInterval"` // по умолчанию 3s, меньше трети sessionTimeout
+	RebalanceTimeout  time.Duration `mapstructure:"rebalanceTimeout"`  // по умолчанию 60s
+
+	Workers   int `mapstructure:"workers"`   // воркеров на партицию, по умолчанию 1 (последовательно)
+	QueueSize int `mapstructure:"queueSize"` // очередь воркера, по умолчанию 16
+}
+
+// ConsumerRetry обработка ошибок консьюмера: retryable ошибки - в топики задержки <readerTopic>-retry-<delay>
+// по очереди, постоянные и исчерпавшие задержки - в DLQ
+type ConsumerRetry struct {
+	Delays   string `mapstructure:"delays"`   // задержки через запятую, например 1m,10m,1h; пусто - повтор на месте
+	DLQTopic string `mapstructure:"dlqTopic"` // по умолчанию <readerTopic>-dlq
+}
+
+// CloudEvents формат сообщений relay (CloudEvents 1.0, Kafka protocol binding)
+type CloudEvents struct {
+	Mode   string `mapstructure:"mode"`   // binary (атрибуты в заголовках ce_*) или structured (конверт в теле), по умолчанию binary
+	Source string `mapstructure:"source"` // ce_source, по умолчанию /calendar
 }
 
 type Cron struct {
@@ -50,6 +97,26 @@ type Cron struct {
 	Schedule     string `mapstructure:"schedule"`     // Расписание в формате cron (например, "0 16 * * *" - каждый день в 16:00)
 	Interval     string `mapstructure:"interval"`     // Интервал в формате "@every 1m" (например, "@every 1m" - каждую минуту)
 	// Приоритет: если указан Schedule, используется он, иначе Interval
+
+	// Очистка outbox: SENT сообщения старше OutboxRetention переносятся в outbox_archive (OutboxArchive)
+	// или удаляются, пачками по OutboxBatchSize
+	OutboxSchedule  string        `mapstructure:"outboxSchedule"`  // cron или @every, по умолчанию @every 1h
+	OutboxRetention time.Duration `mapstructure:"outboxRetention"` // по умолчанию 168h
+	OutboxBatchSize int           `mapstructure:"outboxBatchSize"` // по умолчанию 1000
+	OutboxArchive   bool          `mapstructure:"outboxArchive"`
+	// В той же задаче удаляются записи inbox_message старше InboxRetention (тем же размером пачки); срок
+	// должен быть больше retention топиков Kafka, иначе повторно доставленное сообщение обработается снова
+	InboxRetention time.Duration `mapstructure:"inboxRetention"` // по умолчанию 720h
+
+	// Напоминания: наступившие time_for_notification отправляются сообщением event_reminder через outbox
+	ReminderSchedule    string        `mapstructure:"reminderSchedule"`    // cron или @every, по умолчанию @every 30s
+	ReminderBatchSize   int           `mapstructure:"reminderBatchSize"`   // по умолчанию 100
+	ReminderMaxLateness time.Duration `mapstructure:"reminderMaxLateness"` // опоздавшие больше - MISSED, по умолчанию 24h, < 0 - без ограничения
+
+	// Сводка событий дня: пользователям с включённой сводкой, у которых по их часовому поясу наступило время
+	// отправки, уходит сообщение agenda_digest через outbox
+	DigestSchedule  string `mapstructure:"digestSchedule"`  // cron или @every, по умолчанию @every 1m
+	DigestBatchSize int    `mapstructure:"digestBatchSize"` // по умолчанию 100
 }
 
 type RelayConfig struct {
@@ -58,6 +125,66 @@ type RelayConfig struct {
 	Lease       time.Duration `mapstructure:"lease"`
 	PollPeriod  time.Duration `mapstructure:"pollPeriod"`
 	MaxAttempts int           `mapstructure:"maxAttempts"`
+	Notify      bool          `mapstructure:"notify"` // LISTEN outbox_event: отправка сразу после коммита, pollPeriod - страховка
+
+	// Dead letter для сообщений в GAVE_UP: пусто - выключено, topic - Kafka-топик DeadLetterTopic,
+	// table - таблица outbox_dead_letter
+	DeadLetter      string `mapstructure:"deadLetter"`
+	DeadLetterTopic string `mapstructure:"deadLetterTopic"`
+
+	Sinks Sinks `mapstructure:"sinks"`
+}
+
+// Sinks получатели сообщений relay. Маршрут - по типу сообщения outbox: EventTypes через запятую, пусто - все типы
+type Sinks struct {
+	Kafka   KafkaSink   `mapstructure:"kafka"`
+	Webhook WebhookSink `mapstructure:"webhook"`
+}
+
+type KafkaSink struct {
+	Disabled   bool   `mapstructure:"disabled"`
+	EventTypes string `mapstructure:"eventTypes"`
+}
+
+// WebhookSink HTTP callback: POST CloudEvent (HTTP binary mode) на URL через httpclient.RetryClient
+type WebhookSink struct {
+	URL        string        `mapstructure:"url"` // пусто - webhook выключен
+	EventTypes string        `mapstructure:"eventTypes"`
+	Headers    string        `mapstructure:"headers"` // дополнительные заголовки "Name: value;Name2: value2"
+	Timeout    time.Duration `mapstructure:"timeout"` // на одно сообщение вместе с повторами, по умолчанию 10s
+	Secret     string        `mapstructure:"secret"`  // ключ HMAC-SHA256 тела для X-Calendar-Signature; пусто - без подписи
+}
+
+// Notification воркер уведомлений: сообщения event_reminder из Kafka ставятся в таблицу notification
+// и доставляются по каналам email (SMTP) и webhook с повторами по backoff
+type Notification struct {
+	Disabled      bool          `mapstructure:"disabled"`
+	Workers       int           `mapstructure:"workers"`       // по умолчанию 2
+	BatchSize     int           `mapstructure:"batchSize"`     // по умолчанию 20
+	PollPeriod    time.Duration `mapstructure:"pollPeriod"`    // по умолчанию 5s
+	Lease         time.Duration `mapstructure:"lease"`         // аренда строки на время отправки, по умолчанию 1m
+	MaxAttempts   int           `mapstructure:"maxAttempts"`   // по умолчанию 10, дальше FAILED
+	DefaultLocale string        `mapstructure:"defaultLocale"` // язык шаблонов без настроек пользователя, по умолчанию ru
+
+	SMTP    SMTP                `mapstructure:"smtp"`
+	Webhook NotificationWebhook `mapstructure:"webhook"`
+}
+
+// SMTP канал email. STARTTLS используется, если сервер его поддерживает
+type SMTP struct {
+	Addr     string        `mapstructure:"addr"` // host:port; пусто - email выключен
+	From     string        `mapstructure:"from"`
+	Username string        `mapstructure:"username"` // пусто - без авторизации
+	Password string        `mapstructure:"password"`
+	Timeout  time.Duration `mapstructure:"timeout"` // на одно письмо, по умолчанию 30s
+}
+
+// NotificationWebhook канал webhook: POST JSON на URL из настроек пользователя или на URL по умолчанию
+type NotificationWebhook struct {
+	URL     string        `mapstructure:"url"`     // по умолчанию для пользователей без webhookURL; пусто - только свои URL
+	Headers string        `mapstructure:"headers"` // дополнительные заголовки "Name: value;Name2: value2"
+	Timeout time.Duration `mapstructure:"timeout"` // по умолчанию 10s
+	Secret  string        `mapstructure:"secret"`  // ключ HMAC-SHA256 тела для X-Calendar-Signature; пусто - без подписи
 }
 
 type HTTPClient struct {
@@ -17,6 +17,7 @@ type DB interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
 	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
 	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	Listen(ctx context.Context, channel string, notify chan<- struct{}) error
 	Close()
 }
 
@@ -102,8 +103,15 @@ func (p *Postgres) QueryRow(ctx context.Context, query string, args ...any) pgx.
 
 // ===== Обёртка транзакции =====
 // Коммит/роллбэк управляется единственным defer с именованным возвратом err.
+// Вложенный вызов (tx уже в контексте) открывает savepoint во внешней транзакции: при ошибке откатывается
+// только он, а фиксация происходит вместе с внешней транзакцией.
 func (p *Postgres) WithinTransaction(ctx context.Context, tFunc func(ctx context.Context) error) (err error) {
-	tx, err := p.Pool.BeginTx(ctx, pgx.TxOptions{})
+	var tx pgx.Tx
+	if outer := p.ExtractTx(ctx); outer != nil {
+		tx, err = outer.Begin(ctx)
+	} else {
+		tx, err = p.Pool.BeginTx(ctx, pgx.TxOptions{})
+	}
 	if err != nil {
 		return err
 	}
@@ -120,6 +128,39 @@ func (p *Postgres) WithinTransaction(ctx context.Context, tFunc func(ctx context
 	return
 }
 
+// ===== LISTEN/NOTIFY =====
+
+// Listen слушает канал channel на отдельном соединении (не из пула): на каждое уведомление
+// кладёт сигнал в notify, не блокируясь, если предыдущий ещё не забран. Сразу после LISTEN
+// тоже отправляет сигнал - уведомления, пришедшие до (пере)подключения, потеряны.
+// Блокируется до ошибки соединения или отмены ctx.
+func (p *Postgres) Listen(ctx context.Context, channel string, notify chan<- struct{}) error {
+	conn, err := pgx.ConnectConfig(ctx, p.Pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return fmt.Errorf("listen connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen %s: %w", channel, err)
+	}
+
+	signal := func() {
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+
+	signal()
+	for {
+		if _, err = conn.WaitForNotification(ctx); err != nil {
+			return fmt.Errorf("wait notification on %s: %w", channel, err)
+		}
+		signal()
+	}
+}
+
 func (p *Postgres) Close() {
 	if p.Pool != nil {
 		p.Pool.Close()
@@ -16,6 +16,8 @@ func NewFiber(conf config.Config, m *metrics.Metrics) *fiber.App {
 	app := fiber.New(
 		fiber.Config{
 			ReadBufferSize: 1024 * 100,
+			// PROPFIND и REPORT нужны для CalDAV
+			RequestMethods: append(fiber.DefaultMethods[:len(fiber.DefaultMethods):len(fiber.DefaultMethods)], "PROPFIND", "REPORT"),
 			ErrorHandler: func(c *fiber.Ctx, err error) error {
 				code := fiber.StatusInternalServerError
 				return c.Status(code).JSON(fiber.Map{
@@ -0,0 +1,270 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrEmptyCalendar = errors.New("ical: no components found")
+	ErrUnexpectedEnd = errors.New("ical: unexpected end of input")
+)
+
+// Decode разбирает поток iCalendar (с развёрткой фолдинга) и возвращает корневой компонент
+func Decode(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		root  *Component
+		stack []*Component
+	)
+	for n, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("ical: line %d: %w", n+1, err)
+		}
+
+		switch strings.ToUpper(prop.Name) {
+		case "BEGIN":
+			c := NewComponent(strings.ToUpper(prop.Value))
+			if len(stack) > 0 {
+				stack[len(stack)-1].AddChild(c)
+			} else if root != nil {
+				return nil, fmt.Errorf("ical: line %d: more than one root component", n+1)
+			} else {
+				root = c
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || !strings.EqualFold(stack[len(stack)-1].Name, prop.Value) {
+				return nil, fmt.Errorf("ical: line %d: unexpected END:%s", n+1, prop.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("ical: line %d: property %s outside of component", n+1, prop.Name)
+			}
+			stack[len(stack)-1].Props = append(stack[len(stack)-1].Props, prop)
+		}
+	}
+
+	if root == nil {
+		return nil, ErrEmptyCalendar
+	}
+	if len(stack) > 0 {
+		return nil, ErrUnexpectedEnd
+	}
+	return root, nil
+}
+
+// Unmarshal разбирает строку iCalendar
+func Unmarshal(s string) (*Component, error) {
+	return Decode(strings.NewReader(s))
+}
+
+// unfold склеивает строки продолжения (начинаются с пробела или табуляции)
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("ical: read: %w", err)
+	}
+	return lines, nil
+}
+
+// parseLine разбирает строку контента: NAME *(";" param) ":" value
+func parseLine(line string) (Property, error) {
+	var p Property
+
+	i := strings.IndexAny(line, ";:")
+	if i <= 0 {
+		return p, fmt.Errorf("invalid content line %q", line)
+	}
+	p.Name = strings.ToUpper(line[:i])
+
+	for line[i] == ';' {
+		i++
+		eq := strings.IndexByte(line[i:], '=')
+		if eq <= 0 {
+			return p, fmt.Errorf("invalid parameter in %q", line)
+		}
+		prm := Param{Name: strings.ToUpper(line[i : i+eq])}
+		i += eq + 1
+
+		for {
+			var v string
+			if i < len(line) && line[i] == '"' {
+				end := strings.IndexByte(line[i+1:], '"')
+				if end < 0 {
+					return p, fmt.Errorf("unterminated quoted parameter in %q", line)
+				}
+				v = line[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				end := strings.IndexAny(line[i:], ",;:")
+				if end < 0 {
+					return p, fmt.Errorf("missing value in %q", line)
+				}
+				v = line[i : i+end]
+				i += end
+			}
+			prm.Values = append(prm.Values, v)
+
+			if i >= len(line) {
+				return p, fmt.Errorf("missing value in %q", line)
+			}
+			if line[i] != ',' {
+				break
+			}
+			i++
+		}
+		p.Params = append(p.Params, prm)
+	}
+
+	if line[i] != ':' {
+		return p, fmt.Errorf("missing value in %q", line)
+	}
+	p.Value = line[i+1:]
+	return p, nil
+}
+
+// IsDate возвращает true для свойства со значением типа DATE (весь день)
+func (p *Property) IsDate() bool {
+	return strings.EqualFold(p.Param("VALUE"), "DATE") || (len(p.Value) == len(dateLayout) && !strings.Contains(p.Value, "T"))
+}
+
+// DateTime разбирает значение DATE-TIME/DATE свойства с учётом TZID.
+// Локальное время без TZID трактуется как UTC.
+func (p *Property) DateTime() (time.Time, error) {
+	times, err := p.DateTimes()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(times) != 1 {
+		return time.Time{}, fmt.Errorf("ical: %s: expected single value", p.Name)
+	}
+	return times[0], nil
+}
+
+// TimeZone часовой пояс значения: TZID, "UTC" для времени с суффиксом Z, пусто - плавающее время или дата
+func (p *Property) TimeZone() string {
+	if tzid := p.Param("TZID"); tzid != "" {
+		return strings.TrimPrefix(tzid, "/")
+	}
+	if strings.HasSuffix(p.Value, "Z") {
+		return "UTC"
+	}
+	return ""
+}
+
+// DateTimes разбирает список значений через запятую (RDATE, EXDATE)
+func (p *Property) DateTimes() ([]time.Time, error) {
+	loc := time.UTC
+	if tzid := p.Param("TZID"); tzid != "" {
+		l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("ical: %s: unknown TZID %q", p.Name, tzid)
+		}
+		loc = l
+	}
+
+	var res []time.Time
+	for _, v := range strings.Split(p.Value, ",") {
+		v = strings.TrimSpace(v)
+		var (
+			t   time.Time
+			err error
+		)
+		switch {
+		case strings.HasSuffix(v, "Z"):
+			t, err = time.Parse(dateTimeUTCLayout, v)
+		case strings.Contains(v, "T"):
+			t, err = time.ParseInLocation(dateTimeLayout, v, loc)
+		default:
+			t, err = time.ParseInLocation(dateLayout, v, loc)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ical: %s: invalid date-time %q", p.Name, v)
+		}
+		res = append(res, t.UTC())
+	}
+	return res, nil
+}
+
+// ParseDuration разбирает DURATION (RFC 5545, 3.3.6): [+-]P[nW][nD][T[nH][nM][nS]]
+func ParseDuration(s string) (time.Duration, error) {
+	orig := s
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign = -1
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("ical: invalid duration %q", orig)
+	}
+	s = s[1:]
+
+	var (
+		total  time.Duration
+		num    int
+		digits bool
+		inTime bool
+	)
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			num = num*10 + int(r-'0')
+			digits = true
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		if !digits {
+			return 0, fmt.Errorf("ical: invalid duration %q", orig)
+		}
+		n := time.Duration(num)
+		switch {
+		case r == 'W' && !inTime:
+			total += n * 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			total += n * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += n * time.Hour
+		case r == 'M' && inTime:
+			total += n * time.Minute
+		case r == 'S' && inTime:
+			total += n * time.Second
+		default:
+			return 0, fmt.Errorf("ical: invalid duration %q", orig)
+		}
+		num, digits = 0, false
+	}
+	if digits {
+		return 0, fmt.Errorf("ical: invalid duration %q", orig)
+	}
+	return sign * total, nil
+}
@@ -0,0 +1,93 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxLineOctets максимальная длина строки без CRLF (RFC 5545, 3.1)
+const maxLineOctets = 75
+
+// Encode записывает компонент в формате iCalendar: CRLF и фолдинг длинных строк
+func Encode(w io.Writer, c *Component) error {
+	bw := bufio.NewWriter(w)
+	if err := encodeComponent(bw, c); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Marshal кодирует компонент в строку
+func Marshal(c *Component) (string, error) {
+	var sb strings.Builder
+	if err := Encode(&sb, c); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func encodeComponent(w *bufio.Writer, c *Component) error {
+	if err := writeLine(w, "BEGIN:"+c.Name); err != nil {
+		return err
+	}
+	for _, p := range c.Props {
+		if err := writeLine(w, formatProperty(p)); err != nil {
+			return err
+		}
+	}
+	for _, ch := range c.Children {
+		if err := encodeComponent(w, ch); err != nil {
+			return err
+		}
+	}
+	return writeLine(w, "END:"+c.Name)
+}
+
+func formatProperty(p Property) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToUpper(p.Name))
+	for _, prm := range p.Params {
+		sb.WriteString(";")
+		sb.WriteString(strings.ToUpper(prm.Name))
+		sb.WriteString("=")
+		for i, v := range prm.Values {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(quoteParamValue(v))
+		}
+	}
+	sb.WriteString(":")
+	sb.WriteString(p.Value)
+	return sb.String()
+}
+
+// quoteParamValue берёт значение параметра в кавычки, если оно содержит : ; ,
+func quoteParamValue(v string) string {
+	v = strings.ReplaceAll(v, `"`, "'")
+	if strings.ContainsAny(v, ":;,") {
+		return `"` + v + `"`
+	}
+	return v
+}
+
+// writeLine пишет строку с фолдингом по 75 октетов, не разрывая многобайтовые UTF-8 символы
+func writeLine(w *bufio.Writer, line string) error {
+	limit := maxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		if _, err := w.WriteString(line[:cut] + "\r\n "); err != nil {
+			return err
+		}
+		line = line[cut:]
+		// продолжение начинается с пробела, который тоже входит в 75 октетов
+		limit = maxLineOctets - 1
+	}
+	_, err := w.WriteString(line + "\r\n")
+	return err
+}
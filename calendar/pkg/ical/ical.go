@@ -0,0 +1,211 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Минимальная модель iCalendar (RFC 5545): компоненты и свойства с параметрами.
+// Маппинг на доменные сущности выполняется на стороне вызывающего кода.
+
+const (
+	ComponentCalendar = "VCALENDAR"
+	ComponentEvent    = "VEVENT"
+	ComponentAlarm    = "VALARM"
+	ComponentTimezone = "VTIMEZONE"
+
+	dateTimeLayout    = "20060102T150405"
+	dateTimeUTCLayout = "20060102T150405Z"
+	dateLayout        = "20060102"
+)
+
+// Param параметр свойства, например TZID=Europe/Moscow
+type Param struct {
+	Name   string
+	Values []string
+}
+
+// Property свойство компонента: NAME;PARAM=VALUE:value
+type Property struct {
+	Name   string
+	Params []Param
+	Value  string
+}
+
+// Param возвращает первое значение параметра или пустую строку
+func (p *Property) Param(name string) string {
+	for _, prm := range p.Params {
+		if strings.EqualFold(prm.Name, name) && len(prm.Values) > 0 {
+			return prm.Values[0]
+		}
+	}
+	return ""
+}
+
+// Component компонент: BEGIN:NAME ... END:NAME
+type Component struct {
+	Name     string
+	Props    []Property
+	Children []*Component
+}
+
+func NewComponent(name string) *Component {
+	return &Component{Name: name}
+}
+
+// NewCalendar создаёт VCALENDAR с обязательными VERSION и PRODID
+func NewCalendar(prodID string) *Component {
+	c := NewComponent(ComponentCalendar)
+	c.Add("VERSION", "2.0")
+	c.Add("PRODID", prodID)
+	c.Add("CALSCALE", "GREGORIAN")
+	return c
+}
+
+// Add добавляет свойство с уже подготовленным значением
+func (c *Component) Add(name, value string, params ...Param) {
+	c.Props = append(c.Props, Property{Name: name, Params: params, Value: value})
+}
+
+// AddText добавляет текстовое свойство с экранированием по RFC 5545
+func (c *Component) AddText(name, value string, params ...Param) {
+	c.Add(name, EscapeText(value), params...)
+}
+
+// AddDateTime добавляет дату-время в UTC
+func (c *Component) AddDateTime(name string, t time.Time) {
+	c.Add(name, FormatDateTime(t))
+}
+
+// AddDateTimeIn добавляет местное время в поясе loc с параметром TZID; для UTC - как AddDateTime
+func (c *Component) AddDateTimeIn(name string, t time.Time, loc *time.Location) {
+	if loc == nil || loc == time.UTC {
+		c.AddDateTime(name, t)
+		return
+	}
+	c.Add(name, t.In(loc).Format(dateTimeLayout), Param{Name: "TZID", Values: []string{loc.String()}})
+}
+
+// AddChild добавляет вложенный компонент
+func (c *Component) AddChild(child *Component) {
+	c.Children = append(c.Children, child)
+}
+
+// Prop возвращает первое свойство с указанным именем или nil
+func (c *Component) Prop(name string) *Property {
+	for i := range c.Props {
+		if strings.EqualFold(c.Props[i].Name, name) {
+			return &c.Props[i]
+		}
+	}
+	return nil
+}
+
+// PropsByName возвращает все свойства с указанным именем (например, несколько EXDATE)
+func (c *Component) PropsByName(name string) []Property {
+	var res []Property
+	for _, p := range c.Props {
+		if strings.EqualFold(p.Name, name) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
+
+// Text возвращает разэкранированное значение текстового свойства
+func (c *Component) Text(name string) string {
+	if p := c.Prop(name); p != nil {
+		return UnescapeText(p.Value)
+	}
+	return ""
+}
+
+// ChildrenByName возвращает вложенные компоненты с указанным именем
+func (c *Component) ChildrenByName(name string) []*Component {
+	var res []*Component
+	for _, ch := range c.Children {
+		if strings.EqualFold(ch.Name, name) {
+			res = append(res, ch)
+		}
+	}
+	return res
+}
+
+// EscapeText экранирует значение типа TEXT (RFC 5545, 3.3.11)
+func EscapeText(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	)
+	return r.Replace(s)
+}
+
+// UnescapeText обратное преобразование для EscapeText
+func UnescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			sb.WriteByte('\n')
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// FormatDateTime форматирует время как DATE-TIME в UTC (20260120T150000Z)
+func FormatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeUTCLayout)
+}
+
+// FormatDuration форматирует длительность как DURATION (RFC 5545, 3.3.6), например -PT1H30M
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	d = d.Truncate(time.Second)
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+
+	var sb strings.Builder
+	sb.WriteString(sign + "P")
+	if days > 0 {
+		fmt.Fprintf(&sb, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 || days == 0 {
+		sb.WriteString("T")
+		if hours > 0 {
+			fmt.Fprintf(&sb, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&sb, "%dM", minutes)
+		}
+		if seconds > 0 || (hours == 0 && minutes == 0) {
+			fmt.Fprintf(&sb, "%dS", seconds)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,257 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestEscapeTextRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		escaped string
+		// unescaped ожидаемый результат UnescapeText(escaped), если отличается от in (переводы строк нормализуются)
+		unescaped string
+	}{
+		{name: "plain", in: "Стендап", escaped: "Стендап"},
+		{name: "comma and semicolon", in: "a, b; c", escaped: `a\, b\; c`},
+		{name: "backslash", in: `C:\dir`, escaped: `C:\\dir`},
+		{name: "newline", in: "строка 1\nстрока 2", escaped: `строка 1\nстрока 2`},
+		{name: "crlf", in: "a\r\nb", escaped: `a\nb`, unescaped: "a\nb"},
+		{name: "cr", in: "a\rb", escaped: `a\nb`, unescaped: "a\nb"},
+		{name: "escaped n is not newline", in: `\n`, escaped: `\\n`},
+		{name: "colon is not escaped", in: "09:00", escaped: "09:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EscapeText(tt.in)
+			if got != tt.escaped {
+				t.Fatalf("EscapeText(%q) = %q, want %q", tt.in, got, tt.escaped)
+			}
+			want := tt.in
+			if tt.unescaped != "" {
+				want = tt.unescaped
+			}
+			if back := UnescapeText(got); back != want {
+				t.Errorf("UnescapeText(%q) = %q, want %q", got, back, want)
+			}
+		})
+	}
+}
+
+func TestUnescapeText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{in: `a\Nb`, want: "a\nb"},
+		{in: `trailing\`, want: `trailing\`},
+		{in: `\;\,\\`, want: `;,\`},
+	}
+	for _, tt := range tests {
+		if got := UnescapeText(tt.in); got != tt.want {
+			t.Errorf("UnescapeText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeFoldsLongLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "short", value: "Стендап"},
+		{name: "ascii", value: strings.Repeat("abcdefghij", 30)},
+		{name: "cyrillic", value: strings.Repeat("Длинное описание события, ", 20)},
+		{name: "emoji", value: strings.Repeat("🎉", 50)},
+		{name: "multiline", value: strings.Repeat("строка; с, разделителями\n", 10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal := NewCalendar("-//test//RU")
+			vevent := NewComponent(ComponentEvent)
+			vevent.AddText("UID", "uid-1")
+			vevent.AddText("DESCRIPTION", tt.value)
+			cal.AddChild(vevent)
+
+			out, err := Marshal(cal)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Errorf("output does not end with CRLF")
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+				if len(line) > maxLineOctets {
+					t.Errorf("line is %d octets, want <= %d: %q", len(line), maxLineOctets, line)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line splits a UTF-8 character: %q", line)
+				}
+			}
+
+			back, err := Unmarshal(out)
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			events := back.ChildrenByName(ComponentEvent)
+			if len(events) != 1 {
+				t.Fatalf("got %d VEVENT, want 1", len(events))
+			}
+			if got := events[0].Text("DESCRIPTION"); got != tt.value {
+				t.Errorf("DESCRIPTION round trip = %q, want %q", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestParamsRoundTrip(t *testing.T) {
+	c := NewComponent(ComponentEvent)
+	c.Add("DTSTART", "20260120T090000", Param{Name: "TZID", Values: []string{"Europe/Moscow"}})
+	c.Add("ATTENDEE", "mailto:a@example.com", Param{Name: "CN", Values: []string{"Иванов, Иван"}},
+		Param{Name: "ROLE", Values: []string{"REQ-PARTICIPANT"}})
+
+	out, err := Marshal(c)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	back, err := Unmarshal(out)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	attendee := back.Prop("ATTENDEE")
+	if attendee == nil {
+		t.Fatal("ATTENDEE lost")
+	}
+	if got := attendee.Param("CN"); got != "Иванов, Иван" {
+		t.Errorf("CN = %q, want quoted value preserved", got)
+	}
+	if got := attendee.Param("role"); got != "REQ-PARTICIPANT" {
+		t.Errorf("ROLE = %q", got)
+	}
+	if attendee.Value != "mailto:a@example.com" {
+		t.Errorf("value = %q", attendee.Value)
+	}
+
+	start, err := back.Prop("DTSTART").DateTime()
+	if err != nil {
+		t.Fatalf("DateTime() error = %v", err)
+	}
+	if want := time.Date(2026, 1, 20, 6, 0, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("DTSTART = %s, want %s", start, want)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{name: "empty", in: ""},
+		{name: "unterminated", in: "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"},
+		{name: "mismatched end", in: "BEGIN:VCALENDAR\r\nEND:VEVENT\r\n"},
+		{name: "property outside component", in: "VERSION:2.0\r\n"},
+		{name: "no value", in: "BEGIN:VCALENDAR\r\nVERSION\r\nEND:VCALENDAR\r\n"},
+		{name: "two roots", in: "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\nBEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Unmarshal(tt.in); err == nil {
+				t.Errorf("Unmarshal(%q): want error", tt.in)
+			}
+		})
+	}
+}
+
+func TestDateTimes(t *testing.T) {
+	tests := []struct {
+		name string
+		prop Property
+		want []time.Time
+	}{
+		{
+			name: "utc",
+			prop: Property{Name: "DTSTART", Value: "20260120T090000Z"},
+			want: []time.Time{time.Date(2026, 1, 20, 9, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "floating as utc",
+			prop: Property{Name: "DTSTART", Value: "20260120T090000"},
+			want: []time.Time{time.Date(2026, 1, 20, 9, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "date",
+			prop: Property{Name: "DTSTART", Value: "20260120", Params: []Param{{Name: "VALUE", Values: []string{"DATE"}}}},
+			want: []time.Time{time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name: "list with tzid",
+			prop: Property{Name: "EXDATE", Value: "20260120T090000,20260122T090000",
+				Params: []Param{{Name: "TZID", Values: []string{"Europe/Moscow"}}}},
+			want: []time.Time{
+				time.Date(2026, 1, 20, 6, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 22, 6, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.prop.DateTimes()
+			if err != nil {
+				t.Fatalf("DateTimes() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("DateTimes() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("DateTimes()[%d] = %s, want %s", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	bad := Property{Name: "DTSTART", Value: "20260120T090000", Params: []Param{{Name: "TZID", Values: []string{"Nowhere/City"}}}}
+	if _, err := bad.DateTimes(); err == nil {
+		t.Error("DateTimes() with unknown TZID: want error")
+	}
+}
+
+func TestDurationRoundTrip(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 0, want: "PT0S"},
+		{d: -15 * time.Minute, want: "-PT15M"},
+		{d: -90 * time.Minute, want: "-PT1H30M"},
+		{d: 24 * time.Hour, want: "P1D"},
+		{d: -(26*time.Hour + 5*time.Second), want: "-P1DT2H5S"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := FormatDuration(tt.d)
+			if got != tt.want {
+				t.Fatalf("FormatDuration(%s) = %q, want %q", tt.d, got, tt.want)
+			}
+			back, err := ParseDuration(got)
+			if err != nil {
+				t.Fatalf("ParseDuration(%q) error = %v", got, err)
+			}
+			if back != tt.d {
+				t.Errorf("ParseDuration(%q) = %s, want %s", got, back, tt.d)
+			}
+		})
+	}
+
+	for _, s := range []string{"", "P", "PT", "15M", "PT15", "P1H", "PTXM"} {
+		if _, err := ParseDuration(s); err == nil {
+			t.Errorf("ParseDuration(%q): want error", s)
+		}
+	}
+	if d, err := ParseDuration("P1W"); err != nil || d != 7*24*time.Hour {
+		t.Errorf("ParseDuration(P1W) = %s, %v", d, err)
+	}
+}
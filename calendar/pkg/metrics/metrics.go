@@ -6,10 +6,15 @@ import (
 )
 
 type Metrics struct {
-	Kafka KafkaMetrics
-	API   APIMetrics
-	Repo  RepoMetrics
-	Go    GoMetrics
+	Kafka    KafkaMetrics
+	API      APIMetrics
+	Repo     RepoMetrics
+	Outbox   OutboxMetrics
+	Reminder ReminderMetrics
+	Go       GoMetrics
+
+	Notification NotificationMetrics
+	Digest       DigestMetrics
 }
 
 type KafkaMetrics struct {
@@ -17,12 +22,16 @@ type KafkaMetrics struct {
 	ProducerAttemptLatencySeconds *prometheus.HistogramVec
 	ProducerOperationsTotal       *prometheus.CounterVec
 	ProducerSuccessAttempts       *prometheus.HistogramVec
+	ProducerRetriesTotal          *prometheus.CounterVec
+	ProducerDuplicatesTotal       *prometheus.CounterVec
 
 	// Consumer
 	ConsumerMessagesTotal   *prometheus.CounterVec
 	ConsumerProcessDuration *prometheus.HistogramVec
 	ConsumerRebalancesTotal *prometheus.CounterVec
 	ConsumerInFlight        *prometheus.GaugeVec
+	ConsumerInboxTotal      *prometheus.CounterVec
+	ConsumerErrorsTotal     *prometheus.CounterVec
 }
 
 type APIMetrics struct {
@@ -36,6 +45,26 @@ type RepoMetrics struct {
 	InFlight        *prometheus.GaugeVec
 }
 
+type OutboxMetrics struct {
+	RetentionRowsTotal  *prometheus.CounterVec
+	RetentionRunsTotal  *prometheus.CounterVec
+	SinkDeliveriesTotal *prometheus.CounterVec
+}
+
+type ReminderMetrics struct {
+	RemindersTotal *prometheus.CounterVec
+	RunsTotal      *prometheus.CounterVec
+}
+
+type NotificationMetrics struct {
+	DeliveriesTotal *prometheus.CounterVec
+}
+
+type DigestMetrics struct {
+	DigestsTotal *prometheus.CounterVec
+	RunsTotal    *prometheus.CounterVec
+}
+
 type GoMetrics struct {
 	InternalGoroutines *prometheus.GaugeVec
 }
@@ -68,6 +97,20 @@ func New(reg prometheus.Registerer) *Metrics {
 				Buckets:   []float64{1, 2, 3, 4, 5},
 			}, []string{"topic"}),
 
+			ProducerRetriesTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "kafka",
+				Name:      "producer_retries_total",
+				Help:      "Repeated sends of the same message: in-process retries and relay re-sends of outbox rows.",
+			}, []string{"topic", "source"}), // producer|relay
+
+			ProducerDuplicatesTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "kafka",
+				Name:      "producer_duplicates_total",
+				Help:      "Repeated sends of a message the broker may already have and will not deduplicate.",
+			}, []string{"topic", "reason"}), // ambiguous_error|mark_sent_failed
+
 			ConsumerMessagesTotal: f.NewCounterVec(prometheus.CounterOpts{
 				Namespace: "payments",
 				Subsystem: "kafka",
@@ -96,6 +139,20 @@ func New(reg prometheus.Registerer) *Metrics {
 				Name:      "consumer_inflight_messages",
 				Help:      "Messages currently being processed.",
 			}, []string{"topic"}),
+
+			ConsumerInboxTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "kafka",
+				Name:      "consumer_inbox_total",
+				Help:      "Consumed messages by inbox outcome.",
+			}, []string{"topic", "result"}), // processed|duplicate|failed
+
+			ConsumerErrorsTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "kafka",
+				Name:      "consumer_errors_total",
+				Help:      "Failed consumed messages by error class and outcome.",
+			}, []string{"topic", "class", "outcome"}), // retryable|permanent; retry_topic|dlq|in_place|republish_failed
 		},
 
 		API: APIMetrics{
@@ -138,6 +195,66 @@ func New(reg prometheus.Registerer) *Metrics {
 				Help:      "Number of in-flight DB requests.",
 			}, []string{"op", "name"}),
 		},
+		Outbox: OutboxMetrics{
+			RetentionRowsTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "outbox",
+				Name:      "retention_rows_total",
+				Help:      "Sent outbox and processed inbox rows removed by the retention job.",
+			}, []string{"action"}), // archived|deleted|inbox_deleted
+
+			RetentionRunsTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "outbox",
+				Name:      "retention_runs_total",
+				Help:      "Retention job runs by result.",
+			}, []string{"result"}), // success|error
+
+			SinkDeliveriesTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "outbox",
+				Name:      "sink_deliveries_total",
+				Help:      "Outbox messages delivered by the relay per sink and result.",
+			}, []string{"sink", "result"}), // success|failed
+		},
+		Reminder: ReminderMetrics{
+			RemindersTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "reminder",
+				Name:      "reminders_total",
+				Help:      "Due reminders processed by the scheduler.",
+			}, []string{"result"}), // fired|missed
+
+			RunsTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "reminder",
+				Name:      "runs_total",
+				Help:      "Reminder scheduler runs by result.",
+			}, []string{"result"}), // success|error
+		},
+		Notification: NotificationMetrics{
+			DeliveriesTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "notification",
+				Name:      "deliveries_total",
+				Help:      "Notification delivery attempts by channel and result.",
+			}, []string{"channel", "result"}), // sent|retry|failed|skipped
+		},
+		Digest: DigestMetrics{
+			DigestsTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "digest",
+				Name:      "digests_total",
+				Help:      "Agenda digests processed by the scheduler.",
+			}, []string{"result"}), // sent|empty
+
+			RunsTotal: f.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "payments",
+				Subsystem: "digest",
+				Name:      "runs_total",
+				Help:      "Agenda digest scheduler runs by result.",
+			}, []string{"result"}), // success|error
+		},
 		Go: GoMetrics{
 			InternalGoroutines: f.NewGaugeVec(prometheus.GaugeOpts{
 				Namespace: "payments",
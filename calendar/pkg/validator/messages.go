@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Messages переводит ошибки валидатора в сообщения для клиента
+func Messages(err error) []string {
+	var errors []string
+	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+		for _, e := range validationErrors {
+			field := e.Field()
+			tag := e.Tag()
+			var message string
+			switch tag {
+			case "required":
+				message = fmt.Sprintf("поле '%s' обязательно для заполнения", field)
+			case "min":
+				message = fmt.Sprintf("поле '%s' должно содержать минимум %s символов", field, e.Param())
+			case "max":
+				message = fmt.Sprintf("поле '%s' должно содержать максимум %s символов", field, e.Param())
+			case "rfc3339", "rfc3339_optional":
+				message = fmt.Sprintf("поле '%s' должно быть в формате RFC3339 (например, 2026-01-20T15:00:00Z)", field)
+			case "duration":
+				message = fmt.Sprintf("поле '%s' должно быть длительностью (например, 10m или 24h)", field)
+			case "oneof":
+				message = fmt.Sprintf("поле '%s' должно быть одним из: %s", field, e.Param())
+			case "email":
+				message = fmt.Sprintf("поле '%s' должно быть адресом email", field)
+			case "url":
+				message = fmt.Sprintf("поле '%s' должно быть URL (например, https://example.com/hook)", field)
+			case "timezone":
+				message = fmt.Sprintf("поле '%s' должно быть часовым поясом IANA (например, Europe/Moscow)", field)
+			case "hexcolor", "hexcolor|len=0":
+				message = fmt.Sprintf("поле '%s' должно быть цветом в формате #RRGGBB", field)
+			case "timezone|len=0":
+				message = fmt.Sprintf("поле '%s' должно быть часовым поясом IANA (например, Europe/Moscow) или пустым", field)
+			case "datetime":
+				message = fmt.Sprintf("поле '%s' должно быть в формате %s", field, e.Param())
+			default:
+				message = fmt.Sprintf("поле '%s' не прошло валидацию: %s", field, tag)
+			}
+			errors = append(errors, message)
+		}
+	} else {
+		errors = append(errors, err.Error())
+	}
+	return errors
+}
@@ -17,6 +17,7 @@ func init() {
 	// Регистрируем кастомные валидаторы
 	_ = Validate.RegisterValidation("rfc3339", validateRFC3339)
 	_ = Validate.RegisterValidation("rfc3339_optional", validateRFC3339Optional)
+	_ = Validate.RegisterValidation("duration", validateDuration)
 }
 
 // validateRFC3339 проверяет, что строка является валидной RFC3339 датой
@@ -38,3 +39,13 @@ func validateRFC3339Optional(fl validator.FieldLevel) bool {
 	_, err := time.Parse(time.RFC3339, dateStr)
 	return err == nil
 }
+
+// validateDuration проверяет длительность в формате Go (10m, 1h30m), разрешает пустую строку
+func validateDuration(fl validator.FieldLevel) bool {
+	d := fl.Field().String()
+	if d == "" {
+		return true
+	}
+	_, err := time.ParseDuration(d)
+	return err == nil
+}
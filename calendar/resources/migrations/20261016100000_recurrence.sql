@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Поля серии (RFC 5545): правило повторения и явные включения/исключения дат
+ALTER TABLE events ADD COLUMN IF NOT EXISTS rrule  TEXT;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS rdate  TIMESTAMP[];
+ALTER TABLE events ADD COLUMN IF NOT EXISTS exdate TIMESTAMP[];
+
+-- Поля переопределения (override) одного вхождения серии:
+-- series_id     - ссылка на событие-серию
+-- recurrence_id - исходное время начала вхождения, которое переопределяется
+-- cancelled     - вхождение отменено
+ALTER TABLE events ADD COLUMN IF NOT EXISTS series_id     UUID;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS recurrence_id TIMESTAMP;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS cancelled     BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE events
+ADD CONSTRAINT fk_events_series
+FOREIGN KEY (series_id)
+REFERENCES events(id)
+ON DELETE CASCADE;
+
+ALTER TABLE events
+ADD CONSTRAINT events_override_check
+CHECK ((series_id IS NULL) = (recurrence_id IS NULL) AND (series_id IS NULL OR rrule IS NULL));
+
+-- Одно переопределение на вхождение серии
+CREATE UNIQUE INDEX IF NOT EXISTS idx_events_series_recurrence ON events(series_id, recurrence_id)
+WHERE series_id IS NOT NULL;
+
+-- Индекс для выборки серий, которые могут попасть в период
+CREATE INDEX IF NOT EXISTS idx_events_series_start ON events(start_date_event)
+WHERE rrule IS NOT NULL OR rdate IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_events_series_start;
+DROP INDEX IF EXISTS idx_events_series_recurrence;
+
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_override_check;
+ALTER TABLE events DROP CONSTRAINT IF EXISTS fk_events_series;
+
+ALTER TABLE events DROP COLUMN IF EXISTS cancelled;
+ALTER TABLE events DROP COLUMN IF EXISTS recurrence_id;
+ALTER TABLE events DROP COLUMN IF EXISTS series_id;
+ALTER TABLE events DROP COLUMN IF EXISTS exdate;
+ALTER TABLE events DROP COLUMN IF EXISTS rdate;
+ALTER TABLE events DROP COLUMN IF EXISTS rrule;
+
+-- +goose StatementEnd
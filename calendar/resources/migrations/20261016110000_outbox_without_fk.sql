@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Сообщения outbox (в том числе event_deleted) должны пережить удаление события,
+-- поэтому внешний ключ на events убираем: aggregate_id остаётся просто ID агрегата
+ALTER TABLE outbox_event
+DROP CONSTRAINT IF EXISTS fk_outbox_event;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_event_aggregate ON outbox_event(aggregate_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_event_aggregate;
+
+-- NOT VALID: в outbox уже могут быть сообщения удалённых событий
+ALTER TABLE outbox_event
+ADD CONSTRAINT fk_outbox_event
+FOREIGN KEY (aggregate_id)
+REFERENCES events(id)
+ON DELETE CASCADE
+NOT VALID;
+-- +goose StatementEnd
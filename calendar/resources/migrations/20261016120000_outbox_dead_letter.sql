@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Причина последней неудачной отправки и её класс (producer.ClassifyRetry)
+ALTER TABLE outbox_event
+    ADD COLUMN IF NOT EXISTS last_error  TEXT,
+    ADD COLUMN IF NOT EXISTS error_class VARCHAR(64);
+
+-- Dead letter: сюда relay перекладывает сообщения, дошедшие до GAVE_UP (relay.deadLetter=table)
+CREATE TABLE IF NOT EXISTS outbox_dead_letter (
+    id              bigserial    PRIMARY KEY NOT NULL,
+    outbox_id       BIGINT       NOT NULL,                                                               -- outbox_event.id
+    aggregate_id    UUID         NOT NULL,
+    aggregate_type  VARCHAR(32)  NOT NULL,
+    event_type      VARCHAR(64)  NOT NULL,
+    payload         JSONB        NOT NULL,
+    attempts        INT          NOT NULL,
+    last_error      TEXT,
+    error_class     VARCHAR(64),
+    created_at      TIMESTAMP    NOT NULL,                                                               -- created_at исходного сообщения
+    dead_at         TIMESTAMP    NOT NULL DEFAULT now()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_outbox_dead_letter_outbox_id ON outbox_dead_letter(outbox_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_dead_letter_dead_at ON outbox_dead_letter(dead_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox_dead_letter;
+
+ALTER TABLE outbox_event
+    DROP COLUMN IF EXISTS last_error,
+    DROP COLUMN IF EXISTS error_class;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Будим relay (LISTEN outbox_event), когда в outbox появляется сообщение к отправке:
+-- вставка или возврат в NEW (requeue). NOTIFY доставляется при коммите транзакции,
+-- одинаковые уведомления в одной транзакции схлопываются.
+CREATE OR REPLACE FUNCTION notify_outbox_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('outbox_event', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_outbox_event_notify ON outbox_event;
+CREATE TRIGGER trg_outbox_event_notify
+    AFTER INSERT OR UPDATE OF status ON outbox_event
+    FOR EACH ROW
+    WHEN (NEW.status = 'NEW')
+    EXECUTE FUNCTION notify_outbox_event();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_outbox_event_notify ON outbox_event;
+DROP FUNCTION IF EXISTS notify_outbox_event();
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Архив отправленных сообщений outbox (cron.outboxArchive=true): та же структура плюс archived_at
+CREATE TABLE IF NOT EXISTS outbox_archive (
+    id               BIGINT       PRIMARY KEY NOT NULL,                                                   -- outbox_event.id
+    aggregate_id     UUID         NOT NULL,
+    aggregate_type   VARCHAR(32)  NOT NULL,
+    event_type       VARCHAR(64)  NOT NULL,
+    payload          JSONB        NOT NULL,
+    status           VARCHAR(16)  NOT NULL,
+    attempts         INT          NOT NULL,
+    next_attempt_at  TIMESTAMP    NOT NULL,
+    created_at       TIMESTAMP    NOT NULL,
+    last_error       TEXT,
+    error_class      VARCHAR(64),
+    archived_at      TIMESTAMP    NOT NULL DEFAULT now()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_outbox_archive_created_at ON outbox_archive(created_at);
+
+-- Выборка отправленных сообщений для очистки по возрасту
+CREATE INDEX IF NOT EXISTS idx_outbox_event_sent_created_at ON outbox_event(created_at) WHERE status = 'SENT';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_outbox_event_sent_created_at;
+DROP TABLE IF EXISTS outbox_archive;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Доставки сообщений outbox по sink-ам (kafka, webhook): при частичной неудаче повтор уходит только
+-- в sink-и, которые сообщение ещё не получили. Строки удаляются вместе с сообщением (архив, purge).
+CREATE TABLE IF NOT EXISTS outbox_delivery (
+    outbox_id     BIGINT       NOT NULL REFERENCES outbox_event(id) ON DELETE CASCADE,
+    sink          VARCHAR(32)  NOT NULL,
+    delivered_at  TIMESTAMP    NOT NULL DEFAULT now(),
+    PRIMARY KEY (outbox_id, sink)
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox_delivery;
+-- +goose StatementEnd
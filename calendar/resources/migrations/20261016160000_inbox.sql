@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Inbox консьюмера: обработанные сообщения Kafka. Строка вставляется в той же транзакции, что и побочные
+-- эффекты обработки, поэтому повторная доставка (ребаланс, падение до коммита offset) не применяется дважды.
+-- message_id - "<ce_source>:<ce_id>" для CloudEvents, иначе "<topic>/<partition>/<offset>".
+CREATE TABLE IF NOT EXISTS inbox_message (
+    message_id    VARCHAR(512) PRIMARY KEY NOT NULL,
+    topic         VARCHAR(255) NOT NULL,
+    partition     INT          NOT NULL,
+    "offset"      BIGINT       NOT NULL,
+    event_type    VARCHAR(64),
+    processed_at  TIMESTAMP    NOT NULL DEFAULT now()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_inbox_message_processed_at ON inbox_message(processed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS inbox_message;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Напоминания о событиях: строка на событие (или переопределение вхождения) с временем time_for_notification.
+-- Планировщик забирает наступившие PENDING строки (FOR UPDATE SKIP LOCKED) и в той же транзакции пишет
+-- event_reminder в outbox и отмечает строку FIRED, поэтому напоминание срабатывает один раз на всех репликах.
+-- Изменение time_for_notification возвращает строку в PENDING с новым временем, удаление события удаляет её.
+CREATE TABLE IF NOT EXISTS event_reminder (
+    id          BIGSERIAL   PRIMARY KEY,
+    event_id    UUID        NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    remind_at   TIMESTAMP   NOT NULL,
+    status      VARCHAR(16) NOT NULL DEFAULT 'PENDING', -- PENDING | FIRED | MISSED
+    fired_at    TIMESTAMP,
+    created_at  TIMESTAMP   NOT NULL DEFAULT now()
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_reminder_event ON event_reminder(event_id);
+
+CREATE INDEX IF NOT EXISTS idx_event_reminder_due ON event_reminder(remind_at)
+WHERE status = 'PENDING';
+
+-- уже прошедшие напоминания не переносим, чтобы они не сработали разом после миграции
+INSERT INTO event_reminder (event_id, remind_at)
+SELECT id, time_for_notification
+FROM events
+WHERE time_for_notification IS NOT NULL AND time_for_notification > now() AND NOT cancelled
+ON CONFLICT (event_id) DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS event_reminder;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Несколько напоминаний на событие: за offset_seconds до начала или в момент absolute_at, по каналу channel.
+-- reminder_key - нормализованное напоминание ("email|offset:600", "push|at:<RFC3339>"), по нему PATCH
+-- с тем же набором не сбрасывает сработавшие. timeForNotification - строка с ключом 'timeForNotification'.
+ALTER TABLE event_reminder ADD COLUMN IF NOT EXISTS reminder_key   VARCHAR(128);
+ALTER TABLE event_reminder ADD COLUMN IF NOT EXISTS channel        VARCHAR(16) NOT NULL DEFAULT 'email';
+ALTER TABLE event_reminder ADD COLUMN IF NOT EXISTS offset_seconds BIGINT;
+ALTER TABLE event_reminder ADD COLUMN IF NOT EXISTS absolute_at    TIMESTAMP;
+
+UPDATE event_reminder SET reminder_key = 'timeForNotification', absolute_at = remind_at;
+
+ALTER TABLE event_reminder ALTER COLUMN reminder_key SET NOT NULL;
+
+ALTER TABLE event_reminder
+ADD CONSTRAINT event_reminder_time_check
+CHECK ((offset_seconds IS NULL) <> (absolute_at IS NULL) AND (offset_seconds IS NULL OR offset_seconds >= 0));
+
+DROP INDEX IF EXISTS idx_event_reminder_event;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_reminder_event_key ON event_reminder(event_id, reminder_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM event_reminder WHERE reminder_key <> 'timeForNotification';
+
+DROP INDEX IF EXISTS idx_event_reminder_event_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_event_reminder_event ON event_reminder(event_id);
+
+ALTER TABLE event_reminder DROP CONSTRAINT IF EXISTS event_reminder_time_check;
+ALTER TABLE event_reminder DROP COLUMN IF EXISTS absolute_at;
+ALTER TABLE event_reminder DROP COLUMN IF EXISTS offset_seconds;
+ALTER TABLE event_reminder DROP COLUMN IF EXISTS channel;
+ALTER TABLE event_reminder DROP COLUMN IF EXISTS reminder_key;
+-- +goose StatementEnd
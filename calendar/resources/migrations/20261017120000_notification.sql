@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Настройки уведомлений пользователя: адреса каналов и язык шаблонов
+CREATE TABLE IF NOT EXISTS notification_settings (
+    user_id      VARCHAR(255)  PRIMARY KEY NOT NULL,
+    email        VARCHAR(320),
+    webhook_url  VARCHAR(2048),
+    locale       VARCHAR(16),
+    updated_at   TIMESTAMP     NOT NULL DEFAULT now()
+    );
+
+-- Уведомления к доставке: строка на сообщение Kafka (event_reminder, ...), получателя и канал.
+-- Текст отрисован по шаблону при приёме сообщения; воркер забирает PENDING строки с наступившим
+-- next_attempt_at (FOR UPDATE SKIP LOCKED + аренда), отправляет и отмечает SENT, при ошибке переносит
+-- next_attempt_at с backoff, после notification.maxAttempts или постоянной ошибки - FAILED.
+-- SKIPPED - канал не настроен или у пользователя нет адреса для канала.
+CREATE TABLE IF NOT EXISTS notification (
+    id               BIGSERIAL     PRIMARY KEY,
+    message_id       VARCHAR(512)  NOT NULL, -- inbox_message.message_id исходного сообщения
+    event_type       VARCHAR(64)   NOT NULL,
+    user_id          VARCHAR(255)  NOT NULL,
+    channel          VARCHAR(16)   NOT NULL,
+    recipient        VARCHAR(2048),
+    locale           VARCHAR(16)   NOT NULL,
+    subject          TEXT          NOT NULL,
+    body_text        TEXT          NOT NULL,
+    body_html        TEXT,
+    payload          JSONB         NOT NULL,
+    status           VARCHAR(16)   NOT NULL DEFAULT 'PENDING', -- PENDING | SENT | FAILED | SKIPPED
+    attempts         INT           NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMP     NOT NULL DEFAULT now(),
+    last_error       TEXT,
+    created_at       TIMESTAMP     NOT NULL DEFAULT now(),
+    sent_at          TIMESTAMP
+    );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_message ON notification(message_id, user_id, channel);
+
+CREATE INDEX IF NOT EXISTS idx_notification_due ON notification(next_attempt_at)
+WHERE status = 'PENDING';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS notification;
+DROP TABLE IF EXISTS notification_settings;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Ежедневная сводка событий: пользователь включает её в настройках уведомлений и задаёт время отправки
+-- в своём часовом поясе. digest_sent_on - локальная дата последней сводки, сводка уходит раз в день.
+ALTER TABLE notification_settings
+    ADD COLUMN IF NOT EXISTS time_zone       VARCHAR(64),
+    ADD COLUMN IF NOT EXISTS digest_enabled  BOOLEAN     NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS digest_time     TIME        NOT NULL DEFAULT '08:00',
+    ADD COLUMN IF NOT EXISTS digest_channel  VARCHAR(16) NOT NULL DEFAULT 'email',
+    ADD COLUMN IF NOT EXISTS digest_sent_on  DATE;
+
+CREATE INDEX IF NOT EXISTS idx_notification_settings_digest ON notification_settings(user_id)
+WHERE digest_enabled;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_notification_settings_digest;
+ALTER TABLE notification_settings
+    DROP COLUMN IF EXISTS digest_sent_on,
+    DROP COLUMN IF EXISTS digest_channel,
+    DROP COLUMN IF EXISTS digest_time,
+    DROP COLUMN IF EXISTS digest_enabled,
+    DROP COLUMN IF EXISTS time_zone;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Участники событий: строка на участника события или серии (переопределения вхождений наследуют участников
+-- серии). role - required | optional, partstat - ответ участника по RFC 5545 в нижнем регистре.
+-- Участник видит событие в своей выдаче за период, удаление события удаляет участников.
+CREATE TABLE IF NOT EXISTS event_attendee (
+    event_id      UUID         NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id       VARCHAR(255) NOT NULL,
+    role          VARCHAR(16)  NOT NULL DEFAULT 'required',     -- required | optional
+    partstat      VARCHAR(16)  NOT NULL DEFAULT 'needs-action', -- needs-action | accepted | declined | tentative
+    comment       VARCHAR(1000),
+    invited_at    TIMESTAMP    NOT NULL DEFAULT now(),
+    responded_at  TIMESTAMP,
+    updated_at    TIMESTAMP    NOT NULL DEFAULT now(),
+    PRIMARY KEY (event_id, user_id),
+    CONSTRAINT event_attendee_role_check CHECK (role IN ('required', 'optional')),
+    CONSTRAINT event_attendee_partstat_check CHECK (partstat IN ('needs-action', 'accepted', 'declined', 'tentative'))
+    );
+
+CREATE INDEX IF NOT EXISTS idx_event_attendee_user ON event_attendee(user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS event_attendee;
+-- +goose StatementEnd
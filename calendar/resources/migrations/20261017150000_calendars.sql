@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Календари пользователя: события хранятся в календаре владельца (calendar_id), у каждого пользователя
+-- ровно один основной календарь (is_default) - в него попадают события без calendarID.
+-- Существующие события переносятся в основной календарь своего пользователя.
+CREATE TABLE IF NOT EXISTS calendars (
+    id          UUID         PRIMARY KEY,
+    user_id     VARCHAR(255) NOT NULL,
+    name        VARCHAR(100) NOT NULL,
+    color       VARCHAR(7),  -- #RRGGBB
+    time_zone   VARCHAR(64), -- часовой пояс IANA по умолчанию для клиентов
+    is_default  BOOLEAN      NOT NULL DEFAULT false,
+    created_at  TIMESTAMP    NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMP    NOT NULL DEFAULT now()
+    );
+
+CREATE INDEX IF NOT EXISTS idx_calendars_user ON calendars(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_calendars_user_default ON calendars(user_id) WHERE is_default;
+
+INSERT INTO calendars (id, user_id, name, is_default)
+SELECT gen_random_uuid(), u.user_id, 'Основной', true
+FROM (SELECT DISTINCT user_id FROM events WHERE user_id IS NOT NULL) u
+ON CONFLICT DO NOTHING;
+
+-- календарь с событиями не удаляется (ON DELETE RESTRICT)
+ALTER TABLE events ADD COLUMN IF NOT EXISTS calendar_id UUID REFERENCES calendars(id) ON DELETE RESTRICT;
+
+UPDATE events e
+SET calendar_id = c.id
+FROM calendars c
+WHERE c.user_id = e.user_id AND c.is_default AND e.calendar_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_events_calendar ON events(calendar_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_events_calendar;
+ALTER TABLE events DROP COLUMN IF EXISTS calendar_id;
+DROP TABLE IF EXISTS calendars;
+-- +goose StatementEnd
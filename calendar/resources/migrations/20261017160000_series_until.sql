@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- until_at - окончание последнего вхождения конечной серии (RRULE с UNTIL/COUNT или только RDATE),
+-- считается приложением при создании и изменении серии. NULL - серия бесконечна или ещё не пересчитана
+-- (серии, созданные до миграции, выбираются как бесконечные до первого изменения).
+ALTER TABLE events ADD COLUMN IF NOT EXISTS until_at TIMESTAMP;
+
+DROP INDEX IF EXISTS idx_events_series_start;
+CREATE INDEX IF NOT EXISTS idx_events_series_period ON events(start_date_event, until_at)
+WHERE rrule IS NOT NULL OR rdate IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_events_series_period;
+CREATE INDEX IF NOT EXISTS idx_events_series_start ON events(start_date_event)
+WHERE rrule IS NOT NULL OR rdate IS NOT NULL;
+ALTER TABLE events DROP COLUMN IF EXISTS until_at;
+-- +goose StatementEnd
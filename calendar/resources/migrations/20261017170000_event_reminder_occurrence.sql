@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Напоминания серии - по строке на напоминание, как и у одиночных событий, но remind_at указывает на ближайшее
+-- вхождение: occurrence_at - начало этого вхождения. После срабатывания приложение переносит строку на следующее
+-- вхождение (recurrence.NextReminder). У одиночных событий и переопределений вхождений occurrence_at - NULL,
+-- строки серий, созданные до миграции, считаются напоминаниями о первом вхождении до первого срабатывания или изменения.
+ALTER TABLE event_reminder ADD COLUMN IF NOT EXISTS occurrence_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE event_reminder DROP COLUMN IF EXISTS occurrence_at;
+-- +goose StatementEnd
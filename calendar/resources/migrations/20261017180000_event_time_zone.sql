@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- time_zone - часовой пояс IANA серии: DTSTART разворачивается по местному времени этого пояса (BYDAY, BYHOUR,
+-- переход на летнее время). При создании по умолчанию берётся пояс календаря; NULL - UTC (в том числе
+-- у событий, созданных до миграции).
+ALTER TABLE events ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE events DROP COLUMN IF EXISTS time_zone;
+-- +goose StatementEnd